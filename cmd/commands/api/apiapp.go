@@ -59,6 +59,9 @@ var CmdApiapp = &commands.Command{
 	    └── {{"models"|foldername}}
 	          └── object.go
 	          └── user.go
+
+  Use {{"-dry-run"|bold}} to list the files without writing them, {{"-force"|bold}} to overwrite an existing
+  application and {{"-skip-existing"|bold}} to only add the missing files.
 `,
 	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
 	Run:    createAPI,
@@ -560,12 +563,11 @@ func init() {
 	CmdApiapp.Flag.Var(&generate.SQLConn, "conn", "Connection string used by the driver to connect to a database instance.")
 	CmdApiapp.Flag.Var(&gopath, "gopath", "Support go path,default false")
 	CmdApiapp.Flag.Var(&beegoVersion, "beego", "set beego version,only take effect by go mod")
+	utils.AddWriteFlags(&CmdApiapp.Flag)
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdApiapp)
 }
 
 func createAPI(cmd *commands.Command, args []string) int {
-	output := cmd.Out()
-
 	if len(args) < 1 {
 		beeLogger.Log.Fatal("Argument [appname] is missing")
 	}
@@ -595,7 +597,8 @@ func createAPI(cmd *commands.Command, args []string) int {
 		}
 	}
 
-	if utils.IsExist(appPath) {
+	// -dry-run, -force and -skip-existing decide what happens to the existing files
+	if utils.IsExist(appPath) && !utils.DryRun && !utils.Force && !utils.SkipExisting {
 		beeLogger.Log.Errorf(colors.Bold("Application '%s' already exists"), appPath)
 		beeLogger.Log.Warn(colors.Bold("Do you want to overwrite it? [Yes|No] "))
 		if !utils.AskForConfirmation() {
@@ -613,26 +616,19 @@ func createAPI(cmd *commands.Command, args []string) int {
 
 	beeLogger.Log.Info("Creating API...")
 
-	os.MkdirAll(appPath, 0755)
+	utils.CreateGeneratedDir(appPath)
 	if gopath != `true` { //generate first for calc model name
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "go.mod"), "\x1b[0m")
-		utils.WriteToFile(path.Join(appPath, "go.mod"), fmt.Sprintf(goMod, packPath, utils.GetGoVersionSkipMinor(), beegoVersion.String()))
+		utils.WriteGeneratedFile(path.Join(appPath, "go.mod"), fmt.Sprintf(goMod, packPath, utils.GetGoVersionSkipMinor(), beegoVersion.String()), utils.OverwriteExisting)
 	}
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", appPath, "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "conf"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "conf"), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "controllers"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "controllers"), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "tests"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "tests"), "\x1b[0m")
+	utils.CreateGeneratedDir(path.Join(appPath, "conf"))
+	utils.CreateGeneratedDir(path.Join(appPath, "controllers"))
+	utils.CreateGeneratedDir(path.Join(appPath, "tests"))
 
 	if generate.SQLConn != "" {
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "conf", "app.conf"), "\x1b[0m")
 		confContent := strings.Replace(apiconf, "{{.Appname}}", appName, -1)
 		confContent = strings.Replace(confContent, "{{.SQLConnStr}}", generate.SQLConn.String(), -1)
-		utils.WriteToFile(path.Join(appPath, "conf", "app.conf"), confContent)
+		utils.WriteGeneratedFile(path.Join(appPath, "conf", "app.conf"), confContent, utils.OverwriteExisting)
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "main.go"), "\x1b[0m")
 		mainGoContent := strings.Replace(apiMainconngo, "{{.Appname}}", packPath, -1)
 		mainGoContent = strings.Replace(mainGoContent, "{{.DriverName}}", string(generate.SQLDriver), -1)
 		if generate.SQLDriver == "mysql" {
@@ -640,54 +636,45 @@ func createAPI(cmd *commands.Command, args []string) int {
 		} else if generate.SQLDriver == "postgres" {
 			mainGoContent = strings.Replace(mainGoContent, "{{.DriverPkg}}", `_ "github.com/lib/pq"`, -1)
 		}
-		utils.WriteToFile(path.Join(appPath, "main.go"),
+		utils.WriteGeneratedFile(path.Join(appPath, "main.go"),
 			strings.Replace(
 				mainGoContent,
 				"{{.conn}}",
 				generate.SQLConn.String(),
 				-1,
 			),
+			utils.OverwriteExisting,
 		)
 		beeLogger.Log.Infof("Using '%s' as 'driver'", generate.SQLDriver)
 		beeLogger.Log.Infof("Using '%s' as 'conn'", generate.SQLConn)
 		beeLogger.Log.Infof("Using '%s' as 'tables'", generate.Tables)
 		generate.GenerateAppcode(string(generate.SQLDriver), string(generate.SQLConn), "3", string(generate.Tables), appPath)
 	} else {
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "conf", "app.conf"), "\x1b[0m")
 		confContent := strings.Replace(apiconf, "{{.Appname}}", appName, -1)
 		confContent = strings.Replace(confContent, "{{.SQLConnStr}}", "", -1)
-		utils.WriteToFile(path.Join(appPath, "conf", "app.conf"), confContent)
+		utils.WriteGeneratedFile(path.Join(appPath, "conf", "app.conf"), confContent, utils.OverwriteExisting)
 
-		os.Mkdir(path.Join(appPath, "models"), 0755)
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "models"), "\x1b[0m")
-		os.Mkdir(path.Join(appPath, "routers"), 0755)
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "routers")+string(path.Separator), "\x1b[0m")
+		utils.CreateGeneratedDir(path.Join(appPath, "models"))
+		utils.CreateGeneratedDir(path.Join(appPath, "routers"))
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "controllers", "object.go"), "\x1b[0m")
-		utils.WriteToFile(path.Join(appPath, "controllers", "object.go"),
-			strings.Replace(apiControllers, "{{.Appname}}", packPath, -1))
+		utils.WriteGeneratedFile(path.Join(appPath, "controllers", "object.go"),
+			strings.Replace(apiControllers, "{{.Appname}}", packPath, -1), utils.OverwriteExisting)
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "controllers", "user.go"), "\x1b[0m")
-		utils.WriteToFile(path.Join(appPath, "controllers", "user.go"),
-			strings.Replace(apiControllers2, "{{.Appname}}", packPath, -1))
+		utils.WriteGeneratedFile(path.Join(appPath, "controllers", "user.go"),
+			strings.Replace(apiControllers2, "{{.Appname}}", packPath, -1), utils.OverwriteExisting)
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "tests", "default_test.go"), "\x1b[0m")
-		utils.WriteToFile(path.Join(appPath, "tests", "default_test.go"),
-			strings.Replace(apiTests, "{{.Appname}}", packPath, -1))
+		utils.WriteGeneratedFile(path.Join(appPath, "tests", "default_test.go"),
+			strings.Replace(apiTests, "{{.Appname}}", packPath, -1), utils.OverwriteExisting)
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "routers", "router.go"), "\x1b[0m")
-		utils.WriteToFile(path.Join(appPath, "routers", "router.go"),
-			strings.Replace(apirouter, "{{.Appname}}", packPath, -1))
+		utils.WriteGeneratedFile(path.Join(appPath, "routers", "router.go"),
+			strings.Replace(apirouter, "{{.Appname}}", packPath, -1), utils.OverwriteExisting)
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "models", "object.go"), "\x1b[0m")
-		utils.WriteToFile(path.Join(appPath, "models", "object.go"), APIModels)
+		utils.WriteGeneratedFile(path.Join(appPath, "models", "object.go"), APIModels, utils.OverwriteExisting)
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "models", "user.go"), "\x1b[0m")
-		utils.WriteToFile(path.Join(appPath, "models", "user.go"), APIModels2)
+		utils.WriteGeneratedFile(path.Join(appPath, "models", "user.go"), APIModels2, utils.OverwriteExisting)
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "main.go"), "\x1b[0m")
-		utils.WriteToFile(path.Join(appPath, "main.go"),
-			strings.Replace(apiMaingo, "{{.Appname}}", packPath, -1))
+		utils.WriteGeneratedFile(path.Join(appPath, "main.go"),
+			strings.Replace(apiMaingo, "{{.Appname}}", packPath, -1), utils.OverwriteExisting)
 	}
 	beeLogger.Log.Success("New API successfully created!")
 	return 0
@@ -19,6 +19,7 @@ import (
 	"github.com/beego/bee/v2/cmd/commands"
 	"github.com/beego/bee/v2/internal/app/module/beegopro"
 	"github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
 )
 
 var CmdBeegoPro = &commands.Command{
@@ -33,6 +34,8 @@ func init() {
 	CmdBeegoPro.Flag.Var(&beegopro.SQLMode, "sqlmode", "sql mode")
 	CmdBeegoPro.Flag.Var(&beegopro.SQLModePath, "sqlpath", "sql mode path")
 	CmdBeegoPro.Flag.Var(&beegopro.GitRemotePath, "url", "git remote path")
+	CmdBeegoPro.Flag.Var(&beegopro.DatabaseEnv, "env", "database environment of the Beefile to use")
+	utils.AddWriteFlags(&CmdBeegoPro.Flag)
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdBeegoPro)
 }
 
@@ -15,6 +15,7 @@ package generate
 
 import (
 	"os"
+	"path"
 	"strings"
 
 	"github.com/beego/bee/v2/cmd/commands"
@@ -37,18 +38,43 @@ var CmdGenerate = &commands.Command{
 
      $ bee generate model [modelname] [-fields="name:type"]
 
+  ▶ {{"To generate a Model from a sample JSON payload or a JSON Schema:"|bold}}
+
+     $ bee generate model [modelname] -from-json=sample.json
+     $ bee generate model [modelname] -from-schema=schema.json
+
+  Nested objects become structs not stored by the ORM, and optional or nullable
+  properties get the {{"null"|bold}} orm option and {{"omitempty"|bold}}.
+
   ▶ {{"To generate a controller:"|bold}}
 
      $ bee generate controller [controllerfile]
 
   ▶ {{"To generate a CRUD view:"|bold}}
 
-     $ bee generate view [viewpath]
+     $ bee generate view [viewpath] [-fields="title:string,body:text"]
+
+  Without {{"-fields"|bold}}, the fields of the views are read from the model of the same name.
+  When the model exists, a controller serving the views is generated along.
 
   ▶ {{"To generate a migration file for making database schema updates:"|bold}}
 
      $ bee generate migration [migrationfile] [-fields="name:type"]
 
+  ▶ {{"To generate a migration altering an existing table:"|bold}}
+
+     $ bee generate migration [migrationfile] [-table=tablename] [-add="age:int,nickname:string:64"] [-drop="name:type"] [-rename="old:new"] [-index="col1,col2"] [-unique="col"]
+
+     On MySQL, -rename uses RENAME COLUMN, which needs MySQL 8.0 or MariaDB 10.5.2.
+
+  ▶ {{"To generate a migration by diffing the models against the database schema:"|bold}}
+
+     $ bee generate migration [migrationfile] -auto [-driver=mysql] [-conn="root:@tcp(127.0.0.1:3306)/test"]
+
+  ▶ {{"To generate a seed file filling the database with data:"|bold}}
+
+     $ bee generate seed [seedname] [-format=go|sql|yaml]
+
   ▶ {{"To generate swagger doc file:"|bold}}
 
      $ bee generate docs
@@ -57,13 +83,95 @@ var CmdGenerate = &commands.Command{
 
      $ bee generate routers [-ctrlDir=/path/to/controller/directory] [-routersFile=/path/to/routers/file.go] [-routersPkg=myPackage]
 
+  ▶ {{"To generate an API from a Swagger 2.0 or OpenAPI 3 specification:"|bold}}
+
+     $ bee generate fromspec -spec=openapi.yaml
+
+  fromspec writes the models, a controller per first path segment with the {{"@router"|bold}}, {{"@Param"|bold}},
+  {{"@Success"|bold}}, {{"@Failure"|bold}} and {{"@Tags"|bold}} annotations of its operations, and routers/router.go. Run bee generate routers
+  to register the routes, and bee generate docs to get the specification back.
+
+  ▶ {{"To generate a client of the API:"|bold}}
+
+     $ bee generate client [-lang=go|ts] [-out=client]
+
+  The client is generated from the annotations read by bee generate docs, with a service per
+  controller and a method per operation. Errors of non-2xx responses are {{"APIError"|bold}} values.
+  With {{"-lang=ts"|bold}}, models become TypeScript interfaces and enums, and operations call the API
+  with fetch. Fields with omitempty are optional.
+  Generated files are overwritten: run the command again after changing the API.
+
   ▶ {{"To generate a test case:"|bold}}
 
      $ bee generate test [routerfile]
 
+  The test command writes a table-driven test per controller included in the router file
+  (routers/router.go by default) in the tests directory, with a case per {{"@router"|bold}} annotation.
+  The routes of the annotations are registered by the file {{"bee generate routers"|bold}} writes: run it
+  first, or the cases get 404 Not Found. Every case expects 200 OK, set the status of the routes that
+  need a database, or register one in tests/setup_test.go.
+
+  ▶ {{"To customize the templates of the generators:"|bold}}
+
+     $ bee generate templates list
+     $ bee generate templates eject [-force] [name...]
+
+  eject writes the built-in templates, and a README describing their placeholders, to the
+  templates directory of the Beefile ({{"templates"|bold}} by default). Files found there replace
+  the built-in templates with the same name.
+
   ▶ {{"To generate appcode based on an existing database:"|bold}}
 
      $ bee generate appcode [-tables=""] [-driver=mysql] [-conn="root:@tcp(127.0.0.1:3306)/test"] [-level=3]
+     $ bee generate appcode -driver=postgres -schema=public,sales [-tables="sales.orders"]
+
+  With PostgreSQL, appcode reads the tables of the current schema, or of the schemas given with
+  {{"-schema"|bold}} ({{"*"|bold}} for all of them). The models of tables found in several schemas are prefixed
+  with their schema, and TableName returns the name qualified with the schema.
+
+  Columns of a MySQL enum, a PostgreSQL enum type, or restricted to a list of strings by a
+  CHECK (column IN (...)) constraint get a Go type with a constant per value, a {{"Valid"|bold}} method and
+  text marshaling, written to models/<name>_enum.go.
+
+  ▶ {{"To generate repositories the controllers depend on:"|bold}}
+
+     $ bee generate appcode -repository [-driver=mysql] [-conn="root:@tcp(127.0.0.1:3306)/test"]
+     $ bee generate scaffold post -fields="title:string" -repository
+
+  {{"-repository"|bold}} writes, next to each model, a {{"<Model>Repository"|bold}} interface whose
+  {{"New<Model>Repository"|bold}} implementation uses the ORM, and an in-memory {{"Fake<Model>Repository"|bold}}.
+  The controllers call the {{"Repository"|bold}} field, the ORM one when it is nil: set it to a fake
+  to test them without a database.
+
+  The fields of the models get {{"valid"|bold}} tags for beego's validation package: {{"Required"|bold}} for the
+  NOT NULL strings and times without a default, and {{"MaxSize(n)"|bold}} for the varchar(n) columns, or the
+  string:n fields of {{"-fields"|bold}}. The Post and Put actions of the controllers check them, along with
+  the unique columns, and answer {{"400"|bold}} with the list of the invalid fields.
+
+  scaffold, appcode and migration -auto use the database of the Beefile environment named by
+  {{"-env"|bold}} (BEEGO_RUNMODE, then dev, by default) unless {{"-driver"|bold}} and {{"-conn"|bold}} are given.
+
+  ▶ {{"To preview or control the files written by a generator:"|bold}}
+
+     $ bee generate controller user -dry-run
+     $ bee generate scaffold post -fields="title:string" -force
+     $ bee generate docs -skip-existing
+
+  {{"-dry-run"|bold}} prints a unified diff of each file instead of writing it. {{"-force"|bold}} overwrites
+  the existing files and {{"-skip-existing"|bold}} keeps them unchanged. Without them, each generator
+  keeps its own behaviour: controller, model, migration, seed and view stop on an existing file,
+  appcode and scaffold merge it, tests and fromspec keep it, and routers, docs and client overwrite it.
+  The flags are also accepted by bee new, bee api, bee hprose and bee pro gen.
+
+  ▶ {{"To regenerate files you have edited:"|bold}}
+
+  The code between a {{"// bee:begin <name>"|bold}} and a {{"// bee:end"|bold}} comment is a protected region,
+  kept when the file is generated again. appcode and scaffold also keep the last version they wrote of
+  each file in {{".bee/generated"|bold}}, at the root of the application: when a file is generated again,
+  your changes since then are merged with the new version, and overlapping changes are left between
+  {{"<<<<<<< existing"|bold}} and {{">>>>>>> generated"|bold}} markers. They ask before overwriting a file they
+  did not write. Commit the .bee directory along with the code.
+  {{"-force"|bold}} replaces the file with the new version, keeping only the protected regions.
 `,
 	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
 	Run:    GenerateCode,
@@ -71,11 +179,28 @@ var CmdGenerate = &commands.Command{
 
 func init() {
 	CmdGenerate.Flag.Var(&generate.Tables, "tables", "List of table names separated by a comma.")
+	CmdGenerate.Flag.Var(&generate.Schemas, "schema", "List of PostgreSQL schemas separated by a comma, or * for all of them. Defaults to the current schema.")
 	CmdGenerate.Flag.Var(&generate.SQLDriver, "driver", "Database SQLDriver. Either mysql, postgres or sqlite.")
 	CmdGenerate.Flag.Var(&generate.SQLConn, "conn", "Connection string used by the SQLDriver to connect to a database instance.")
+	CmdGenerate.Flag.Var(&generate.DatabaseEnv, "env", "Database environment of the Beefile to use. Defaults to BEEGO_RUNMODE, then dev.")
 	CmdGenerate.Flag.Var(&generate.Level, "level", "Either 1, 2 or 3. i.e. 1=models; 2=models and controllers; 3=models, controllers and routers.")
 	CmdGenerate.Flag.Var(&generate.Fields, "fields", "List of table Fields.")
 	CmdGenerate.Flag.Var(&generate.DDL, "ddl", "Generate DDL Migration")
+	CmdGenerate.Flag.Var(&generate.AlterTable, "table", "Table altered by the migration. Defaults to the migration name.")
+	CmdGenerate.Flag.Var(&generate.AddFields, "add", "List of columns to add to the table, using the same format as -fields. They are added as NULL columns.")
+	CmdGenerate.Flag.Var(&generate.DropFields, "drop", "List of columns to drop from the table. Give their type (name:type) so they can be restored on rollback.")
+	CmdGenerate.Flag.Var(&generate.RenameFields, "rename", "List of columns to rename, as old:new pairs. Needs MySQL 8.0 or MariaDB 10.5.2 on MySQL.")
+	CmdGenerate.Flag.Var(&generate.IndexFields, "index", "List of columns to create an index on.")
+	CmdGenerate.Flag.Var(&generate.UniqueFields, "unique", "List of columns to create a unique index on.")
+	CmdGenerate.Flag.Var(&generate.FromJSON, "from-json", "Sample JSON payload the model is inferred from.")
+	CmdGenerate.Flag.Var(&generate.FromSchema, "from-schema", "JSON Schema the model is generated from.")
+	CmdGenerate.Flag.Var(&generate.Spec, "spec", "Swagger 2.0 or OpenAPI 3 specification, in JSON or YAML.")
+	CmdGenerate.Flag.Var(&generate.ClientLang, "lang", "Language of the generated client: go or ts. Defaults to go.")
+	CmdGenerate.Flag.Var(&generate.ClientOut, "out", "Output directory of the generated client. Defaults to client.")
+	CmdGenerate.Flag.Var(&generate.SeedFormat, "format", "Format of the seed file. Either go, sql or yaml.")
+	utils.AddWriteFlags(&CmdGenerate.Flag)
+	CmdGenerate.Flag.BoolVar(&generate.AutoMigration, "auto", false, "Generate the migration by diffing the models against the database schema")
+	CmdGenerate.Flag.BoolVar(&generate.Repository, "repository", false, "Generate a repository interface and an in-memory fake per model, and controllers using it")
 
 	// bee generate routers
 	CmdGenerate.Flag.Var(&generate.ControllerDirectory, "ctrlDir",
@@ -99,19 +224,30 @@ func GenerateCode(cmd *commands.Command, args []string) int {
 	case "scaffold":
 		scaffold(cmd, args, currpath)
 	case "docs":
+		cmd.Flag.Parse(args[1:])
 		swaggergen.GenerateDocs(currpath)
 	case "appcode":
 		appCode(cmd, args, currpath)
 	case "migration":
 		migration(cmd, args, currpath)
+	case "seed":
+		seed(cmd, args, currpath)
 	case "controller":
-		controller(args, currpath)
+		controller(cmd, args, currpath)
 	case "model":
 		model(cmd, args, currpath)
 	case "view":
-		view(args, currpath)
+		view(cmd, args, currpath)
 	case "routers":
 		genRouters(cmd, args)
+	case "test":
+		test(cmd, args, currpath)
+	case "fromspec":
+		fromSpec(cmd, args, currpath)
+	case "client":
+		client(cmd, args, currpath)
+	case "templates":
+		templates(cmd, args, currpath)
 	default:
 		beeLogger.Log.Fatal("Command is missing")
 	}
@@ -129,12 +265,24 @@ func genRouters(cmd *commands.Command, args []string) {
 	generate.GenRouters()
 }
 
+// selectDatabase selects the database environment of the commands connecting to
+// the database, unless -driver and -conn are given
+func selectDatabase() {
+	if err := config.SelectDatabase(generate.DatabaseEnv.String()); err != nil {
+		if generate.SQLDriver == "" || generate.SQLConn == "" {
+			beeLogger.Log.Fatal(err.Error())
+		}
+		beeLogger.Log.Warn(err.Error())
+	}
+}
+
 func scaffold(cmd *commands.Command, args []string, currpath string) {
 	if len(args) < 2 {
 		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
 	}
 
 	cmd.Flag.Parse(args[2:])
+	selectDatabase()
 	if generate.SQLDriver == "" {
 		generate.SQLDriver = utils.DocValue(config.Conf.Database.Driver)
 		if generate.SQLDriver == "" {
@@ -157,6 +305,7 @@ func scaffold(cmd *commands.Command, args []string, currpath string) {
 
 func appCode(cmd *commands.Command, args []string, currpath string) {
 	cmd.Flag.Parse(args[1:])
+	selectDatabase()
 	if generate.SQLDriver == "" {
 		generate.SQLDriver = utils.DocValue(config.Conf.Database.Driver)
 		if generate.SQLDriver == "" {
@@ -179,6 +328,9 @@ func appCode(cmd *commands.Command, args []string, currpath string) {
 	beeLogger.Log.Infof("Using '%s' as 'SQLDriver'", generate.SQLDriver)
 	beeLogger.Log.Infof("Using '%s' as 'SQLConn'", generate.SQLConn)
 	beeLogger.Log.Infof("Using '%s' as 'Tables'", generate.Tables)
+	if generate.Schemas != "" {
+		beeLogger.Log.Infof("Using '%s' as 'Schemas'", generate.Schemas)
+	}
 	beeLogger.Log.Infof("Using '%s' as 'Level'", generate.Level)
 	generate.GenerateAppcode(generate.SQLDriver.String(), generate.SQLConn.String(), generate.Level.String(), generate.Tables.String(), currpath)
 }
@@ -188,27 +340,75 @@ func migration(cmd *commands.Command, args []string, currpath string) {
 		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
 	}
 	cmd.Flag.Parse(args[2:])
+	if generate.AutoMigration {
+		selectDatabase()
+	} else if err := config.SelectDatabase(generate.DatabaseEnv.String()); err != nil {
+		// only the driver is used, to choose the SQL dialect
+		beeLogger.Log.Warn(err.Error())
+	}
 	mname := args[1]
 
 	beeLogger.Log.Infof("Using '%s' as migration name", mname)
 
+	if generate.SQLDriver == "" {
+		generate.SQLDriver = utils.DocValue(config.Conf.Database.Driver)
+		if generate.SQLDriver == "" {
+			generate.SQLDriver = "mysql"
+		}
+	}
+
 	upsql := ""
 	downsql := ""
-	if generate.Fields != "" {
+	if generate.AutoMigration {
+		if generate.SQLConn == "" {
+			generate.SQLConn = utils.DocValue(config.Conf.Database.Conn)
+			if generate.SQLConn == "" {
+				generate.SQLConn = "root:@tcp(127.0.0.1:3306)/test"
+			}
+		}
+		beeLogger.Log.Infof("Using '%s' as 'SQLDriver'", generate.SQLDriver)
+		upsql, downsql = generate.GenerateAutoMigration(generate.SQLDriver.String(), generate.SQLConn.String(),
+			path.Join(currpath, config.Conf.DirStruct.Models))
+		if upsql == "" {
+			beeLogger.Log.Success("Database schema is already up to date with the models")
+			os.Exit(0)
+		}
+	} else if generate.Fields != "" {
 		dbMigrator := generate.NewDBDriver()
 		upsql = dbMigrator.GenerateCreateUp(mname)
 		downsql = dbMigrator.GenerateCreateDown(mname)
+	} else if generate.HasAlterFields() {
+		tableName := mname
+		if generate.AlterTable != "" {
+			tableName = generate.AlterTable.String()
+		}
+		beeLogger.Log.Infof("Using '%s' as table name", tableName)
+		dbMigrator := generate.NewDBDriver()
+		upsql, downsql = dbMigrator.GenerateAlter(tableName)
 	}
 	generate.GenerateMigration(mname, upsql, downsql, currpath)
 }
 
-func controller(args []string, currpath string) {
-	if len(args) == 2 {
-		cname := args[1]
-		generate.GenerateController(cname, currpath)
-	} else {
+func seed(cmd *commands.Command, args []string, currpath string) {
+	if len(args) < 2 {
 		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
 	}
+	cmd.Flag.Parse(args[2:])
+	sname := args[1]
+
+	beeLogger.Log.Infof("Using '%s' as seed name", sname)
+	generate.GenerateSeed(sname, generate.SeedFormat.String(), currpath)
+}
+
+func controller(cmd *commands.Command, args []string, currpath string) {
+	if len(args) < 2 {
+		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
+	}
+	cmd.Flag.Parse(args[2:])
+	if cmd.Flag.NArg() > 0 {
+		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
+	}
+	generate.GenerateController(args[1], currpath)
 }
 
 func model(cmd *commands.Command, args []string, currpath string) {
@@ -216,19 +416,63 @@ func model(cmd *commands.Command, args []string, currpath string) {
 		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
 	}
 	cmd.Flag.Parse(args[2:])
-	if generate.Fields == "" {
+	sname := args[1]
+	switch {
+	case generate.FromJSON != "":
+		generate.GenerateModelFromJSON(sname, generate.FromJSON.String(), currpath)
+	case generate.FromSchema != "":
+		generate.GenerateModelFromSchema(sname, generate.FromSchema.String(), currpath)
+	case generate.Fields != "":
+		generate.GenerateModel(sname, generate.Fields.String(), currpath)
+	default:
 		beeLogger.Log.Hint("Fields option should not be empty, i.e. -Fields=\"title:string,body:text\"")
 		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
 	}
-	sname := args[1]
-	generate.GenerateModel(sname, generate.Fields.String(), currpath)
 }
 
-func view(args []string, currpath string) {
-	if len(args) == 2 {
-		cname := args[1]
-		generate.GenerateView(cname, currpath)
-	} else {
+func view(cmd *commands.Command, args []string, currpath string) {
+	if len(args) < 2 {
+		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
+	}
+	cmd.Flag.Parse(args[2:])
+	generate.GenerateView(args[1], generate.Fields.String(), currpath)
+}
+
+func fromSpec(cmd *commands.Command, args []string, currpath string) {
+	cmd.Flag.Parse(args[1:])
+	generate.GenerateFromSpec(generate.Spec.String(), currpath)
+}
+
+func client(cmd *commands.Command, args []string, currpath string) {
+	cmd.Flag.Parse(args[1:])
+	generate.GenerateClient(generate.ClientLang.String(), generate.ClientOut.String(), currpath)
+}
+
+func test(cmd *commands.Command, args []string, currpath string) {
+	var routerFile string
+	if len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+		routerFile = args[1]
+		args = args[1:]
+	}
+	cmd.Flag.Parse(args[1:])
+	if cmd.Flag.NArg() > 0 {
 		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
 	}
+	generate.GenerateTests(routerFile, currpath)
+}
+
+func templates(cmd *commands.Command, args []string, currpath string) {
+	if len(args) < 2 {
+		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
+	}
+	switch args[1] {
+	case "eject":
+		cmd.Flag.Parse(args[2:])
+		generate.EjectTemplates(cmd.Flag.Args())
+	case "list":
+		generate.ListTemplates()
+		os.Exit(0)
+	default:
+		beeLogger.Log.Fatalf("Unknown templates command '%s'. Run: bee help generate", args[1])
+	}
 }
@@ -62,11 +62,11 @@ func init() {
 	CmdHproseapp.Flag.Var(&generate.SQLConn, "conn", "Connection string used by the driver to connect to a database instance.")
 	CmdHproseapp.Flag.Var(&gopath, "gopath", "Support go path,default false")
 	CmdHproseapp.Flag.Var(&beegoVersion, "beego", "set beego version,only take effect by go mod")
+	utils.AddWriteFlags(&CmdHproseapp.Flag)
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdHproseapp)
 }
 
 func createhprose(cmd *commands.Command, args []string) int {
-	output := cmd.Out()
 	if len(args) == 0 {
 		beeLogger.Log.Fatal("Argument [appname] is missing")
 	}
@@ -97,7 +97,8 @@ func createhprose(cmd *commands.Command, args []string) int {
 		}
 	}
 
-	if utils.IsExist(apppath) {
+	// -dry-run, -force and -skip-existing decide what happens to the existing files
+	if utils.IsExist(apppath) && !utils.DryRun && !utils.Force && !utils.SkipExisting {
 		beeLogger.Log.Errorf(colors.Bold("Application '%s' already exists"), apppath)
 		beeLogger.Log.Warn(colors.Bold("Do you want to overwrite it? [Yes|No] "))
 		if !utils.AskForConfirmation() {
@@ -110,17 +111,13 @@ func createhprose(cmd *commands.Command, args []string) int {
 	}
 	beeLogger.Log.Info("Creating Hprose application...")
 
-	os.MkdirAll(apppath, 0755)
+	utils.CreateGeneratedDir(apppath)
 	if gopath != `true` { //generate first for calc model name
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(apppath, "go.mod"), "\x1b[0m")
-		utils.WriteToFile(path.Join(apppath, "go.mod"), fmt.Sprintf(goMod, packpath, utils.GetGoVersionSkipMinor(), beegoVersion.String()))
+		utils.WriteGeneratedFile(path.Join(apppath, "go.mod"), fmt.Sprintf(goMod, packpath, utils.GetGoVersionSkipMinor(), beegoVersion.String()), utils.OverwriteExisting)
 	}
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", apppath, "\x1b[0m")
-	os.Mkdir(path.Join(apppath, "conf"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(apppath, "conf"), "\x1b[0m")
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(apppath, "conf", "app.conf"), "\x1b[0m")
-	utils.WriteToFile(path.Join(apppath, "conf", "app.conf"),
-		strings.Replace(generate.Hproseconf, "{{.Appname}}", args[0], -1))
+	utils.CreateGeneratedDir(path.Join(apppath, "conf"))
+	utils.WriteGeneratedFile(path.Join(apppath, "conf", "app.conf"),
+		strings.Replace(generate.Hproseconf, "{{.Appname}}", args[0], -1), utils.OverwriteExisting)
 
 	if generate.SQLConn != "" {
 		beeLogger.Log.Infof("Using '%s' as 'driver'", generate.SQLDriver)
@@ -128,7 +125,6 @@ func createhprose(cmd *commands.Command, args []string) int {
 		beeLogger.Log.Infof("Using '%s' as 'tables'", generate.Tables)
 		generate.GenerateHproseAppcode(string(generate.SQLDriver), string(generate.SQLConn), "1", string(generate.Tables), path.Join(curpath, args[0]))
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(apppath, "main.go"), "\x1b[0m")
 		maingoContent := strings.Replace(generate.HproseMainconngo, "{{.Appname}}", packpath, -1)
 		maingoContent = strings.Replace(maingoContent, "{{.DriverName}}", string(generate.SQLDriver), -1)
 		maingoContent = strings.Replace(maingoContent, "{{HproseFunctionList}}", strings.Join(generate.HproseAddFunctions, ""), -1)
@@ -137,27 +133,24 @@ func createhprose(cmd *commands.Command, args []string) int {
 		} else if generate.SQLDriver == "postgres" {
 			maingoContent = strings.Replace(maingoContent, "{{.DriverPkg}}", `_ "github.com/lib/pq"`, -1)
 		}
-		utils.WriteToFile(path.Join(apppath, "main.go"),
+		utils.WriteGeneratedFile(path.Join(apppath, "main.go"),
 			strings.Replace(
 				maingoContent,
 				"{{.conn}}",
 				generate.SQLConn.String(),
 				-1,
 			),
+			utils.OverwriteExisting,
 		)
 	} else {
-		os.Mkdir(path.Join(apppath, "models"), 0755)
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(apppath, "models"), "\x1b[0m")
+		utils.CreateGeneratedDir(path.Join(apppath, "models"))
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(apppath, "models", "object.go"), "\x1b[0m")
-		utils.WriteToFile(path.Join(apppath, "models", "object.go"), apiapp.APIModels)
+		utils.WriteGeneratedFile(path.Join(apppath, "models", "object.go"), apiapp.APIModels, utils.OverwriteExisting)
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(apppath, "models", "user.go"), "\x1b[0m")
-		utils.WriteToFile(path.Join(apppath, "models", "user.go"), apiapp.APIModels2)
+		utils.WriteGeneratedFile(path.Join(apppath, "models", "user.go"), apiapp.APIModels2, utils.OverwriteExisting)
 
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(apppath, "main.go"), "\x1b[0m")
-		utils.WriteToFile(path.Join(apppath, "main.go"),
-			strings.Replace(generate.HproseMaingo, "{{.Appname}}", packpath, -1))
+		utils.WriteGeneratedFile(path.Join(apppath, "main.go"),
+			strings.Replace(generate.HproseMaingo, "{{.Appname}}", packpath, -1), utils.OverwriteExisting)
 	}
 	beeLogger.Log.Success("New Hprose application successfully created!")
 	return 0
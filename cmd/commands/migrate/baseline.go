@@ -0,0 +1,68 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+)
+
+// MigrateBaseline marks the migrations up to and including the named one as
+// applied without running them, for databases whose schema already exists
+func MigrateBaseline(currpath, driver, connStr, dir, to string) {
+	if dir == "" {
+		dir = path.Join(currpath, "database", "migrations")
+	}
+	if to == "" {
+		beeLogger.Log.Hint("Name the last migration the database schema already contains, i.e. -to=\"20200102_150405_users\"")
+		beeLogger.Log.Fatal("The -to option is required")
+	}
+
+	db, err := sql.Open(driver, connStr)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to database using '%s': %s", connStr, err)
+	}
+	defer db.Close()
+	checkForSchemaUpdateTable(db, driver)
+
+	baselined, err := baselineMigrations(db, driver, dir, to)
+	if err != nil {
+		beeLogger.Log.Fatal(err.Error())
+	}
+	beeLogger.Log.Infof("%d migration(s) marked as applied", baselined)
+}
+
+// baselineMigrations records the migrations of dir up to and including the named
+// one as applied, along with their checksums, and returns how many were not yet
+func baselineMigrations(db *sql.DB, driver, dir, to string) (int, error) {
+	sources := readMigrationSources(dir)
+	last := findMigration(sources, to)
+	if last < 0 {
+		return 0, fmt.Errorf("Could not find migration '%s'", to)
+	}
+	baselined := 0
+	for _, s := range sources[:last+1] {
+		if _, ok := getAppliedStatements(db, driver, s.Name); ok {
+			continue
+		}
+		insertAppliedMigration(db, driver, s.Name, "")
+		beeLogger.Log.Infof("Marked '%s' as applied", s.Name)
+		baselined++
+	}
+	recordChecksums(db, driver, readMigrationFiles(dir))
+	return baselined, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/beego/bee/v2/internal/pkg/sqlite"
+)
+
+// testBaselineMigration is a migration creating a table, to be formatted
+// with its type and the table
+const testBaselineMigration = `package main
+
+import "github.com/beego/beego/v2/client/orm/migration"
+
+type %[1]s struct {
+	migration.Migration
+}
+
+func init() {
+	migration.Register("%[1]s", &%[1]s{})
+}
+
+func (m *%[1]s) Up() {
+	m.SQL("CREATE TABLE %[2]s (id int)")
+}
+
+func (m *%[1]s) Down() {
+	m.SQL("DROP TABLE %[2]s")
+}
+`
+
+func TestBaselineMigrations(t *testing.T) {
+	if !sqlite.Supported {
+		t.Skip("SQLite needs cgo")
+	}
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"20200101_120000_users.go":    fmt.Sprintf(testBaselineMigration, "Users_20200101_120000", "users"),
+		"20200102_120000_posts.go":    fmt.Sprintf(testBaselineMigration, "Posts_20200102_120000", "posts"),
+		"20200103_120000_comments.go": fmt.Sprintf(testBaselineMigration, "Comments_20200103_120000", "comments"),
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := sql.Open(sqlite.DriverName, filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	checkForSchemaUpdateTable(db, sqlite.DriverName)
+	applied := func() map[string]string {
+		rows, err := db.Query("SELECT name, checksum FROM migrations WHERE status = 'update' ORDER BY id_migration")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		checksums := make(map[string]string)
+		for rows.Next() {
+			var name string
+			var sum sql.NullString
+			if err := rows.Scan(&name, &sum); err != nil {
+				t.Fatal(err)
+			}
+			checksums[name] = sum.String
+		}
+		return checksums
+	}
+
+	if _, err := baselineMigrations(db, sqlite.DriverName, dir, "tags"); err == nil || !strings.Contains(err.Error(), "'tags'") {
+		t.Errorf("expected an unknown migration to be an error, got %v", err)
+	}
+	if n := len(applied()); n != 0 {
+		t.Fatalf("expected no migration to be marked as applied, got %d", n)
+	}
+
+	// the users migration was applied by bee migrate, before checksums were recorded
+	insertAppliedMigration(db, sqlite.DriverName, "Users_20200101_120000", "CREATE TABLE users (id int)")
+	n, err := baselineMigrations(db, sqlite.DriverName, dir, "posts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 migration to be marked as applied, got %d", n)
+	}
+	expected := map[string]string{
+		"Users_20200101_120000": checksum([]byte(files["20200101_120000_users.go"])),
+		"Posts_20200102_120000": checksum([]byte(files["20200102_120000_posts.go"])),
+	}
+	if checksums := applied(); !reflect.DeepEqual(checksums, expected) {
+		t.Errorf("expected the migrations up to posts to be applied with their checksums, got %v", checksums)
+	}
+	statements, _ := getAppliedStatements(db, sqlite.DriverName, "Users_20200101_120000")
+	if statements != "CREATE TABLE users (id int)" {
+		t.Errorf("expected the applied migration to be kept, got statements %q", statements)
+	}
+
+	// baselining again changes nothing
+	if n, err := baselineMigrations(db, sqlite.DriverName, dir, "20200102_120000_posts"); err != nil || n != 0 {
+		t.Errorf("expected no migration to be marked again, got %d, %v", n, err)
+	}
+	if checksums := applied(); !reflect.DeepEqual(checksums, expected) {
+		t.Errorf("expected the rows to be unchanged, got %v", checksums)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package migrate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+)
+
+// migrationRegisterRegex extracts the registered migration name from a migration source file
+var migrationRegisterRegex = regexp.MustCompile(`migration\.Register\(\s*"([^"]+)"`)
+
+// migrationFile is a migration source file found in the migrations directory
+type migrationFile struct {
+	Name     string
+	Path     string
+	Checksum string
+}
+
+// readMigrationFiles scans dir for migration source files and returns them
+// keyed by the name they register themselves with.
+func readMigrationFiles(dir string) map[string]*migrationFile {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not list migration files: %s", err)
+	}
+
+	files := make(map[string]*migrationFile)
+	for _, p := range paths {
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not read migration file: %s", err)
+		}
+		match := migrationRegisterRegex.FindSubmatch(content)
+		if match == nil {
+			continue
+		}
+		name := string(match[1])
+		files[name] = &migrationFile{
+			Name:     name,
+			Path:     p,
+			Checksum: checksum(content),
+		}
+	}
+	return files
+}
+
+// checksum returns the hex encoded SHA-256 of a migration file.
+// Line endings are normalized so that checkouts on Windows produce the same value.
+func checksum(content []byte) string {
+	sum := sha256.Sum256(bytes.Replace(content, []byte("\r\n"), []byte("\n"), -1))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyChecksums compares the recorded checksum of every applied migration
+// with its source file. It exits on mismatch unless force is set.
+func verifyChecksums(db *sql.DB, files map[string]*migrationFile, force bool) {
+	rows, err := db.Query("SELECT name, checksum FROM migrations WHERE status = 'update' AND checksum IS NOT NULL")
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not retrieve migration checksums: %s", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]string)
+	for rows.Next() {
+		var name, sum string
+		if err := rows.Scan(&name, &sum); err != nil {
+			beeLogger.Log.Fatalf("Could not read migration checksums: %s", err)
+		}
+		applied[name] = sum
+	}
+	if err := checkChecksums(applied, files, force); err != nil {
+		beeLogger.Log.Hint("Revert the changes, or run 'bee migrate repair' to accept the current files")
+		beeLogger.Log.Fatalf("%s", err)
+	}
+}
+
+// checkChecksums compares the checksums recorded for the applied migrations, by name,
+// with their source files. The mismatches are an error unless force is set.
+func checkChecksums(applied map[string]string, files map[string]*migrationFile, force bool) error {
+	var names []string
+	for name := range applied {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mismatch := 0
+	for _, name := range names {
+		f, ok := files[name]
+		if !ok {
+			beeLogger.Log.Warnf("Applied migration '%s' has no source file", name)
+			continue
+		}
+		if f.Checksum != applied[name] {
+			beeLogger.Log.Errorf("Migration '%s' has been modified after it was applied: %s", name, f.Path)
+			mismatch++
+		}
+	}
+	if mismatch == 0 {
+		return nil
+	}
+	if force {
+		beeLogger.Log.Warnf("Ignoring %d checksum mismatch(es) because of -force", mismatch)
+		return nil
+	}
+	return fmt.Errorf("Found %d migration(s) with mismatching checksums", mismatch)
+}
+
+// recordChecksums stores the checksum of applied migrations that do not have one yet
+func recordChecksums(db *sql.DB, driver string, files map[string]*migrationFile) {
+	updateMissingChecksums(db, driver, files)
+}
+
+// backfillChecksums records the checksum of the migrations applied before bee recorded
+// checksums. Their files may have changed since, which cannot be verified: the rows
+// are logged so that they can be checked.
+func backfillChecksums(db *sql.DB, driver string, files map[string]*migrationFile) {
+	for _, f := range updateMissingChecksums(db, driver, files) {
+		beeLogger.Log.Warnf("Recorded the checksum of migration '%s', applied without one, from its current file: %s", f.Name, f.Path)
+	}
+}
+
+// updateMissingChecksums stores the checksum of applied migrations that do not have
+// one yet, and returns their files
+func updateMissingChecksums(db *sql.DB, driver string, files map[string]*migrationFile) (updated []*migrationFile) {
+	query := "UPDATE migrations SET checksum = " + placeholder(driver, 1) +
+		" WHERE name = " + placeholder(driver, 2) + " AND status = 'update' AND checksum IS NULL"
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := files[name]
+		res, err := db.Exec(query, f.Checksum, f.Name)
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not record checksum of migration '%s': %s", f.Name, err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			updated = append(updated, f)
+		}
+	}
+	return
+}
+
+// repairChecksums overwrites the checksum of every applied migration with the
+// checksum of its current source file
+func repairChecksums(db *sql.DB, driver string, files map[string]*migrationFile) {
+	query := "UPDATE migrations SET checksum = " + placeholder(driver, 1) +
+		" WHERE name = " + placeholder(driver, 2) + " AND status = 'update'"
+	var repaired int64
+	for _, f := range files {
+		res, err := db.Exec(query, f.Checksum, f.Name)
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not repair checksum of migration '%s': %s", f.Name, err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			repaired += n
+		}
+	}
+	beeLogger.Log.Infof("Re-recorded checksums of %d applied migration(s)", repaired)
+}
+
+// placeholder returns the n-th bind parameter marker for driver
+func placeholder(driver string, n int) string {
+	if driver == "postgres" {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
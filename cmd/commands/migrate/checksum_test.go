@@ -0,0 +1,68 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package migrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChecksumNormalizesLineEndings(t *testing.T) {
+	unix := checksum([]byte("package main\n\nfunc main() {}\n"))
+	windows := checksum([]byte("package main\r\n\r\nfunc main() {}\r\n"))
+	if unix != windows {
+		t.Errorf("expected the same checksum for CRLF and LF files, got %s and %s", unix, windows)
+	}
+	if unix == checksum([]byte("package main\n\nfunc main() { }\n")) {
+		t.Error("expected a different checksum for a different file")
+	}
+}
+
+func TestCheckChecksums(t *testing.T) {
+	files := map[string]*migrationFile{
+		"a": {Name: "a", Path: "a.go", Checksum: checksum([]byte("a"))},
+		"b": {Name: "b", Path: "b.go", Checksum: checksum([]byte("b"))},
+	}
+	tests := []struct {
+		name    string
+		applied map[string]string
+		force   bool
+		err     string
+	}{
+		{"unchanged", map[string]string{"a": checksum([]byte("a")), "b": checksum([]byte("b"))}, false, ""},
+		{"missing file", map[string]string{"c": checksum([]byte("c"))}, false, ""},
+		{"mismatch", map[string]string{"a": checksum([]byte("a")), "b": checksum([]byte("b2"))}, false, "Found 1 migration(s)"},
+		{"mismatch forced", map[string]string{"a": checksum([]byte("a2")), "b": checksum([]byte("b2"))}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkChecksums(tt.applied, files, tt.force)
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestShowChecksumColumnSQL(t *testing.T) {
+	if q := showChecksumColumnSQL("postgres"); !strings.Contains(q, "table_schema = current_schema()") {
+		t.Errorf("expected the postgres query to be limited to the current schema: %s", q)
+	}
+	if q := showChecksumColumnSQL("sqlite3"); !strings.Contains(q, "pragma_table_info") {
+		t.Errorf("expected a SQLite query: %s", q)
+	}
+}
@@ -15,6 +15,8 @@ package migrate
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"path"
@@ -26,6 +28,7 @@ import (
 	"github.com/beego/bee/v2/cmd/commands"
 	"github.com/beego/bee/v2/cmd/commands/version"
 	"github.com/beego/bee/v2/config"
+	"github.com/beego/bee/v2/internal/pkg/sqlite"
 	"github.com/beego/bee/v2/utils"
 
 	beeLogger "github.com/beego/bee/v2/logger"
@@ -51,6 +54,48 @@ var CmdMigrate = &commands.Command{
   ▶ {{"To update your schema:"|bold}}
 
     $ bee migrate refresh [-driver=mysql] [-conn="root:@tcp(127.0.0.1:3306)/test"] [-dir="path/to/migration"]
+
+  ▶ {{"To re-baseline the checksums of applied migrations after editing them:"|bold}}
+
+    $ bee migrate repair [-driver=mysql] [-conn="root:@tcp(127.0.0.1:3306)/test"] [-dir="path/to/migration"]
+
+  ▶ {{"To adopt migrations on an existing database, marking them as applied up to the given one without running them:"|bold}}
+
+    $ bee migrate baseline -to="20200102_150405_users" [-driver=mysql] [-conn="root:@tcp(127.0.0.1:3306)/test"] [-dir="path/to/migration"]
+
+  ▶ {{"To squash the migrations created before the given one into a single baseline:"|bold}}
+
+    $ bee migrate squash -before="20200102_150405_users" [-driver=mysql] [-conn="root:@tcp(127.0.0.1:3306)/test"] [-dir="path/to/migration"]
+
+  Databases where all the squashed migrations were applied record the baseline as applied
+  the next time they are migrated, so it is never run against them. Squashing is refused when
+  the database has only some of them applied. It is refused too when a Down method is not made
+  of m.SQL("...") calls, unless {{"-force"|bold}} is given: the baseline Down() then fails until
+  it is completed by hand.
+
+  When the Beefile defines several {{"databases"|bold}}, the one named by {{"-env"|bold}} is used,
+  defaulting to BEEGO_RUNMODE, then dev. {{"-driver"|bold}}, {{"-conn"|bold}} and {{"-dir"|bold}} still take precedence.
+
+  ▶ {{"To dump the schema to a file after migrating, for it to be committed and reviewed:"|bold}}
+
+    $ bee migrate -schema=database/schema.sql
+
+  ▶ {{"To check that the committed schema dump matches the database:"|bold}}
+
+    $ bee migrate check-schema [-schema=database/schema.sql]
+
+  The dump file can also be set as {{"schema"|bold}} in the database block of the Beefile.
+
+  Migrations run in the order of the creation time in their file names. Every migration not
+  applied yet runs, even one older than the latest applied migration, e.g. merged from another branch.
+  Their statements are queued with m.SQL, or m.Migrate for a DDL specification.
+
+  On postgres and sqlite each migration runs in a transaction, and is rolled back entirely when one of its
+  statements fails. Add a {{"// bee:no-transaction"|bold}} line to a migration file to run it without one,
+  e.g. for CREATE INDEX CONCURRENTLY.
+
+  Each applied migration stores a checksum of its source file. bee refuses to run
+  when an applied migration file has been modified since, unless {{"-force"|bold}} is given.
 `,
 	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
 	Run:    RunMigration,
@@ -59,11 +104,21 @@ var CmdMigrate = &commands.Command{
 var mDriver utils.DocValue
 var mConn utils.DocValue
 var mDir utils.DocValue
+var mEnv utils.DocValue
+var mForce bool
+var mBefore utils.DocValue
+var mTo utils.DocValue
+var mSchema utils.DocValue
 
 func init() {
 	CmdMigrate.Flag.Var(&mDriver, "driver", "Database driver. Either mysql, postgres or sqlite.")
 	CmdMigrate.Flag.Var(&mConn, "conn", "Connection string used by the driver to connect to a database instance.")
 	CmdMigrate.Flag.Var(&mDir, "dir", "The directory where the migration files are stored")
+	CmdMigrate.Flag.Var(&mEnv, "env", "Database environment of the Beefile to use. Defaults to BEEGO_RUNMODE, then dev.")
+	CmdMigrate.Flag.BoolVar(&mForce, "force", false, "Run even if applied migration files do not match their recorded checksums, or squash migrations whose Down cannot be read")
+	CmdMigrate.Flag.Var(&mBefore, "before", "The first migration to keep when squashing")
+	CmdMigrate.Flag.Var(&mTo, "to", "The last migration to mark as applied when baselining")
+	CmdMigrate.Flag.Var(&mSchema, "schema", "File the database schema is dumped to after migrating, and checked against by check-schema")
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdMigrate)
 }
 
@@ -75,18 +130,7 @@ func RunMigration(cmd *commands.Command, args []string) int {
 	if len(args) != 0 {
 		cmd.Flag.Parse(args[1:])
 	}
-	if mDriver == "" {
-		mDriver = utils.DocValue(config.Conf.Database.Driver)
-		if mDriver == "" {
-			mDriver = "mysql"
-		}
-	}
-	if mConn == "" {
-		mConn = utils.DocValue(config.Conf.Database.Conn)
-		if mConn == "" {
-			mConn = "root:@tcp(127.0.0.1:3306)/test"
-		}
-	}
+	resolveDatabase()
 	if mDir == "" {
 		mDir = utils.DocValue(config.Conf.Database.Dir)
 		if mDir == "" {
@@ -98,6 +142,9 @@ func RunMigration(cmd *commands.Command, args []string) int {
 	//Log sensitive connection information only when DEBUG is set to true.
 	beeLogger.Log.Debugf("Conn: %s", utils.FILE(), utils.LINE(), mConn)
 	beeLogger.Log.Infof("Using '%s' as 'dir'", mDir)
+	if mSchema == "" {
+		mSchema = utils.DocValue(config.Conf.Database.Schema)
+	}
 	driverStr, connStr, dirStr := string(mDriver), string(mConn), string(mDir)
 
 	dirRune := []rune(dirStr)
@@ -122,6 +169,18 @@ func RunMigration(cmd *commands.Command, args []string) int {
 		case "refresh":
 			beeLogger.Log.Info("Refreshing all migrations")
 			MigrateRefresh(currpath, driverStr, connStr, dirStr)
+		case "repair":
+			beeLogger.Log.Info("Repairing migration checksums")
+			MigrateRepair(currpath, driverStr, connStr, dirStr)
+		case "baseline":
+			beeLogger.Log.Info("Baselining the database")
+			MigrateBaseline(currpath, driverStr, connStr, dirStr, string(mTo))
+		case "check-schema":
+			beeLogger.Log.Info("Checking the schema dump")
+			MigrateCheckSchema(currpath, driverStr, connStr, string(mSchema))
+		case "squash":
+			beeLogger.Log.Info("Squashing migrations")
+			MigrateSquash(currpath, driverStr, connStr, dirStr, string(mBefore), mForce)
 		default:
 			beeLogger.Log.Fatal("Command is missing")
 		}
@@ -130,6 +189,34 @@ func RunMigration(cmd *commands.Command, args []string) int {
 	return 0
 }
 
+// resolveDatabase falls back to the Beefile database settings of the selected
+// environment, then to the defaults, for the driver and connection string not
+// given on the command line
+func resolveDatabase() {
+	if err := config.SelectDatabase(string(mEnv)); err != nil {
+		if mDriver == "" || mConn == "" {
+			beeLogger.Log.Fatal(err.Error())
+		}
+		beeLogger.Log.Warn(err.Error())
+	}
+	if mDriver == "" {
+		mDriver = utils.DocValue(config.Conf.Database.Driver)
+		if mDriver == "" {
+			mDriver = "mysql"
+		}
+	}
+	if mConn == "" {
+		mConn = utils.DocValue(config.Conf.Database.Conn)
+		if mConn == "" {
+			mConn = "root:@tcp(127.0.0.1:3306)/test"
+		}
+	}
+	if sqlite.IsSQLite(string(mDriver)) {
+		sqlite.Check(string(mDriver))
+		mDriver = sqlite.DriverName
+	}
+}
+
 // migrate generates source code, build it, and invoke the binary who does the actual migration
 func migrate(goal, currpath, driver, connStr, dir string) {
 	if dir == "" {
@@ -150,27 +237,40 @@ func migrate(goal, currpath, driver, connStr, dir string) {
 	defer db.Close()
 
 	checkForSchemaUpdateTable(db, driver)
-	latestName, latestTime := getLatestMigration(db, goal)
-	writeMigrationSourceFile(dir, source, driver, connStr, latestTime, latestName, goal)
+	files := readMigrationFiles(dir)
+	recordSquashedBaselines(db, driver, files)
+	verifyChecksums(db, files, mForce)
+	backfillChecksums(db, driver, files)
+	latestName, _ := getLatestMigration(db, goal)
+	writeMigrationSourceFile(dir, source, driver, connStr, readMigrationSources(dir), latestName, goal)
 	buildMigrationBinary(dir, binary)
 	runMigrationBinary(dir, binary)
 	removeTempFile(dir, source)
 	removeTempFile(dir, binary)
+	recordChecksums(db, driver, files)
+	if mSchema != "" {
+		dumpSchema(currpath, driver, connStr, string(mSchema))
+	}
 }
 
 // checkForSchemaUpdateTable checks the existence of migrations table.
 // It checks for the proper table structures and creates the table using MYSQL_MIGRATION_DDL if it does not exist.
 func checkForSchemaUpdateTable(db *sql.DB, driver string) {
 	showTableSQL := showMigrationsTableSQL(driver)
-	if rows, err := db.Query(showTableSQL); err != nil {
+	rows, err := db.Query(showTableSQL)
+	if err != nil {
 		beeLogger.Log.Fatalf("Could not show migrations table: %s", err)
-	} else if !rows.Next() {
+	}
+	exists := rows.Next()
+	rows.Close()
+	if !exists {
 		// No migrations table, create new ones
 		createTableSQL := createMigrationsTableSQL(driver)
 
 		beeLogger.Log.Infof("Creating 'migrations' table...")
 
-		if _, err := db.Query(createTableSQL); err != nil {
+		// Exec, for SQLite only runs a query once its rows are read
+		if _, err := db.Exec(createTableSQL); err != nil {
 			beeLogger.Log.Fatalf("Could not create migrations table: %s", err)
 		}
 	}
@@ -205,6 +305,26 @@ func checkForSchemaUpdateTable(db *sql.DB, driver string) {
 			}
 		}
 	}
+
+	checkForChecksumColumn(db, driver)
+}
+
+// checkForChecksumColumn adds the checksum column to migrations tables
+// created by older versions of bee.
+func checkForChecksumColumn(db *sql.DB, driver string) {
+	rows, err := db.Query(showChecksumColumnSQL(driver))
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not show columns of migrations table: %s", err)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		return
+	}
+
+	beeLogger.Log.Infof("Adding 'checksum' column to 'migrations' table...")
+	if _, err := db.Exec(alterMigrationsChecksumSQL(driver)); err != nil {
+		beeLogger.Log.Fatalf("Could not add checksum column to migrations table: %s", err)
+	}
 }
 
 func driverImportStatement(driver string) string {
@@ -213,6 +333,8 @@ func driverImportStatement(driver string) string {
 		return "github.com/go-sql-driver/mysql"
 	case "postgres":
 		return "github.com/lib/pq"
+	case sqlite.DriverName:
+		return "github.com/mattn/go-sqlite3"
 	default:
 		return "github.com/go-sql-driver/mysql"
 	}
@@ -224,6 +346,8 @@ func showMigrationsTableSQL(driver string) string {
 		return "SHOW TABLES LIKE 'migrations'"
 	case "postgres":
 		return "SELECT * FROM pg_catalog.pg_tables WHERE tablename = 'migrations';"
+	case "sqlite", "sqlite3":
+		return "SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'migrations'"
 	default:
 		return "SHOW TABLES LIKE 'migrations'"
 	}
@@ -235,17 +359,47 @@ func createMigrationsTableSQL(driver string) string {
 		return MYSQLMigrationDDL
 	case "postgres":
 		return POSTGRESMigrationDDL
+	case "sqlite", "sqlite3":
+		return SQLiteMigrationDDL
 	default:
 		return MYSQLMigrationDDL
 	}
 }
 
+func showChecksumColumnSQL(driver string) string {
+	switch driver {
+	case "mysql":
+		return "SHOW COLUMNS FROM migrations LIKE 'checksum'"
+	case "postgres":
+		return "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = 'migrations' AND column_name = 'checksum';"
+	case "sqlite", "sqlite3":
+		return "SELECT name FROM pragma_table_info('migrations') WHERE name = 'checksum'"
+	default:
+		return "SHOW COLUMNS FROM migrations LIKE 'checksum'"
+	}
+}
+
+func alterMigrationsChecksumSQL(driver string) string {
+	switch driver {
+	case "mysql":
+		return MYSQLMigrationChecksumDDL
+	case "postgres":
+		return POSTGRESMigrationChecksumDDL
+	case "sqlite", "sqlite3":
+		return SQLiteMigrationChecksumDDL
+	default:
+		return MYSQLMigrationChecksumDDL
+	}
+}
+
 func selectMigrationsTableSQL(driver string) string {
 	switch driver {
 	case "mysql":
 		return "DESC migrations"
 	case "postgres":
 		return "SELECT * FROM migrations WHERE false ORDER BY id_migration;"
+	case "sqlite", "sqlite3":
+		return "SELECT * FROM migrations WHERE 0"
 	default:
 		return "DESC migrations"
 	}
@@ -279,17 +433,31 @@ func getLatestMigration(db *sql.DB, goal string) (file string, createdAt int64)
 }
 
 // writeMigrationSourceFile create the source file based on MIGRATION_MAIN_TPL
-func writeMigrationSourceFile(dir, source, driver, connStr string, latestTime int64, latestName string, task string) {
+func writeMigrationSourceFile(dir, source, driver, connStr string, sources []*migrationSource, latestName string, task string) {
+	for _, s := range sources {
+		if s.Type == "" {
+			beeLogger.Log.Hint("Define Up and Down methods calling m.SQL, or m.Migrate for a DDL specification")
+			beeLogger.Log.Fatalf("Could not find the Up method of migration '%s'", s.Name)
+		}
+		if s.Hooked {
+			beeLogger.Log.Hint("The migration binary defines these methods to collect the SQL of the migrations")
+			beeLogger.Log.Fatalf("Migration '%s' must not define its own SQL or Migrate method", s.Name)
+		}
+		if !s.HasDown && task != "upgrade" {
+			beeLogger.Log.Hint("Define a Down method calling m.SQL, or m.Migrate for a DDL specification")
+			beeLogger.Log.Fatalf("Could not find the Down method of migration '%s'", s.Name)
+		}
+		if s.Bypassed != "" {
+			beeLogger.Log.Hint("Call m.SQL or m.Migrate, the statements queued by migration.Migration itself are not run")
+			beeLogger.Log.Fatalf("Migration '%s' must not call m.Migration.%s", s.Name, s.Bypassed)
+		}
+	}
+
 	changeDir(dir)
 	if f, err := os.OpenFile(source, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0666); err != nil {
 		beeLogger.Log.Fatalf("Could not create file: %s", err)
 	} else {
-		content := strings.Replace(MigrationMainTPL, "{{DBDriver}}", driver, -1)
-		content = strings.Replace(content, "{{DriverRepo}}", driverImportStatement(driver), -1)
-		content = strings.Replace(content, "{{ConnStr}}", connStr, -1)
-		content = strings.Replace(content, "{{LatestTime}}", strconv.FormatInt(latestTime, 10), -1)
-		content = strings.Replace(content, "{{LatestName}}", latestName, -1)
-		content = strings.Replace(content, "{{Task}}", task, -1)
+		content := renderMigrationMain(driver, connStr, sources, latestName, task)
 		if _, err := f.WriteString(content); err != nil {
 			beeLogger.Log.Fatalf("Could not write to file: %s", err)
 		}
@@ -297,6 +465,36 @@ func writeMigrationSourceFile(dir, source, driver, connStr string, latestTime in
 	}
 }
 
+// renderMigrationMain renders MigrationMainTPL for the migrations of sources. Each
+// migration type gets SQL and Migrate methods, shadowing the ones of migration.Migration,
+// that queue its statements for the binary to run them.
+func renderMigrationMain(driver, connStr string, sources []*migrationSource, latestName, task string) string {
+	var list, hooks []string
+	hooked := make(map[string]bool)
+	for _, s := range sources {
+		transactional := transactionalDDL(driver) && !s.NoTransaction
+		list = append(list, fmt.Sprintf("\t{%s, func() migration.Migrationer { return &%s{} }, %t},", strconv.Quote(s.Name), s.Type, transactional))
+		if !hooked[s.Type] {
+			hooks = append(hooks, strings.Replace(MigrationHooksTPL, "{{StructName}}", s.Type, -1))
+			hooked[s.Type] = true
+		}
+	}
+
+	content := strings.Replace(MigrationMainTPL, "{{DBDriver}}", driver, -1)
+	content = strings.Replace(content, "{{DriverRepo}}", driverImportStatement(driver), -1)
+	content = strings.Replace(content, "{{ConnStr}}", connStr, -1)
+	content = strings.Replace(content, "{{Migrations}}", strings.Join(list, "\n"), -1)
+	content = strings.Replace(content, "{{Hooks}}", strings.Join(hooks, ""), -1)
+	content = strings.Replace(content, "{{LatestName}}", latestName, -1)
+	content = strings.Replace(content, "{{Task}}", task, -1)
+	return content
+}
+
+// transactionalDDL tells whether the driver can roll back schema changes
+func transactionalDDL(driver string) bool {
+	return driver == "postgres" || driver == "sqlite3"
+}
+
 // buildMigrationBinary changes directory to database/migrations folder and go-build the source
 func buildMigrationBinary(dir, binary string) {
 	changeDir(dir)
@@ -311,12 +509,14 @@ func buildMigrationBinary(dir, binary string) {
 }
 
 // runMigrationBinary runs the migration program who does the actual work
-func runMigrationBinary(dir, binary string) {
+func runMigrationBinary(dir, binary string, args ...string) {
 	changeDir(dir)
-	cmd := exec.Command("./" + binary)
+	cmd := exec.Command("./"+binary, args...)
 	if out, err := cmd.CombinedOutput(); err != nil {
-		formatShellOutput(string(out))
-		beeLogger.Log.Errorf("Could not run migration binary: %s", err)
+		failed := formatMigrationOutput(string(out))
+		if !failed {
+			beeLogger.Log.Errorf("Could not run migration binary: %s", err)
+		}
 		removeTempFile(dir, binary)
 		removeTempFile(dir, binary+".go")
 		os.Exit(2)
@@ -325,6 +525,45 @@ func runMigrationBinary(dir, binary string) {
 	}
 }
 
+// migrationFailure is the report the migration binary prints when a migration fails
+type migrationFailure struct {
+	Migration     string `json:"migration"`
+	Statement     string `json:"statement"`
+	Transactional bool   `json:"transactional"`
+	Error         string `json:"error"`
+}
+
+// formatMigrationOutput formats the output of a failed migration binary, and
+// returns whether it reported the migration that failed
+func formatMigrationOutput(o string) (failed bool) {
+	for _, line := range strings.Split(o, "\n") {
+		if !strings.HasPrefix(line, migrationFailurePrefix) {
+			formatShellOutput(line)
+			continue
+		}
+		var f migrationFailure
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, migrationFailurePrefix)), &f); err != nil {
+			formatShellErrOutput(line)
+			continue
+		}
+		failed = true
+		if f.Migration == "" {
+			beeLogger.Log.Errorf("Could not run migrations: %s", f.Error)
+			continue
+		}
+		beeLogger.Log.Errorf("Migration '%s' failed: %s", f.Migration, f.Error)
+		if f.Statement != "" {
+			beeLogger.Log.Errorf("Failing statement: %s", f.Statement)
+		}
+		if f.Transactional {
+			beeLogger.Log.Info("The migration was rolled back, the database is unchanged")
+		} else {
+			beeLogger.Log.Warnf("The migration did not run in a transaction, statements before the failing one were applied")
+		}
+	}
+	return
+}
+
 // changeDir changes working directory to dir.
 // It exits the system when encouter an error
 func changeDir(dir string) {
@@ -360,11 +599,18 @@ func formatShellOutput(o string) {
 }
 
 const (
+	// migrationFailurePrefix starts the line MigrationMainTPL prints when a migration fails
+	migrationFailurePrefix = "bee:failed "
+
 	// MigrationMainTPL migration main template
 	MigrationMainTPL = `package main
 
 import(
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/beego/beego/v2/client/orm"
 	"github.com/beego/beego/v2/client/orm/migration"
@@ -376,28 +622,159 @@ func init(){
 	orm.RegisterDataBase("default", "{{DBDriver}}","{{ConnStr}}")
 }
 
+// migrations lists the migrations in the order they run, and whether they run in a transaction.
+// Each run gets a new migration, for the DDL specification its Up or Down builds to start empty.
+var migrations = []struct{
+	name          string
+	new           func() migration.Migrationer
+	transactional bool
+}{
+{{Migrations}}
+}
+
+// queued holds the statements queued by each migration
+var queued = make(map[migration.Migrationer][]string)
+{{Hooks}}
+
 func main(){
 	task := "{{Task}}"
 	switch task {
 	case "upgrade":
-		if err := migration.Upgrade({{LatestTime}}); err != nil {
-			os.Exit(2)
-		}
+		upgrade()
 	case "rollback":
-		if err := migration.Rollback("{{LatestName}}"); err != nil {
-			os.Exit(2)
-		}
+		rollback("{{LatestName}}")
 	case "reset":
-		if err := migration.Reset(); err != nil {
-			os.Exit(2)
-		}
+		reset()
 	case "refresh":
-		if err := migration.Refresh(); err != nil {
-			os.Exit(2)
+		reset()
+		upgrade()
+	}
+}
+
+// upgrade runs the migrations that are not applied
+func upgrade() {
+	applied := appliedMigrations()
+	count := 0
+	for _, mg := range migrations {
+		if applied[mg.name] {
+			continue
+		}
+		fmt.Println("start upgrade", mg.name)
+		m := mg.new()
+		m.Up()
+		sqls := queued[m]
+		run(mg.name, sqls, mg.transactional,
+			"INSERT INTO migrations (name, created_at, statements, status) VALUES (?, ?, ?, 'update')",
+			mg.name, time.Now().Format(migration.DBDateFormat), strings.Join(sqls, "; "))
+		count++
+	}
+	fmt.Println("total success upgrade:", count, "migration(s)")
+}
+
+// rollback reverts the named migration
+func rollback(name string) {
+	for _, mg := range migrations {
+		if mg.name == name {
+			down(mg.name, mg.new(), mg.transactional)
+			return
+		}
+	}
+	fail(name, "", false, fmt.Errorf("migration source not found"))
+}
+
+// reset reverts the applied migrations, latest first
+func reset() {
+	applied := appliedMigrations()
+	count := 0
+	for i := len(migrations) - 1; i >= 0; i-- {
+		mg := migrations[i]
+		if !applied[mg.name] {
+			continue
 		}
+		down(mg.name, mg.new(), mg.transactional)
+		count++
 	}
+	fmt.Println("total success reset:", count, "migration(s)")
 }
 
+func down(name string, m migration.Migrationer, transactional bool) {
+	fmt.Println("start rollback", name)
+	m.Down()
+	sqls := queued[m]
+	run(name, sqls, transactional,
+		"UPDATE migrations SET status = 'rollback', rollback_statements = ?, created_at = ? WHERE name = ?",
+		strings.Join(sqls, "; "), time.Now().Format(migration.DBDateFormat), name)
+}
+
+// appliedMigrations returns the migrations whose latest status is update
+func appliedMigrations() map[string]bool {
+	var rows []orm.ParamsList
+	if _, err := orm.NewOrm().Raw("SELECT name, status FROM migrations ORDER BY id_migration").ValuesList(&rows); err != nil {
+		fail("", "", false, err)
+	}
+	applied := make(map[string]bool)
+	for _, row := range rows {
+		applied[fmt.Sprint(row[0])] = fmt.Sprint(row[1]) == "update"
+	}
+	return applied
+}
+
+// run executes the statements of a migration and records it, both in the same transaction if asked
+func run(name string, sqls []string, transactional bool, record string, args ...interface{}) {
+	var o orm.QueryExecutor = orm.NewOrm()
+	var tx orm.TxOrmer
+	if transactional {
+		var err error
+		if tx, err = orm.NewOrm().Begin(); err != nil {
+			fail(name, "", transactional, err)
+		}
+		o = tx
+	}
+	exec := func(s string, args ...interface{}) {
+		if _, err := o.Raw(s, args...).Exec(); err != nil {
+			if tx != nil {
+				tx.Rollback()
+			}
+			fail(name, s, transactional, err)
+		}
+	}
+	for _, s := range sqls {
+		fmt.Println("exec sql:", s)
+		exec(s)
+	}
+	exec(record, args...)
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			fail(name, "", transactional, err)
+		}
+	}
+}
+
+// fail reports the failing migration and statement to bee, then exits
+func fail(name, statement string, transactional bool, err error) {
+	report, _ := json.Marshal(map[string]interface{}{
+		"migration":     name,
+		"statement":     statement,
+		"transactional": transactional,
+		"error":         err.Error(),
+	})
+	fmt.Println("bee:failed " + string(report))
+	os.Exit(2)
+}
+
+`
+	// MigrationHooksTPL queues the statements of a migration type of the migration binary
+	MigrationHooksTPL = `
+// SQL queues a statement of the migration
+func (m *{{StructName}}) SQL(sql string) {
+	queued[m] = append(queued[m], sql)
+}
+
+// Migrate queues the statement of the DDL specification of the migration
+func (m *{{StructName}}) Migrate(migrationType string) {
+	m.ModifyType = migrationType
+	m.SQL(m.GetSQL())
+}
 `
 	// MYSQLMigrationDDL MySQL migration SQL
 	MYSQLMigrationDDL = `
@@ -408,6 +785,7 @@ CREATE TABLE migrations (
 	statements longtext COMMENT 'SQL statements for this migration',
 	rollback_statements longtext COMMENT 'SQL statment for rolling back migration',
 	status ENUM('update', 'rollback') COMMENT 'update indicates it is a normal migration while rollback means this migration is rolled back',
+	checksum varchar(64) DEFAULT NULL COMMENT 'SHA-256 checksum of the migration source file',
 	PRIMARY KEY (id_migration)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8
 `
@@ -421,8 +799,26 @@ CREATE TABLE migrations (
 	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
 	statements text,
 	rollback_statements text,
-	status migrations_status
+	status migrations_status,
+	checksum varchar(64) DEFAULT NULL
+)`
+	// SQLiteMigrationDDL SQLite migration SQL
+	SQLiteMigrationDDL = `
+CREATE TABLE migrations (
+	id_migration INTEGER PRIMARY KEY AUTOINCREMENT,
+	name varchar(255) DEFAULT NULL,
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	statements text,
+	rollback_statements text,
+	status varchar(8) CHECK (status IN ('update', 'rollback')),
+	checksum varchar(64) DEFAULT NULL
 )`
+	// MYSQLMigrationChecksumDDL adds the checksum column to an existing MySQL migrations table
+	MYSQLMigrationChecksumDDL = `ALTER TABLE migrations ADD COLUMN checksum varchar(64) DEFAULT NULL COMMENT 'SHA-256 checksum of the migration source file'`
+	// POSTGRESMigrationChecksumDDL adds the checksum column to an existing Postgres migrations table
+	POSTGRESMigrationChecksumDDL = `ALTER TABLE migrations ADD COLUMN checksum varchar(64) DEFAULT NULL`
+	// SQLiteMigrationChecksumDDL adds the checksum column to an existing SQLite migrations table
+	SQLiteMigrationChecksumDDL = `ALTER TABLE migrations ADD COLUMN checksum varchar(64) DEFAULT NULL`
 )
 
 // MigrateUpdate does the schema update
@@ -444,3 +840,18 @@ func MigrateReset(currpath, driver, connStr, dir string) {
 func MigrateRefresh(currpath, driver, connStr, dir string) {
 	migrate("refresh", currpath, driver, connStr, dir)
 }
+
+// MigrateRepair re-records the checksums of all applied migrations
+func MigrateRepair(currpath, driver, connStr, dir string) {
+	if dir == "" {
+		dir = path.Join(currpath, "database", "migrations")
+	}
+	db, err := sql.Open(driver, connStr)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to database using '%s': %s", connStr, err)
+	}
+	defer db.Close()
+
+	checkForSchemaUpdateTable(db, driver)
+	repairChecksums(db, driver, readMigrationFiles(dir))
+}
@@ -0,0 +1,262 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package migrate
+
+import (
+	"bytes"
+	"database/sql"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/beego/bee/v2/internal/pkg/sqlite"
+	beeLogger "github.com/beego/bee/v2/logger"
+)
+
+func TestFormatMigrationOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		failed   bool
+		expected []string
+	}{
+		{"no failure", "start upgrade a\nexec sql: CREATE TABLE a\n", false,
+			[]string{"|> start upgrade a", "|> exec sql: CREATE TABLE a"}},
+		{"transactional failure", "start upgrade a\n" + migrationFailurePrefix +
+			`{"migration":"a","statement":"CREATE TABLE a","transactional":true,"error":"table exists"}` + "\n", true,
+			[]string{"Migration 'a' failed: table exists", "Failing statement: CREATE TABLE a", "The migration was rolled back"}},
+		{"failure outside a transaction", migrationFailurePrefix +
+			`{"migration":"a","statement":"","transactional":false,"error":"bad"}`, true,
+			[]string{"Migration 'a' failed: bad", "statements before the failing one were applied"}},
+		{"failure before the migrations", migrationFailurePrefix + `{"migration":"","error":"no database"}`, true,
+			[]string{"Could not run migrations: no database"}},
+		{"malformed report", migrationFailurePrefix + "{", false,
+			[]string{"|> " + migrationFailurePrefix + "{"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			beeLogger.Log.SetOutput(&out)
+			defer beeLogger.Log.SetOutput(os.Stdout)
+
+			if failed := formatMigrationOutput(tt.output); failed != tt.failed {
+				t.Errorf("expected failed to be %t", tt.failed)
+			}
+			for _, s := range tt.expected {
+				if !strings.Contains(out.String(), s) {
+					t.Errorf("expected the output to contain %q:\n%s", s, out.String())
+				}
+			}
+		})
+	}
+}
+
+const testMigration = `package main
+
+import "github.com/beego/beego/v2/client/orm/migration"
+
+type Users_20200102_150405 struct {
+	migration.Migration
+}
+
+func init() {
+	m := &Users_20200102_150405{}
+	m.Created = "20200102_150405"
+	migration.Register("Users_20200102_150405", m)
+}
+
+func (m *Users_20200102_150405) Up() {
+	m.SQL("CREATE TABLE users (id int)")
+}
+
+func (m *Users_20200102_150405) Down() {
+	m.SQL("DROP TABLE users")
+}
+`
+
+func TestRenderMigrationMainCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a binary")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	// build inside the module, for the imports of the binary to resolve
+	dir, err := ioutil.TempDir(".", "runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fpath := filepath.Join(dir, "20200102_150405_users.go")
+	if err := ioutil.WriteFile(fpath, []byte(testMigration), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sources := []*migrationSource{parseMigrationSource(&migrationFile{Name: "Users_20200102_150405", Path: fpath})}
+	content := renderMigrationMain("mysql", "root:@tcp(127.0.0.1:3306)/test", sources, "Users_20200102_150405", "upgrade")
+	if strings.Contains(content, "{{") {
+		t.Errorf("unexpected placeholder left in the binary:\n%s", content)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "m.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(goBin, "build", "-o", filepath.Join(dir, "m"), ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("could not build the migration binary: %s\n%s\n%s", err, out, content)
+	}
+}
+
+// testDDLMigration adds a column to the users table of testMigration through the DDL specification
+const testDDLMigration = `package main
+
+import "github.com/beego/beego/v2/client/orm/migration"
+
+type Nickname_20200103_150405 struct {
+	migration.Migration
+}
+
+func init() {
+	m := &Nickname_20200103_150405{}
+	m.Created = "20200103_150405"
+	migration.Register("Nickname_20200103_150405", m)
+}
+
+func (m *Nickname_20200103_150405) ddlSpec() {
+	m.AlterTable("users")
+	m.NewCol("nickname").SetDataType("varchar(64)")
+}
+
+func (m *Nickname_20200103_150405) Up() {
+	m.ddlSpec()
+	m.Migrate("alter")
+}
+
+func (m *Nickname_20200103_150405) Down() {
+	m.ddlSpec()
+	m.Migrate("reverse")
+}
+`
+
+func TestMigrationBinaryDDL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs a binary")
+	}
+	if !sqlite.Supported {
+		t.Skip("SQLite needs cgo")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	// build inside the module, for the imports of the binary to resolve
+	dir, err := ioutil.TempDir(".", "runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{"20200102_150405_users.go": testMigration, "20200103_150405_nickname.go": testDDLMigration}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn := filepath.Join(dir, "test.db")
+	db, err := sql.Open(sqlite.DriverName, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	checkForSchemaUpdateTable(db, sqlite.DriverName)
+
+	sources := readMigrationSources(dir)
+	for _, s := range sources {
+		if s.Hooked || s.Bypassed != "" || !s.HasDown {
+			t.Fatalf("unexpected migration: %+v", s)
+		}
+	}
+	run := func(task, latest string) {
+		content := renderMigrationMain(sqlite.DriverName, conn, sources, latest, task)
+		if err := ioutil.WriteFile(filepath.Join(dir, "m.go"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		build := exec.Command(goBin, "build", "-o", filepath.Join(dir, "m"), ".")
+		build.Dir = dir
+		if out, err := build.CombinedOutput(); err != nil {
+			t.Fatalf("could not build the migration binary: %s\n%s", err, out)
+		}
+		if out, err := exec.Command(filepath.Join(dir, "m")).CombinedOutput(); err != nil {
+			t.Fatalf("the %s failed: %s\n%s", task, err, out)
+		}
+	}
+	columns := func() (names []string) {
+		rows, err := db.Query("SELECT name FROM pragma_table_info('users')")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, name)
+		}
+		return
+	}
+
+	run("upgrade", "")
+	if c := columns(); !reflect.DeepEqual(c, []string{"id", "nickname"}) {
+		t.Errorf("expected the DDL migration to add the nickname column, got %q", c)
+	}
+	statements, ok := getAppliedStatements(db, sqlite.DriverName, "Nickname_20200103_150405")
+	if !ok || !strings.Contains(statements, "ADD `nickname` varchar(64)") {
+		t.Errorf("expected the statements of the DDL migration to be recorded, got %q", statements)
+	}
+	run("rollback", "Nickname_20200103_150405")
+	if c := columns(); !reflect.DeepEqual(c, []string{"id"}) {
+		t.Errorf("expected the rollback to drop the nickname column, got %q", c)
+	}
+}
+
+func TestEmbeddedCall(t *testing.T) {
+	tests := map[string]string{
+		`m.SQL("CREATE TABLE a (id int)")`:           "",
+		`m.Migrate("create")`:                        "",
+		`m.Migration.SQL("CREATE TABLE a (id int)")`: "SQL",
+		`m.Migration.Up()`:                           "Up",
+		`m.Migration.CreateTable("a", "", "")`:       "",
+	}
+	for stmt, expected := range tests {
+		f, err := parser.ParseFile(token.NewFileSet(), "", "package main\nfunc (m *M) Up() {\n"+stmt+"\n}", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := embeddedCall(f.Decls[0].(*ast.FuncDecl).Body); name != expected {
+			t.Errorf("%s: expected %q, got %q", stmt, expected, name)
+		}
+	}
+}
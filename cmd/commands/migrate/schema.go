@@ -0,0 +1,83 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
+)
+
+// SchemaDumper returns the schema of a database as SQL statements.
+// It is registered by the generate package, which holds the database introspection.
+var SchemaDumper func(driver, connStr string) string
+
+// defaultSchemaFile is where check-schema looks for the dump when none is configured
+const defaultSchemaFile = "database/schema.sql"
+
+// dumpSchema writes the schema of the database to file
+func dumpSchema(currpath, driver, connStr, file string) {
+	file = schemaPath(currpath, file)
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		beeLogger.Log.Fatalf("Could not create directory: %s", err)
+	}
+	if err := ioutil.WriteFile(file, []byte(schemaDumper()(driver, connStr)), 0666); err != nil {
+		beeLogger.Log.Fatalf("Could not write schema dump: %s", err)
+	}
+	beeLogger.Log.Infof("Dumped the database schema to '%s'", file)
+}
+
+// MigrateCheckSchema fails when the schema dump differs from the schema of the database
+func MigrateCheckSchema(currpath, driver, connStr, file string) {
+	if file == "" {
+		file = defaultSchemaFile
+	}
+	file = schemaPath(currpath, file)
+	committed, err := ioutil.ReadFile(file)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read schema dump: %s", err)
+	}
+
+	current := schemaDumper()(driver, connStr)
+	dump := strings.Replace(string(committed), "\r\n", "\n", -1)
+	if dump == current {
+		beeLogger.Log.Infof("'%s' is up to date", file)
+		return
+	}
+
+	utils.PrintFileDiff(os.Stdout, file, dump, current)
+	beeLogger.Log.Hintf("Run 'bee migrate -schema=%s' against an up to date database, and commit the result", file)
+	beeLogger.Log.Fatalf("'%s' does not match the database schema", file)
+}
+
+// schemaDumper returns SchemaDumper, failing when no package registered it
+func schemaDumper() func(driver, connStr string) string {
+	if SchemaDumper == nil {
+		beeLogger.Log.Fatal("Schema dumps are not supported: no schema dumper is registered")
+	}
+	return SchemaDumper
+}
+
+// schemaPath makes a schema dump path absolute
+func schemaPath(currpath, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return path.Join(currpath, file)
+}
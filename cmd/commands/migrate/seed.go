@@ -0,0 +1,592 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/beego/bee/v2/cmd/commands"
+	"github.com/beego/bee/v2/cmd/commands/version"
+	"github.com/beego/bee/v2/config"
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
+)
+
+var CmdSeed = &commands.Command{
+	UsageLine: "seed [name...]",
+	Short:     "Seeds the database with data",
+	Long: `The command 'seed' runs the seed files stored in database/seeds against the database.
+
+  ▶ {{"To run all the seeds that have not been run yet:"|bold}}
+
+    $ bee seed [-env=dev] [-driver=mysql] [-conn="root:@tcp(127.0.0.1:3306)/test"] [-dir="path/to/seeds"]
+
+  ▶ {{"To run some seeds only:"|bold}}
+
+    $ bee seed [name...] [-driver=mysql] [-conn="root:@tcp(127.0.0.1:3306)/test"]
+
+  ▶ {{"To empty the fixture tables and run the seeds again:"|bold}}
+
+    $ bee seed [name...] -reset
+
+  Reset asks for a confirmation in the environments other than dev and test. It only empties
+  the tables of the YAML fixtures: the rows inserted by SQL and Go seeds are not removed, and
+  running these seeds again may duplicate them or break unique keys.
+
+  Seeds are Go files, SQL files or YAML fixtures, see {{"bee generate seed"|bold}}.
+  Seeds that were run are recorded per environment ({{"-env"|bold}}, or BEEGO_RUNMODE, dev by default)
+  in the 'seeds' table.
+`,
+	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
+	Run:    RunSeed,
+}
+
+var sDir utils.DocValue
+var sReset bool
+
+func init() {
+	CmdSeed.Flag.Var(&mDriver, "driver", "Database driver. Either mysql, postgres or sqlite.")
+	CmdSeed.Flag.Var(&mConn, "conn", "Connection string used by the driver to connect to a database instance.")
+	CmdSeed.Flag.Var(&mEnv, "env", "Database environment of the Beefile to use, and to record the seeds for. Defaults to BEEGO_RUNMODE, then dev.")
+	CmdSeed.Flag.Var(&sDir, "dir", "The directory where the seed files are stored")
+	CmdSeed.Flag.BoolVar(&sReset, "reset", false, "Empty the fixture tables and forget the seeds that were run, then run them again. Asks for a confirmation outside dev and test.")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdSeed)
+}
+
+// seedFile is a seed found in the seeds directory
+type seedFile struct {
+	Name string
+	Path string
+	Ext  string
+}
+
+// RunSeed is the entry point of the seed command
+func RunSeed(cmd *commands.Command, args []string) int {
+	currpath, _ := os.Getwd()
+
+	// seed names come first, flags may follow them
+	var names []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		names = append(names, args[0])
+		args = args[1:]
+	}
+	cmd.Flag.Parse(args)
+	names = append(names, cmd.Flag.Args()...)
+
+	resolveDatabase()
+	dir := string(sDir)
+	if dir == "" {
+		dir = path.Join(currpath, "database", "seeds")
+	} else if !filepath.IsAbs(dir) {
+		dir = path.Join(currpath, dir)
+	}
+	env := seedEnv()
+
+	beeLogger.Log.Infof("Using '%s' as 'driver'", mDriver)
+	beeLogger.Log.Debugf("Conn: %s", utils.FILE(), utils.LINE(), mConn)
+	beeLogger.Log.Infof("Using '%s' as 'dir'", dir)
+	beeLogger.Log.Infof("Using '%s' as 'env'", env)
+
+	Seed(string(mDriver), string(mConn), dir, env, names, sReset)
+	beeLogger.Log.Success("Seeding successful!")
+	return 0
+}
+
+// Seed runs the seeds of dir that have not been run in env yet. When names are
+// given, only the matching seeds are considered.
+func Seed(driver, connStr, dir, env string, names []string, reset bool) {
+	db, err := sql.Open(driver, connStr)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to database using '%s': %s", connStr, err)
+	}
+	defer db.Close()
+
+	checkForSeedsTable(db, driver)
+	seeds := selectSeeds(readSeedFiles(dir), names)
+
+	if reset {
+		if !seedResetEnvs[env] {
+			beeLogger.Log.Warnf("Resetting empties the fixture tables of the '%s' environment. Do you want to continue? [Yes|No] ", env)
+			if !utils.AskForConfirmation() {
+				beeLogger.Log.Fatal("Seeds were not reset")
+			}
+		}
+		resetSeeds(db, driver, env, seeds)
+	}
+
+	applied := getAppliedSeeds(db, driver, env)
+	postfix := ""
+	if runtime.GOOS == "windows" {
+		postfix = ".exe"
+	}
+	binary := "s" + postfix
+	built := false
+	count := 0
+	for _, s := range seeds {
+		if applied[s.Name] {
+			beeLogger.Log.Infof("Skipping '%s', it has already been run", s.Name)
+			continue
+		}
+		beeLogger.Log.Infof("Running seed '%s'", s.Name)
+		if s.Ext == ".go" {
+			if !built {
+				writeSeedSourceFile(dir, binary+".go", driver, connStr)
+				buildMigrationBinary(dir, binary)
+				built = true
+			}
+			runMigrationBinary(dir, binary, s.Name)
+			if err := recordSeed(db, driver, env, s.Name); err != nil {
+				beeLogger.Log.Fatal(err.Error())
+			}
+		} else {
+			runSeedInTransaction(db, driver, env, s)
+		}
+		count++
+	}
+	if built {
+		removeTempFile(dir, binary+".go")
+		removeTempFile(dir, binary)
+	}
+	beeLogger.Log.Infof("%d seed(s) run", count)
+}
+
+// seedResetEnvs are the environments whose seeds are reset without confirmation
+var seedResetEnvs = map[string]bool{"dev": true, "test": true}
+
+// seedEnv returns the environment seeds are recorded for
+func seedEnv() string {
+	return config.DatabaseEnv(string(mEnv))
+}
+
+// readSeedFiles returns the seeds of dir ordered by file name
+func readSeedFiles(dir string) []*seedFile {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read seeds directory: %s", err)
+	}
+	var seeds []*seedFile
+	for _, info := range infos {
+		ext := filepath.Ext(info.Name())
+		name := strings.TrimSuffix(info.Name(), ext)
+		if info.IsDir() || strings.HasSuffix(name, "_test") {
+			continue
+		}
+		switch ext {
+		case ".go":
+			// skip the temporary main file of the seed binary
+			if name == "s" {
+				continue
+			}
+		case ".sql", ".yml", ".yaml":
+		default:
+			continue
+		}
+		seeds = append(seeds, &seedFile{Name: name, Path: filepath.Join(dir, info.Name()), Ext: ext})
+	}
+	sort.Slice(seeds, func(i, j int) bool { return seeds[i].Name < seeds[j].Name })
+	return seeds
+}
+
+// selectSeeds returns the seeds matching names, all of them if names is empty.
+// A name matches a seed with or without its timestamp prefix.
+func selectSeeds(seeds []*seedFile, names []string) []*seedFile {
+	if len(names) == 0 {
+		return seeds
+	}
+	var selected []*seedFile
+	for _, name := range names {
+		found := false
+		for _, s := range seeds {
+			// seed names are the creation time, 20060102_150405, followed by _name
+			if s.Name == name || (len(s.Name) > 16 && s.Name[16:] == name) {
+				selected = append(selected, s)
+				found = true
+			}
+		}
+		if !found {
+			beeLogger.Log.Fatalf("Could not find seed '%s'", name)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+	return selected
+}
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// runSeedInTransaction runs an SQL or YAML seed and records it in a single transaction,
+// so that a failing seed leaves no rows behind. MySQL commits the schema changes
+// of SQL seeds implicitly.
+func runSeedInTransaction(db *sql.DB, driver, env string, s *seedFile) {
+	tx, err := db.Begin()
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not start a transaction: %s", err)
+	}
+	if s.Ext == ".sql" {
+		err = runSQLSeed(tx, driver, s)
+	} else {
+		err = runFixtureSeed(tx, driver, s)
+	}
+	if err == nil {
+		err = recordSeed(tx, driver, env, s.Name)
+	}
+	if err != nil {
+		tx.Rollback()
+		beeLogger.Log.Fatalf("Could not run seed '%s': %s", s.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		beeLogger.Log.Fatalf("Could not commit seed '%s': %s", s.Name, err)
+	}
+}
+
+// runSQLSeed executes the statements of an SQL seed one by one
+func runSQLSeed(db execer, driver string, s *seedFile) error {
+	content, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitSQLStatements(string(content), driver) {
+		if _, err := db.Exec(stmt); err != nil {
+			beeLogger.Log.Errorf("|> %s", stmt)
+			return err
+		}
+	}
+	return nil
+}
+
+// readFixture reads a YAML fixture mapping table names to the rows to insert
+func readFixture(s *seedFile) yaml.MapSlice {
+	content, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read seed file: %s", err)
+	}
+	var tables yaml.MapSlice
+	if err := yaml.Unmarshal(content, &tables); err != nil {
+		beeLogger.Log.Fatalf("Could not parse fixture '%s': %s", s.Name, err)
+	}
+	return tables
+}
+
+// runFixtureSeed inserts the rows of a YAML fixture
+func runFixtureSeed(db execer, driver string, s *seedFile) error {
+	for _, table := range readFixture(s) {
+		rows, ok := table.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("table '%v' must hold a list of rows", table.Key)
+		}
+		for _, r := range rows {
+			row, ok := r.(yaml.MapSlice)
+			if !ok {
+				return fmt.Errorf("rows of table '%v' must be maps of column to value", table.Key)
+			}
+			var columns, params []string
+			var values []interface{}
+			for i, col := range row {
+				columns = append(columns, fmt.Sprint(col.Key))
+				params = append(params, placeholder(driver, i+1))
+				values = append(values, col.Value)
+			}
+			stmt := fmt.Sprintf("INSERT INTO %v (%s) VALUES (%s)", table.Key, strings.Join(columns, ", "), strings.Join(params, ", "))
+			if _, err := db.Exec(stmt, values...); err != nil {
+				beeLogger.Log.Errorf("|> %s %v", stmt, values)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resetSeeds empties the tables filled by the fixtures among seeds and forgets
+// that seeds were run in env
+func resetSeeds(db *sql.DB, driver, env string, seeds []*seedFile) {
+	emptied := make(map[string]bool)
+	for _, s := range seeds {
+		if s.Ext != ".yml" && s.Ext != ".yaml" {
+			beeLogger.Log.Warnf("The rows inserted by seed '%s' are not removed before it runs again", s.Name)
+		} else {
+			for _, table := range readFixture(s) {
+				name := fmt.Sprint(table.Key)
+				if emptied[name] {
+					continue
+				}
+				beeLogger.Log.Infof("Emptying table '%s'", name)
+				if _, err := db.Exec("DELETE FROM " + name); err != nil {
+					beeLogger.Log.Fatalf("Could not empty table '%s': %s", name, err)
+				}
+				emptied[name] = true
+			}
+		}
+		query := "DELETE FROM seeds WHERE name = " + placeholder(driver, 1) + " AND env = " + placeholder(driver, 2)
+		if _, err := db.Exec(query, s.Name, env); err != nil {
+			beeLogger.Log.Fatalf("Could not reset seed '%s': %s", s.Name, err)
+		}
+	}
+}
+
+// splitSQLStatements splits an SQL script on the semicolons that are not quoted
+// or commented out. Backslashes escape quotes in MySQL strings and in Postgres
+// E'...' strings, and Postgres dollar-quoted bodies are kept whole.
+func splitSQLStatements(script, driver string) (statements []string) {
+	var quote byte
+	var dollarTag string
+	escapes, content := false, false
+	start := 0
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case dollarTag != "":
+			if strings.HasPrefix(script[i:], dollarTag) {
+				i += len(dollarTag) - 1
+				dollarTag = ""
+			}
+		case quote != 0:
+			if c == '\\' && escapes {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case strings.HasPrefix(script[i:], "--"):
+			// skip comments, they may contain quotes
+			if j := strings.IndexByte(script[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(script)
+			}
+		case strings.HasPrefix(script[i:], "/*"):
+			if j := strings.Index(script[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(script)
+			}
+		case c == ';':
+			if content {
+				statements = append(statements, strings.TrimSpace(script[start:i]))
+			}
+			start, content = i+1, false
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		default:
+			content = true
+			if c == '$' && driver == "postgres" {
+				if tag := dollarQuoteTag(script[i:]); tag != "" && (i == 0 || !isIdentByte(script[i-1])) {
+					dollarTag = tag
+					i += len(tag) - 1
+				}
+			} else if c == '\'' || c == '"' || c == '`' {
+				quote = c
+				switch driver {
+				case "mysql":
+					escapes = c != '`'
+				case "postgres":
+					escapes = c == '\'' && i > 0 && (script[i-1] == 'E' || script[i-1] == 'e') && (i == 1 || !isIdentByte(script[i-2]))
+				default:
+					escapes = false
+				}
+			}
+		}
+	}
+	if content {
+		statements = append(statements, strings.TrimSpace(script[start:]))
+	}
+	return
+}
+
+// dollarQuoteTag returns the tag opening a Postgres dollar-quoted string at the
+// start of s, e.g. $$ or $body$, or an empty string
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '$':
+			return s[:i+1]
+		case c >= '0' && c <= '9':
+			// $1 is a parameter
+			if i == 1 {
+				return ""
+			}
+		case !isIdentByte(c):
+			return ""
+		}
+	}
+	return ""
+}
+
+// isIdentByte reports whether c can be part of an unquoted SQL identifier
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// checkForSeedsTable creates the seeds table if it does not exist
+func checkForSeedsTable(db *sql.DB, driver string) {
+	rows, err := db.Query(showSeedsTableSQL(driver))
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not show seeds table: %s", err)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		return
+	}
+
+	beeLogger.Log.Infof("Creating 'seeds' table...")
+	if _, err := db.Exec(createSeedsTableSQL(driver)); err != nil {
+		beeLogger.Log.Fatalf("Could not create seeds table: %s", err)
+	}
+}
+
+// getAppliedSeeds returns the names of the seeds already run in env
+func getAppliedSeeds(db *sql.DB, driver, env string) map[string]bool {
+	rows, err := db.Query("SELECT name FROM seeds WHERE env = "+placeholder(driver, 1), env)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not retrieve seeds: %s", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			beeLogger.Log.Fatalf("Could not read seeds in database: %s", err)
+		}
+		applied[name] = true
+	}
+	return applied
+}
+
+// recordSeed records that a seed was run in env
+func recordSeed(db execer, driver, env, name string) error {
+	query := "INSERT INTO seeds (name, env) VALUES (" + placeholder(driver, 1) + ", " + placeholder(driver, 2) + ")"
+	if _, err := db.Exec(query, name, env); err != nil {
+		return fmt.Errorf("could not record seed '%s': %s", name, err)
+	}
+	return nil
+}
+
+// writeSeedSourceFile creates the main file of the binary running Go seeds
+func writeSeedSourceFile(dir, source, driver, connStr string) {
+	changeDir(dir)
+	if f, err := os.OpenFile(source, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0666); err != nil {
+		beeLogger.Log.Fatalf("Could not create file: %s", err)
+	} else {
+		content := strings.Replace(SeedMainTPL, "{{DBDriver}}", driver, -1)
+		content = strings.Replace(content, "{{DriverRepo}}", driverImportStatement(driver), -1)
+		content = strings.Replace(content, "{{ConnStr}}", connStr, -1)
+		if _, err := f.WriteString(content); err != nil {
+			beeLogger.Log.Fatalf("Could not write to file: %s", err)
+		}
+		utils.CloseFile(f)
+	}
+}
+
+func showSeedsTableSQL(driver string) string {
+	switch driver {
+	case "mysql":
+		return "SHOW TABLES LIKE 'seeds'"
+	case "postgres":
+		return "SELECT * FROM pg_catalog.pg_tables WHERE tablename = 'seeds';"
+	case "sqlite", "sqlite3":
+		return "SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'seeds'"
+	default:
+		return "SHOW TABLES LIKE 'seeds'"
+	}
+}
+
+func createSeedsTableSQL(driver string) string {
+	switch driver {
+	case "mysql":
+		return MYSQLSeedDDL
+	case "postgres":
+		return POSTGRESSeedDDL
+	case "sqlite", "sqlite3":
+		return SQLiteSeedDDL
+	default:
+		return MYSQLSeedDDL
+	}
+}
+
+const (
+	// SeedMainTPL is the main file of the binary running Go seeds
+	SeedMainTPL = `package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/beego/beego/v2/client/orm"
+
+	_ "{{DriverRepo}}"
+)
+
+var seeds = make(map[string]func(o orm.Ormer) error)
+
+// Register is called by the seed files to register their seed function
+func Register(name string, seed func(o orm.Ormer) error) {
+	seeds[name] = seed
+}
+
+func init() {
+	orm.RegisterDataBase("default", "{{DBDriver}}", "{{ConnStr}}")
+}
+
+func main() {
+	o := orm.NewOrm()
+	for _, name := range os.Args[1:] {
+		seed, ok := seeds[name]
+		if !ok {
+			fmt.Println("seed not registered:", name)
+			os.Exit(2)
+		}
+		if err := seed(o); err != nil {
+			fmt.Println("seed", name, "failed:", err)
+			os.Exit(2)
+		}
+	}
+}
+`
+	// MYSQLSeedDDL MySQL seeds SQL
+	MYSQLSeedDDL = `
+CREATE TABLE seeds (
+	id_seed int(10) unsigned NOT NULL AUTO_INCREMENT COMMENT 'surrogate key',
+	name varchar(255) NOT NULL COMMENT 'seed name',
+	env varchar(64) NOT NULL COMMENT 'environment the seed was run in',
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'date seeded',
+	PRIMARY KEY (id_seed)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8
+`
+	// POSTGRESSeedDDL Postgres seeds SQL
+	POSTGRESSeedDDL = `
+CREATE TABLE seeds (
+	id_seed SERIAL PRIMARY KEY,
+	name varchar(255) NOT NULL,
+	env varchar(64) NOT NULL,
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+	// SQLiteSeedDDL SQLite seeds SQL
+	SQLiteSeedDDL = `
+CREATE TABLE seeds (
+	id_seed INTEGER PRIMARY KEY AUTOINCREMENT,
+	name varchar(255) NOT NULL,
+	env varchar(64) NOT NULL,
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+)
@@ -0,0 +1,146 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package migrate
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/beego/bee/v2/internal/pkg/sqlite"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name, driver, script string
+		expected             []string
+	}{
+		{"plain", "mysql", "INSERT INTO a VALUES (1);\nINSERT INTO b VALUES (2);\n",
+			[]string{"INSERT INTO a VALUES (1)", "INSERT INTO b VALUES (2)"}},
+		{"no trailing semicolon", "mysql", "DELETE FROM a; DELETE FROM b",
+			[]string{"DELETE FROM a", "DELETE FROM b"}},
+		{"quoted semicolons", "mysql", `INSERT INTO a VALUES ('x;y', "z;");`,
+			[]string{`INSERT INTO a VALUES ('x;y', "z;")`}},
+		{"doubled quotes", "sqlite", "INSERT INTO a VALUES ('it''s; fine');",
+			[]string{"INSERT INTO a VALUES ('it''s; fine')"}},
+		{"line comments", "mysql", "-- it's; a comment\nDELETE FROM a; -- done\n",
+			[]string{"-- it's; a comment\nDELETE FROM a"}},
+		{"block comments", "postgres", "/* it's; a\ncomment */ DELETE FROM a; /* trailing; */",
+			[]string{"/* it's; a\ncomment */ DELETE FROM a"}},
+		{"mysql backslash escapes", "mysql", `INSERT INTO a VALUES ('it\'s;', "\";");`,
+			[]string{`INSERT INTO a VALUES ('it\'s;', "\";")`}},
+		{"postgres backslashes", "postgres", `INSERT INTO a VALUES ('C:\'); DELETE FROM b;`,
+			[]string{`INSERT INTO a VALUES ('C:\')`, "DELETE FROM b"}},
+		{"postgres escape strings", "postgres", `INSERT INTO a VALUES (E'it\'s;'); DELETE FROM b;`,
+			[]string{`INSERT INTO a VALUES (E'it\'s;')`, "DELETE FROM b"}},
+		{"postgres dollar quotes", "postgres",
+			"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;\nCREATE FUNCTION g() RETURNS int AS $body$ SELECT '$$'; $body$ LANGUAGE sql;",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql",
+				"CREATE FUNCTION g() RETURNS int AS $body$ SELECT '$$'; $body$ LANGUAGE sql"}},
+		{"postgres parameters", "postgres", "PREPARE p AS SELECT $1; EXECUTE p(1);",
+			[]string{"PREPARE p AS SELECT $1", "EXECUTE p(1)"}},
+		{"only comments", "mysql", "-- nothing\n/* to do */;\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if statements := splitSQLStatements(tt.script, tt.driver); !reflect.DeepEqual(statements, tt.expected) {
+				t.Errorf("unexpected statements:\n%q", statements)
+			}
+		})
+	}
+}
+
+func TestSelectSeeds(t *testing.T) {
+	seeds := []*seedFile{
+		{Name: "20200101_120000_users"},
+		{Name: "20200102_120000_posts"},
+		{Name: "20200103_120000_admin_users"},
+	}
+	names := func(seeds []*seedFile) (names []string) {
+		for _, s := range seeds {
+			names = append(names, s.Name)
+		}
+		return
+	}
+	tests := []struct {
+		name     string
+		selected []string
+		expected []string
+	}{
+		{"all", nil, []string{"20200101_120000_users", "20200102_120000_posts", "20200103_120000_admin_users"}},
+		{"full name", []string{"20200102_120000_posts"}, []string{"20200102_120000_posts"}},
+		{"without timestamp", []string{"users"}, []string{"20200101_120000_users"}},
+		{"in file order", []string{"admin_users", "posts"}, []string{"20200102_120000_posts", "20200103_120000_admin_users"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if selected := names(selectSeeds(seeds, tt.selected)); !reflect.DeepEqual(selected, tt.expected) {
+				t.Errorf("unexpected seeds: %q", selected)
+			}
+		})
+	}
+}
+
+func TestSeedReset(t *testing.T) {
+	if !sqlite.Supported {
+		t.Skip("SQLite needs cgo")
+	}
+	dir, err := ioutil.TempDir("", "seeds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conn := filepath.Join(dir, "test.db")
+	db, err := sql.Open(sqlite.DriverName, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE users (name text); CREATE TABLE logs (message text)"); err != nil {
+		t.Fatal(err)
+	}
+	seeds := filepath.Join(dir, "seeds")
+	files := map[string]string{
+		"001_users.yml": "users:\n  - name: admin\n",
+		"002_logs.sql":  "INSERT INTO logs (message) VALUES ('seeded');",
+	}
+	if err := os.MkdirAll(seeds, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(seeds, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	count := func(table string) (n int) {
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	Seed(sqlite.DriverName, conn, seeds, "test", nil, false)
+	Seed(sqlite.DriverName, conn, seeds, "test", nil, false)
+	if count("users") != 1 || count("logs") != 1 {
+		t.Fatalf("expected the seeds to run once, got %d users and %d logs", count("users"), count("logs"))
+	}
+	// the fixture tables are emptied, the rows of SQL seeds are not
+	Seed(sqlite.DriverName, conn, seeds, "test", nil, true)
+	if count("users") != 1 || count("logs") != 2 {
+		t.Errorf("expected 1 user and 2 logs after a reset, got %d users and %d logs", count("users"), count("logs"))
+	}
+}
@@ -0,0 +1,469 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
+)
+
+// squashedRegex extracts the migrations replaced by a baseline migration
+var squashedRegex = regexp.MustCompile(`(?m)^// bee:squashed (.+)$`)
+
+// noTransactionRegex matches the marker of migrations that must not run in a transaction
+var noTransactionRegex = regexp.MustCompile(`(?m)^// bee:no-transaction\s*$`)
+
+// migrationSource is a migration file along with the SQL of its Up and Down methods
+type migrationSource struct {
+	*migrationFile
+	Type          string
+	Created       string
+	Up            []string
+	Down          []string
+	UpOK          bool
+	DownOK        bool
+	NoTransaction bool
+	Hooked        bool   // defines the SQL or Migrate method the migration binary defines
+	HasDown       bool   // defines its Down method, rather than using the one of migration.Migration
+	Bypassed      string // method of migration.Migration it calls directly, bypassing the binary's
+}
+
+// MigrateSquash replaces the migrations created before the named one with a
+// single baseline migration. With force, migrations whose Down cannot be read
+// are squashed anyway, leaving the baseline Down to be completed by hand.
+func MigrateSquash(currpath, driver, connStr, dir, before string, force bool) {
+	if dir == "" {
+		dir = path.Join(currpath, "database", "migrations")
+	}
+	if before == "" {
+		beeLogger.Log.Hint("Name the first migration to keep, i.e. -before=\"20200102_150405_users\"")
+		beeLogger.Log.Fatal("The -before option is required")
+	}
+
+	db, err := sql.Open(driver, connStr)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to database using '%s': %s", connStr, err)
+	}
+	defer db.Close()
+	checkForSchemaUpdateTable(db, driver)
+
+	sources := readMigrationSources(dir)
+	i := findMigration(sources, before)
+	if i < 0 {
+		beeLogger.Log.Fatalf("Could not find migration '%s'", before)
+	}
+	squashed := sources[:i]
+	if len(squashed) < 2 {
+		beeLogger.Log.Fatal("There must be at least two migrations before it to squash")
+	}
+
+	applied := make(map[string]bool)
+	for _, s := range squashed {
+		_, applied[s.Name] = getAppliedStatements(db, driver, s.Name)
+	}
+	if err := checkSquashApplied(squashed, applied); err != nil {
+		beeLogger.Log.Hint("Run or roll back the migrations so that all of them or none are applied, then squash them")
+		beeLogger.Log.Fatal(err.Error())
+	}
+	if irreversible := irreversibleMigrations(squashed); len(irreversible) > 0 {
+		if !force {
+			beeLogger.Log.Hint("Only migrations whose Down is made of m.SQL(\"...\") calls can be squashed. Use -force to complete the baseline Down() by hand")
+			beeLogger.Log.Fatalf("Could not read the SQL of the Down method of %s", strings.Join(irreversible, ", "))
+		}
+		for _, name := range irreversible {
+			beeLogger.Log.Warnf("Could not read the SQL of '%s'.Down(): the baseline Down() fails until it is completed by hand", name)
+		}
+	}
+
+	var names, up []string
+	for _, s := range squashed {
+		names = append(names, s.Name)
+		if !s.UpOK {
+			// fall back to the statements recorded when the migration was applied
+			recorded, ok := getAppliedStatements(db, driver, s.Name)
+			if !ok {
+				beeLogger.Log.Hint("Only migrations made of m.SQL(\"...\") calls can be squashed without being applied")
+				beeLogger.Log.Fatalf("Could not read the SQL of migration '%s'", s.Name)
+			}
+			s.Up = splitSQLStatements(recorded, driver)
+		}
+		up = append(up, s.Up...)
+	}
+	down := baselineDown(squashed)
+	var upCalls []string
+	for _, stmt := range up {
+		upCalls = append(upCalls, "m.SQL("+strconv.Quote(stmt)+")")
+	}
+
+	last := squashed[len(squashed)-1]
+	structName := "Baseline_" + last.Created
+	fpath := filepath.Join(dir, last.Created+"_baseline.go")
+	content := strings.Replace(BaselineMigrationTPL, "{{StructName}}", structName, -1)
+	content = strings.Replace(content, "{{CurrTime}}", last.Created, -1)
+	content = strings.Replace(content, "{{Squashed}}", strings.Join(names, ","), -1)
+	content = strings.Replace(content, "{{UpSQL}}", strings.Join(upCalls, "\n"), -1)
+	content = strings.Replace(content, "{{DownSQL}}", strings.Join(down, "\n"), -1)
+
+	// the baseline is written before the squashed migrations are removed, so
+	// that a failure leaves them in place
+	formatted, err := format.Source([]byte(content))
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not format baseline migration: %s", err)
+	}
+	if utils.IsExist(fpath) {
+		beeLogger.Log.Fatalf("Could not write baseline migration: '%s' already exists", fpath)
+	}
+	if err := ioutil.WriteFile(fpath, formatted, 0666); err != nil {
+		beeLogger.Log.Fatalf("Could not write baseline migration: %s", err)
+	}
+	beeLogger.Log.Infof("Created '%s' replacing %d migrations", fpath, len(squashed))
+	for _, s := range squashed {
+		if err := os.Remove(s.Path); err != nil {
+			beeLogger.Log.Fatalf("Could not remove migration file: %s", err)
+		}
+		beeLogger.Log.Infof("Removed '%s'", s.Path)
+	}
+
+	recordSquashedBaselines(db, driver, readMigrationFiles(dir))
+	recordChecksums(db, driver, readMigrationFiles(dir))
+}
+
+// checkSquashApplied returns an error when only some of the squashed migrations
+// are applied: the baseline could neither be recorded as applied nor be run
+func checkSquashApplied(squashed []*migrationSource, applied map[string]bool) error {
+	var done, pending []string
+	for _, s := range squashed {
+		if applied[s.Name] {
+			done = append(done, s.Name)
+		} else {
+			pending = append(pending, s.Name)
+		}
+	}
+	if len(done) > 0 && len(pending) > 0 {
+		return fmt.Errorf("Refusing to squash partially applied migrations: %s applied, %s not applied",
+			strings.Join(done, ", "), strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// baselineDown returns the body of the Down method of the baseline, reversing the
+// squashed migrations in the opposite order. When some of them cannot be reversed,
+// the statements are commented out after a panic, for Down to fail until it is
+// completed by hand.
+func baselineDown(squashed []*migrationSource) []string {
+	var down []string
+	for i := len(squashed) - 1; i >= 0; i-- {
+		s := squashed[i]
+		if !s.DownOK {
+			down = append(down, "// TODO: reverse "+s.Name)
+			continue
+		}
+		for _, stmt := range s.Down {
+			down = append(down, "m.SQL("+strconv.Quote(stmt)+")")
+		}
+	}
+	irreversible := irreversibleMigrations(squashed)
+	if len(irreversible) == 0 {
+		return down
+	}
+	for i, line := range down {
+		if !strings.HasPrefix(line, "//") {
+			down[i] = "// " + line
+		}
+	}
+	return append([]string{
+		"// complete the statements below and uncomment them, then remove this panic",
+		"panic(" + strconv.Quote("the baseline cannot reverse "+strings.Join(irreversible, ", ")+" until its Down is completed by hand") + ")",
+	}, down...)
+}
+
+// irreversibleMigrations returns the names of the migrations whose Down is not
+// made of m.SQL("...") calls
+func irreversibleMigrations(squashed []*migrationSource) (names []string) {
+	for _, s := range squashed {
+		if !s.DownOK {
+			names = append(names, s.Name)
+		}
+	}
+	return
+}
+
+// recordSquashedBaselines marks the baseline migrations as applied on databases where
+// all the migrations they replace were applied, and forgets about those migrations
+func recordSquashedBaselines(db *sql.DB, driver string, files map[string]*migrationFile) {
+	for _, f := range files {
+		content, err := ioutil.ReadFile(f.Path)
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not read migration file: %s", err)
+		}
+		match := squashedRegex.FindSubmatch(content)
+		if match == nil {
+			continue
+		}
+		if _, ok := getAppliedStatements(db, driver, f.Name); ok {
+			continue
+		}
+		squashed := strings.Split(strings.TrimSpace(string(match[1])), ",")
+		var statements []string
+		applied := true
+		for _, name := range squashed {
+			stmts, ok := getAppliedStatements(db, driver, name)
+			if !ok {
+				applied = false
+				break
+			}
+			statements = append(statements, stmts)
+		}
+		if !applied {
+			continue
+		}
+
+		beeLogger.Log.Infof("Marking baseline '%s' as applied", f.Name)
+		insertAppliedMigration(db, driver, f.Name, strings.Join(statements, "; "))
+		for _, name := range squashed {
+			if _, err := db.Exec("DELETE FROM migrations WHERE name = "+placeholder(driver, 1), name); err != nil {
+				beeLogger.Log.Fatalf("Could not remove squashed migration '%s': %s", name, err)
+			}
+		}
+	}
+}
+
+// insertAppliedMigration records a migration as applied without running it
+func insertAppliedMigration(db *sql.DB, driver, name, statements string) {
+	insert := "INSERT INTO migrations (name, created_at, statements, status) VALUES (" +
+		placeholder(driver, 1) + ", " + placeholder(driver, 2) + ", " + placeholder(driver, 3) + ", 'update')"
+	if _, err := db.Exec(insert, name, time.Now().Format("2006-01-02 15:04:05"), statements); err != nil {
+		beeLogger.Log.Fatalf("Could not record migration '%s': %s", name, err)
+	}
+}
+
+// getAppliedStatements returns the statements recorded for a migration whose
+// latest status is 'update'
+func getAppliedStatements(db *sql.DB, driver, name string) (string, bool) {
+	var statements sql.NullString
+	var status string
+	err := db.QueryRow("SELECT statements, status FROM migrations WHERE name = "+placeholder(driver, 1)+
+		" ORDER BY id_migration DESC LIMIT 1", name).Scan(&statements, &status)
+	if err == sql.ErrNoRows {
+		return "", false
+	}
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not retrieve migration '%s': %s", name, err)
+	}
+	return statements.String, status == "update"
+}
+
+// readMigrationSources returns the migrations of dir in the order they run
+func readMigrationSources(dir string) []*migrationSource {
+	var sources []*migrationSource
+	for _, f := range readMigrationFiles(dir) {
+		sources = append(sources, parseMigrationSource(f))
+	}
+	sortMigrationSources(sources)
+	return sources
+}
+
+// sortMigrationSources sorts migrations by creation time, then by name for the
+// ones created in the same second
+func sortMigrationSources(sources []*migrationSource) {
+	sort.SliceStable(sources, func(i, j int) bool {
+		if sources[i].Created != sources[j].Created {
+			return sources[i].Created < sources[j].Created
+		}
+		return sources[i].Name < sources[j].Name
+	})
+}
+
+// findMigration returns the index of the migration given by its registered name
+// or its file name, with or without the timestamp, or -1 when there is none
+func findMigration(sources []*migrationSource, name string) int {
+	for i, s := range sources {
+		base := strings.TrimSuffix(filepath.Base(s.Path), ".go")
+		// file names are the creation time, 20060102_150405, followed by _name
+		if s.Name == name || base == name || (len(base) > 16 && base[16:] == name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseMigrationSource reads the SQL passed to m.SQL() in the Up and Down methods
+// of a migration. UpOK and DownOK are false when a method does anything else.
+// It also finds the migration type, and whether it opts out of transactions.
+func parseMigrationSource(f *migrationFile) *migrationSource {
+	s := &migrationSource{migrationFile: f}
+	if len(f.Name) >= 15 {
+		s.Created = f.Name[len(f.Name)-15:]
+	}
+
+	content, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read migration file: %s", err)
+	}
+	s.NoTransaction = noTransactionRegex.Match(content)
+	file, err := parser.ParseFile(token.NewFileSet(), f.Path, content, parser.ParseComments)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not parse migration file: %s", err)
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || fn.Body == nil {
+			continue
+		}
+		switch fn.Name.Name {
+		case "Up":
+			s.Type = receiverType(fn.Recv)
+			s.Up, s.UpOK = sqlCalls(fn.Body)
+		case "Down":
+			s.HasDown = true
+			s.Down, s.DownOK = sqlCalls(fn.Body)
+		case "SQL", "Migrate":
+			s.Hooked = true
+		}
+		if name := embeddedCall(fn.Body); name != "" {
+			s.Bypassed = name
+		}
+	}
+	return s
+}
+
+// embeddedCall returns the name of the first queuing method of migration.Migration called
+// directly in body, as in m.Migration.SQL("..."), or an empty string
+func embeddedCall(body *ast.BlockStmt) (name string) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok || name != "" {
+			return name == ""
+		}
+		if x, ok := sel.X.(*ast.SelectorExpr); ok && x.Sel.Name == "Migration" {
+			switch sel.Sel.Name {
+			case "Up", "Down", "SQL", "Migrate":
+				name = sel.Sel.Name
+			}
+		}
+		return true
+	})
+	return
+}
+
+// receiverType returns the name of the type of a method receiver
+func receiverType(recv *ast.FieldList) string {
+	expr := recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// sqlCalls returns the statements of a body made only of m.SQL("...") calls
+func sqlCalls(body *ast.BlockStmt) (statements []string, ok bool) {
+	for _, stmt := range body.List {
+		expr, isExpr := stmt.(*ast.ExprStmt)
+		if !isExpr {
+			return nil, false
+		}
+		call, isCall := expr.X.(*ast.CallExpr)
+		if !isCall || len(call.Args) != 1 {
+			return nil, false
+		}
+		sel, isSel := call.Fun.(*ast.SelectorExpr)
+		if !isSel || sel.Sel.Name != "SQL" {
+			return nil, false
+		}
+		s, isString := stringConstant(call.Args[0])
+		if !isString {
+			return nil, false
+		}
+		statements = append(statements, s)
+	}
+	return statements, true
+}
+
+// stringConstant evaluates a string literal or a concatenation of string literals
+func stringConstant(expr ast.Expr) (string, bool) {
+	switch x := expr.(type) {
+	case *ast.BasicLit:
+		if x.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(x.Value)
+		return s, err == nil
+	case *ast.BinaryExpr:
+		if x.Op != token.ADD {
+			return "", false
+		}
+		l, ok := stringConstant(x.X)
+		if !ok {
+			return "", false
+		}
+		r, ok := stringConstant(x.Y)
+		return l + r, ok
+	case *ast.ParenExpr:
+		return stringConstant(x.X)
+	}
+	return "", false
+}
+
+const (
+	// BaselineMigrationTPL is the migration replacing squashed migrations
+	BaselineMigrationTPL = `package main
+
+import (
+	"github.com/beego/beego/v2/client/orm/migration"
+)
+
+// bee:squashed {{Squashed}}
+
+// DO NOT MODIFY
+type {{StructName}} struct {
+	migration.Migration
+}
+
+// DO NOT MODIFY
+func init() {
+	m := &{{StructName}}{}
+	m.Created = "{{CurrTime}}"
+	migration.Register("{{StructName}}", m)
+}
+
+// Run the migrations
+func (m *{{StructName}}) Up() {
+	{{UpSQL}}
+}
+
+// Reverse the migrations
+func (m *{{StructName}}) Down() {
+	{{DownSQL}}
+}
+`
+)
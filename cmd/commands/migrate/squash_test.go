@@ -0,0 +1,197 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package migrate
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStringConstant(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		ok       bool
+	}{
+		{`"CREATE TABLE a"`, "CREATE TABLE a", true},
+		{"`CREATE TABLE \"a\"`", `CREATE TABLE "a"`, true},
+		{`"CREATE " + ("TABLE" + " a")`, "CREATE TABLE a", true},
+		{`"DROP TABLE " + name`, "", false},
+		{`fmt.Sprintf("DROP TABLE %s", "a")`, "", false},
+		{`42`, "", false},
+		{`"a" - "b"`, "", false},
+	}
+	for _, tt := range tests {
+		expr, err := parser.ParseExpr(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, ok := stringConstant(expr)
+		if ok != tt.ok || (ok && s != tt.expected) {
+			t.Errorf("%s: expected %q, %t, got %q, %t", tt.expr, tt.expected, tt.ok, s, ok)
+		}
+	}
+}
+
+func TestSQLCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+		ok       bool
+	}{
+		{"empty", `{}`, nil, true},
+		{"sql calls", `{ m.SQL("CREATE TABLE a"); m.SQL("CREATE " + "INDEX b") }`, []string{"CREATE TABLE a", "CREATE INDEX b"}, true},
+		{"other call", `{ m.SQL("CREATE TABLE a"); m.Exec("b") }`, nil, false},
+		{"two arguments", `{ m.SQL("a", "b") }`, nil, false},
+		{"variable", `{ m.SQL(query) }`, nil, false},
+		{"statement", `{ if true { m.SQL("a") } }`, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.ParseExpr("func() " + tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			statements, ok := sqlCalls(expr.(*ast.FuncLit).Body)
+			if !reflect.DeepEqual(statements, tt.expected) || ok != tt.ok {
+				t.Errorf("expected %q, %t, got %q, %t", tt.expected, tt.ok, statements, ok)
+			}
+		})
+	}
+}
+
+func TestParseMigrationSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fpath := filepath.Join(dir, "20200102_150405_users.go")
+	content := `package main
+
+import "github.com/beego/beego/v2/client/orm/migration"
+
+// bee:no-transaction
+
+type Users_20200102_150405 struct {
+	migration.Migration
+}
+
+func (m *Users_20200102_150405) Up() {
+	m.SQL("CREATE TABLE users (id int)")
+	m.SQL("CREATE INDEX users_id ON users (id)")
+}
+
+func (m *Users_20200102_150405) Down() {
+	for _, table := range []string{"users"} {
+		m.SQL("DROP TABLE " + table)
+	}
+}
+`
+	if err := ioutil.WriteFile(fpath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := parseMigrationSource(&migrationFile{Name: "Users_20200102_150405", Path: fpath})
+	if s.Type != "Users_20200102_150405" || s.Created != "20200102_150405" || !s.NoTransaction {
+		t.Errorf("unexpected migration: %+v", s)
+	}
+	if !s.UpOK || !reflect.DeepEqual(s.Up, []string{"CREATE TABLE users (id int)", "CREATE INDEX users_id ON users (id)"}) {
+		t.Errorf("unexpected Up statements: %t %q", s.UpOK, s.Up)
+	}
+	if s.DownOK {
+		t.Errorf("expected the Down statements not to be read, got %q", s.Down)
+	}
+	if names := irreversibleMigrations([]*migrationSource{s}); !reflect.DeepEqual(names, []string{s.Name}) {
+		t.Errorf("expected the migration to be irreversible, got %q", names)
+	}
+}
+
+func TestCheckSquashApplied(t *testing.T) {
+	squashed := []*migrationSource{
+		{migrationFile: &migrationFile{Name: "a"}},
+		{migrationFile: &migrationFile{Name: "b"}},
+	}
+	tests := []struct {
+		name    string
+		applied map[string]bool
+		fails   bool
+	}{
+		{"none applied", map[string]bool{}, false},
+		{"all applied", map[string]bool{"a": true, "b": true}, false},
+		{"partially applied", map[string]bool{"a": true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkSquashApplied(squashed, tt.applied); (err != nil) != tt.fails {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSortMigrationSources(t *testing.T) {
+	source := func(name, created string) *migrationSource {
+		return &migrationSource{migrationFile: &migrationFile{Name: name}, Created: created}
+	}
+	sources := []*migrationSource{
+		source("Users_20200102_150405", "20200102_150405"),
+		source("Posts_20200102_150405", "20200102_150405"),
+		source("Init_20200101_000000", "20200101_000000"),
+	}
+	sortMigrationSources(sources)
+	var names []string
+	for _, s := range sources {
+		names = append(names, s.Name)
+	}
+	if expected := []string{"Init_20200101_000000", "Posts_20200102_150405", "Users_20200102_150405"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %q, got %q", expected, names)
+	}
+}
+
+func TestBaselineDown(t *testing.T) {
+	users := &migrationSource{migrationFile: &migrationFile{Name: "Users"}, Down: []string{"DROP TABLE users"}, DownOK: true}
+	posts := &migrationSource{migrationFile: &migrationFile{Name: "Posts"}, Down: []string{"DROP TABLE posts"}, DownOK: true}
+	tags := &migrationSource{migrationFile: &migrationFile{Name: "Tags"}}
+
+	expected := []string{`m.SQL("DROP TABLE posts")`, `m.SQL("DROP TABLE users")`}
+	if down := baselineDown([]*migrationSource{users, posts}); !reflect.DeepEqual(down, expected) {
+		t.Errorf("expected %q, got %q", expected, down)
+	}
+
+	// Down fails until the migrations that cannot be reversed are completed by hand
+	down := baselineDown([]*migrationSource{users, tags, posts})
+	expected = []string{
+		"// complete the statements below and uncomment them, then remove this panic",
+		`panic("the baseline cannot reverse Tags until its Down is completed by hand")`,
+		`// m.SQL("DROP TABLE posts")`,
+		"// TODO: reverse Tags",
+		`// m.SQL("DROP TABLE users")`,
+	}
+	if !reflect.DeepEqual(down, expected) {
+		t.Errorf("expected %q, got %q", expected, down)
+	}
+	src := "package main\n\nfunc Down() {\n" + strings.Join(down, "\n") + "\n}\n"
+	if _, err := parser.ParseFile(token.NewFileSet(), "baseline.go", src, 0); err != nil {
+		t.Errorf("the baseline does not parse: %s\n%s", err, src)
+	}
+}
@@ -56,6 +56,8 @@ Creates a Beego application for the given app name in the current directory.
             └── {{"views"|foldername}}
                   └── index.tpl
 
+  Use {{"-dry-run"|bold}} to list the files without writing them, {{"-force"|bold}} to overwrite an existing
+  application and {{"-skip-existing"|bold}} to only add the missing files.
 `,
 	PreRun: nil,
 	Run:    CreateApp,
@@ -260,11 +262,11 @@ var reloadJsClient = `function b(a){var c=new WebSocket(a);c.onclose=function(){
 func init() {
 	CmdNew.Flag.Var(&gopath, "gopath", "Support go path,default false")
 	CmdNew.Flag.Var(&beegoVersion, "beego", "set beego version,only take effect by go mod")
+	utils.AddWriteFlags(&CmdNew.Flag)
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdNew)
 }
 
 func CreateApp(cmd *commands.Command, args []string) int {
-	output := cmd.Out()
 	if len(args) == 0 {
 		beeLogger.Log.Fatal("Argument [appname] is missing")
 	}
@@ -294,7 +296,8 @@ func CreateApp(cmd *commands.Command, args []string) int {
 		}
 	}
 
-	if utils.IsExist(appPath) {
+	// -dry-run, -force and -skip-existing decide what happens to the existing files
+	if utils.IsExist(appPath) && !utils.DryRun && !utils.Force && !utils.SkipExisting {
 		beeLogger.Log.Errorf(colors.Bold("Application '%s' already exists"), appPath)
 		beeLogger.Log.Warn(colors.Bold("Do you want to overwrite it? [Yes|No] "))
 		if !utils.AskForConfirmation() {
@@ -309,50 +312,32 @@ func CreateApp(cmd *commands.Command, args []string) int {
 		packPath = path.Base(appPath)
 	}
 
-	os.MkdirAll(appPath, 0755)
+	utils.CreateGeneratedDir(appPath)
 	if gopath != `true` {
-		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "go.mod"), "\x1b[0m")
-		utils.WriteToFile(path.Join(appPath, "go.mod"), fmt.Sprintf(goMod, packPath, utils.GetGoVersionSkipMinor(), beegoVersion.String()))
+		utils.WriteGeneratedFile(path.Join(appPath, "go.mod"), fmt.Sprintf(goMod, packPath, utils.GetGoVersionSkipMinor(), beegoVersion.String()), utils.OverwriteExisting)
 	}
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", appPath+string(path.Separator), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "conf"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "conf")+string(path.Separator), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "controllers"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "controllers")+string(path.Separator), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "models"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "models")+string(path.Separator), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "routers"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "routers")+string(path.Separator), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "tests"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "tests")+string(path.Separator), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "static"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "static")+string(path.Separator), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "static", "js"), 0755)
-	utils.WriteToFile(path.Join(appPath, "static", "js", "reload.min.js"), reloadJsClient)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "static", "js")+string(path.Separator), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "static", "css"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "static", "css")+string(path.Separator), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "static", "img"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "static", "img")+string(path.Separator), "\x1b[0m")
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "views")+string(path.Separator), "\x1b[0m")
-	os.Mkdir(path.Join(appPath, "views"), 0755)
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "conf", "app.conf"), "\x1b[0m")
-	utils.WriteToFile(path.Join(appPath, "conf", "app.conf"), strings.Replace(appconf, "{{.Appname}}", path.Base(args[0]), -1))
-
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "controllers", "default.go"), "\x1b[0m")
-	utils.WriteToFile(path.Join(appPath, "controllers", "default.go"), controllers)
-
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "views", "index.tpl"), "\x1b[0m")
-	utils.WriteToFile(path.Join(appPath, "views", "index.tpl"), indextpl)
-
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "routers", "router.go"), "\x1b[0m")
-	utils.WriteToFile(path.Join(appPath, "routers", "router.go"), strings.Replace(router, "{{.Appname}}", packPath, -1))
-
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "tests", "default_test.go"), "\x1b[0m")
-	utils.WriteToFile(path.Join(appPath, "tests", "default_test.go"), strings.Replace(test, "{{.Appname}}", packPath, -1))
-
-	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", path.Join(appPath, "main.go"), "\x1b[0m")
-	utils.WriteToFile(path.Join(appPath, "main.go"), strings.Replace(maingo, "{{.Appname}}", packPath, -1))
+	utils.CreateGeneratedDir(path.Join(appPath, "conf"))
+	utils.CreateGeneratedDir(path.Join(appPath, "controllers"))
+	utils.CreateGeneratedDir(path.Join(appPath, "models"))
+	utils.CreateGeneratedDir(path.Join(appPath, "routers"))
+	utils.CreateGeneratedDir(path.Join(appPath, "tests"))
+	utils.CreateGeneratedDir(path.Join(appPath, "static"))
+	utils.CreateGeneratedDir(path.Join(appPath, "static", "js"))
+	utils.WriteGeneratedFile(path.Join(appPath, "static", "js", "reload.min.js"), reloadJsClient, utils.OverwriteExisting)
+	utils.CreateGeneratedDir(path.Join(appPath, "static", "css"))
+	utils.CreateGeneratedDir(path.Join(appPath, "static", "img"))
+	utils.CreateGeneratedDir(path.Join(appPath, "views"))
+	utils.WriteGeneratedFile(path.Join(appPath, "conf", "app.conf"), strings.Replace(appconf, "{{.Appname}}", path.Base(args[0]), -1), utils.OverwriteExisting)
+
+	utils.WriteGeneratedFile(path.Join(appPath, "controllers", "default.go"), controllers, utils.OverwriteExisting)
+
+	utils.WriteGeneratedFile(path.Join(appPath, "views", "index.tpl"), indextpl, utils.OverwriteExisting)
+
+	utils.WriteGeneratedFile(path.Join(appPath, "routers", "router.go"), strings.Replace(router, "{{.Appname}}", packPath, -1), utils.OverwriteExisting)
+
+	utils.WriteGeneratedFile(path.Join(appPath, "tests", "default_test.go"), strings.Replace(test, "{{.Appname}}", packPath, -1), utils.OverwriteExisting)
+
+	utils.WriteGeneratedFile(path.Join(appPath, "main.go"), strings.Replace(maingo, "{{.Appname}}", packPath, -1), utils.OverwriteExisting)
 
 	beeLogger.Log.Success("New application successfully created!")
 	return 0
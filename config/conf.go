@@ -15,9 +15,13 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 
@@ -41,9 +45,11 @@ var Conf = struct {
 	Envs               []string
 	Bale               bale
 	Database           database
-	EnableReload       bool              `json:"enable_reload" yaml:"enable_reload"`
-	EnableNotification bool              `json:"enable_notification" yaml:"enable_notification"`
-	Scripts            map[string]string `json:"scripts" yaml:"scripts"`
+	Databases          map[string]database `json:"databases" yaml:"databases"`
+	EnableReload       bool                `json:"enable_reload" yaml:"enable_reload"`
+	EnableNotification bool                `json:"enable_notification" yaml:"enable_notification"`
+	Scripts            map[string]string   `json:"scripts" yaml:"scripts" expand:"-"`
+	Templates          string              `json:"templates" yaml:"templates"` // Directory of the templates overriding the ones of bee generate
 }{
 	WatchExts:       []string{".go"},
 	WatchExtsStatic: []string{".html", ".tpl", ".js", ".css"},
@@ -60,8 +66,10 @@ var Conf = struct {
 	Database: database{
 		Driver: "mysql",
 	},
+	Databases:          map[string]database{},
 	EnableNotification: true,
 	Scripts:            map[string]string{},
+	Templates:          "templates",
 }
 
 // dirStruct describes the application's directory structure
@@ -84,11 +92,63 @@ type database struct {
 	Driver string
 	Conn   string
 	Dir    string
+	Schema string // file the schema is dumped to after migrating, e.g. database/schema.sql
+}
+
+// DatabaseEnv returns the name of the database environment to use.
+// It defaults to BEEGO_RUNMODE, then to dev.
+func DatabaseEnv(env string) string {
+	if env != "" {
+		return env
+	}
+	if env = os.Getenv("BEEGO_RUNMODE"); env != "" {
+		return env
+	}
+	return "dev"
+}
+
+// SelectDatabase replaces the database settings with the ones of the named
+// environment in the databases block, if there is one.
+// It returns an error when an environment given explicitly, or through
+// BEEGO_RUNMODE, is not defined, for the commands needing a database to fail.
+func SelectDatabase(env string) error {
+	if len(Conf.Databases) == 0 {
+		return nil
+	}
+	explicit := env != "" || os.Getenv("BEEGO_RUNMODE") != ""
+	env = DatabaseEnv(env)
+	db, ok := Conf.Databases[env]
+	if !ok {
+		if explicit {
+			var names []string
+			for name := range Conf.Databases {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("Database environment '%s' is not defined. Available: %s", env, strings.Join(names, ", "))
+		}
+		return nil
+	}
+
+	beeLogger.Log.Infof("Using '%s' database environment", env)
+	if db.Driver != "" {
+		Conf.Database.Driver = db.Driver
+	}
+	Conf.Database.Conn = db.Conn
+	if db.Dir != "" {
+		Conf.Database.Dir = db.Dir
+	}
+	if db.Schema != "" {
+		Conf.Database.Schema = db.Schema
+	}
+	return nil
 }
 
 // LoadConfig loads the bee tool configuration.
 // It looks for Beefile or bee.json in the current path,
 // and falls back to default configuration in case not found.
+// String values may reference environment variables as ${VAR} or ${VAR:-default},
+// and be read from a file with file:/path/to/file. Scripts are left to the shell.
 func LoadConfig() {
 	currentPath, err := os.Getwd()
 	if err != nil {
@@ -127,6 +187,11 @@ func LoadConfig() {
 		}
 	}
 
+	// Expand ${VAR}, ${VAR:-default} and file: references
+	if err := expandStrings(reflect.ValueOf(&Conf)); err != nil {
+		beeLogger.Log.Fatalf("Failed to expand configuration value %s", err)
+	}
+
 	// Check format version
 	if Conf.Version != confVer {
 		beeLogger.Log.Warn("Your configuration file is outdated. Please do consider updating it.")
@@ -0,0 +1,72 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import "testing"
+
+func TestDatabaseEnv(t *testing.T) {
+	t.Setenv("BEEGO_RUNMODE", "")
+	if env := DatabaseEnv(""); env != "dev" {
+		t.Errorf("expected dev by default, got %s", env)
+	}
+	t.Setenv("BEEGO_RUNMODE", "test")
+	if env := DatabaseEnv(""); env != "test" {
+		t.Errorf("expected BEEGO_RUNMODE, got %s", env)
+	}
+	if env := DatabaseEnv("prod"); env != "prod" {
+		t.Errorf("expected the explicit environment, got %s", env)
+	}
+}
+
+func TestSelectDatabase(t *testing.T) {
+	defer func(db database, dbs map[string]database) {
+		Conf.Database, Conf.Databases = db, dbs
+	}(Conf.Database, Conf.Databases)
+
+	databases := map[string]database{
+		"dev":  {Driver: "sqlite3", Conn: "dev.db"},
+		"prod": {Conn: "root:@tcp(db:3306)/prod", Dir: "migrations"},
+	}
+	tests := []struct {
+		name      string
+		databases map[string]database
+		env       string
+		runmode   string
+		expected  database
+		fails     bool
+	}{
+		{"no databases", nil, "prod", "", database{Driver: "mysql", Conn: "default"}, false},
+		{"default environment", databases, "", "", database{Driver: "sqlite3", Conn: "dev.db"}, false},
+		{"explicit environment", databases, "prod", "test", database{Driver: "mysql", Conn: "root:@tcp(db:3306)/prod", Dir: "migrations"}, false},
+		{"runmode environment", databases, "", "prod", database{Driver: "mysql", Conn: "root:@tcp(db:3306)/prod", Dir: "migrations"}, false},
+		{"missing default environment", map[string]database{"prod": databases["prod"]}, "", "", database{Driver: "mysql", Conn: "default"}, false},
+		{"missing explicit environment", databases, "staging", "", database{Driver: "mysql", Conn: "default"}, true},
+		{"missing runmode environment", databases, "", "staging", database{Driver: "mysql", Conn: "default"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BEEGO_RUNMODE", tt.runmode)
+			Conf.Database = database{Driver: "mysql", Conn: "default"}
+			Conf.Databases = tt.databases
+			err := SelectDatabase(tt.env)
+			if (err != nil) != tt.fails {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if Conf.Database != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, Conf.Database)
+			}
+		})
+	}
+}
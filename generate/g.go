@@ -18,11 +18,34 @@ import "github.com/beego/bee/v2/utils"
 
 var SQLDriver utils.DocValue
 var SQLConn utils.DocValue
+var DatabaseEnv utils.DocValue
 var Level utils.DocValue
 var Tables utils.DocValue
+var Schemas utils.DocValue
 var Fields utils.DocValue
 var DDL utils.DocValue
+var AutoMigration bool
+var Repository bool
+var SeedFormat utils.DocValue
 
+// bee generate model -from-json/-from-schema
+var FromJSON utils.DocValue
+var FromSchema utils.DocValue
+
+// bee generate fromspec -spec
+var Spec utils.DocValue
+
+// bee generate client -lang/-out
+var ClientLang utils.DocValue
+var ClientOut utils.DocValue
+
+// bee generate migration -add/-drop/-rename/-index/-unique
+var AlterTable utils.DocValue
+var AddFields utils.DocValue
+var DropFields utils.DocValue
+var RenameFields utils.DocValue
+var IndexFields utils.DocValue
+var UniqueFields utils.DocValue
 
 // bee generate routers
 var ControllerDirectory utils.DocValue
@@ -23,10 +23,11 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/beego/bee/v2/internal/pkg/sqlite"
 	beeLogger "github.com/beego/bee/v2/logger"
-	"github.com/beego/bee/v2/logger/colors"
 	"github.com/beego/bee/v2/utils"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
@@ -46,6 +47,20 @@ type DbTransformer interface {
 	GetGoDataType(sqlType string) (string, error)
 }
 
+// DbIndexReader is implemented by the DbTransformers reading the secondary indexes
+// of a table. It is apart from DbTransformer so that the transformers written
+// without it still satisfy DbTransformer.
+type DbIndexReader interface {
+	GetIndexes(conn *sql.DB, table *Table)
+}
+
+// getIndexes reads the secondary indexes of a table, if the transformer can
+func getIndexes(trans DbTransformer, db *sql.DB, table *Table) {
+	if r, ok := trans.(DbIndexReader); ok {
+		r.GetIndexes(db, table)
+	}
+}
+
 // MysqlDB is the MySQL version of DbTransformer
 type MysqlDB struct {
 }
@@ -54,10 +69,15 @@ type MysqlDB struct {
 type PostgresDB struct {
 }
 
+// SQLiteDB is the SQLite version of DbTransformer
+type SQLiteDB struct {
+}
+
 // dbDriver maps a DBMS name to its version of DbTransformer
 var dbDriver = map[string]DbTransformer{
 	"mysql":    &MysqlDB{},
 	"postgres": &PostgresDB{},
+	"sqlite3":  &SQLiteDB{},
 }
 
 type MvcPath struct {
@@ -140,21 +160,60 @@ var typeMappingPostgres = map[string]string{
 	"inet":                        "string",  // ip address
 }
 
+// typeMappingSQLite maps SQLite declared types to corresponding Go data type.
+// Other declared types follow the SQLite type affinity rules, see GetGoDataType.
+var typeMappingSQLite = map[string]string{
+	"integer":   "int", // int
+	"int":       "int",
+	"tinyint":   "int8",
+	"smallint":  "int16",
+	"mediumint": "int32",
+	"bigint":    "int64",
+	"boolean":   "bool", // bool
+	"bool":      "bool",
+	"date":      "time.Time", // time
+	"datetime":  "time.Time",
+	"timestamp": "time.Time",
+	"time":      "time.Time",
+	"real":      "float64", // float & decimal
+	"float":     "float32",
+	"double":    "float64",
+	"decimal":   "float64",
+	"numeric":   "float64",
+	"blob":      "[]byte", // binary
+}
+
 // Table represent a table in a database
 type Table struct {
 	Name          string
+	Schema        string // schema of the table, when the tables are listed with -schema
+	Alias         string // name of the model and its files, when it differs from the table name
 	Pk            string
+	PkColumns     []string // all the columns of the primary key, in order
 	Uk            []string
 	Fk            map[string]*ForeignKey
 	Columns       []*Column
+	Indexes       []*Index
 	ImportTimePkg bool
+	RelTableOnly  bool // join table used through rel_table, which gets no model
 }
 
 // Column reprsents a column for a table
 type Column struct {
-	Name string
-	Type string
-	Tag  *OrmTag
+	Name       string
+	Type       string
+	Tag        *OrmTag
+	SQLType    string // column type as reported by the database, e.g. varchar(255)
+	Nullable   bool
+	HasDefault bool  // the column has a default value
+	Enum       *Enum // values of the column, when restricted by an ENUM type or a CHECK constraint
+}
+
+// Index represents a secondary index of a table, unique or not
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
 }
 
 // ForeignKey represents a foreign key column for a table
@@ -185,12 +244,14 @@ type OrmTag struct {
 	RelFk       bool
 	ReverseMany bool
 	RelM2M      bool
+	RelThrough  string // model of the join table of a rel(m2m), with its package path
+	RelTable    string // join table of a rel(m2m) that is not a model
 	Comment     string //column comment
 }
 
 // String returns the source code string for the Table struct
 func (tb *Table) String() string {
-	rv := fmt.Sprintf("type %s struct {\n", utils.CamelCase(tb.Name))
+	rv := fmt.Sprintf("type %s struct {\n", tb.modelName())
 	for _, v := range tb.Columns {
 		rv += v.String() + "\n"
 	}
@@ -198,10 +259,80 @@ func (tb *Table) String() string {
 	return rv
 }
 
+// alias returns the name of the files and the namespace of the table
+func (tb *Table) alias() string {
+	if tb.Alias != "" {
+		return tb.Alias
+	}
+	return tb.Name
+}
+
+// modelName returns the name of the model of the table
+func (tb *Table) modelName() string {
+	return utils.CamelCase(tb.alias())
+}
+
+// ormTableName returns the name returned by the TableName method of the model. The
+// schema and the table are separated by quotes since the ORM quotes the whole name.
+func (tb *Table) ormTableName() string {
+	if tb.Schema == "" {
+		return tb.Name
+	}
+	return tb.Schema + `\".\"` + tb.Name
+}
+
+// tableKey returns the name of a table, qualified with its schema if any
+func tableKey(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// refKey returns the qualified name of the table referenced by a foreign key of tb
+func (tb *Table) refKey(fk *ForeignKey) string {
+	if tb.Schema == "" {
+		return fk.RefTable
+	}
+	return tableKey(fk.RefSchema, fk.RefTable)
+}
+
+// isUnique tells whether a column has a unique index of its own
+func (tb *Table) isUnique(column string) bool {
+	for _, idx := range tb.Indexes {
+		if idx.Unique && len(idx.Columns) == 1 && idx.Columns[0] == column {
+			return true
+		}
+	}
+	return false
+}
+
+// addIndexColumn appends a column to the named index, creating the index on first use
+func (tb *Table) addIndexColumn(name, column string, unique bool) {
+	for _, idx := range tb.Indexes {
+		if idx.Name == name {
+			idx.Columns = append(idx.Columns, column)
+			return
+		}
+	}
+	tb.Indexes = append(tb.Indexes, &Index{Name: name, Columns: []string{column}, Unique: unique})
+}
+
+// withoutIndexes returns the indexes but the named ones
+func withoutIndexes(indexes []*Index, names map[string]bool) (kept []*Index) {
+	for _, idx := range indexes {
+		if !names[idx.Name] {
+			kept = append(kept, idx)
+		}
+	}
+	return
+}
+
 // String returns the source code string of a field in Table struct
 // It maps to a column in database table. e.g. Id int `orm:"column(id);auto"`
 func (col *Column) String() string {
-	return fmt.Sprintf("%s %s %s", col.Name, col.Type, col.Tag.String())
+	tag := addStructTag(col.Tag.String(), "valid", strings.Join(col.validRules(), ";"))
+	return fmt.Sprintf("%s %s %s", col.Name, col.Type, tag)
 }
 
 // String returns the ORM tag string for a column
@@ -246,6 +377,12 @@ func (tag *OrmTag) String() string {
 	if tag.RelM2M {
 		ormOptions = append(ormOptions, "rel(m2m)")
 	}
+	if tag.RelThrough != "" {
+		ormOptions = append(ormOptions, fmt.Sprintf("rel_through(%s)", tag.RelThrough))
+	}
+	if tag.RelTable != "" {
+		ormOptions = append(ormOptions, fmt.Sprintf("rel_table(%s)", tag.RelTable))
+	}
 	if tag.Pk {
 		ormOptions = append(ormOptions, "pk")
 	}
@@ -287,8 +424,9 @@ func GenerateAppcode(driver, connStr, level, tables, currpath string) {
 	switch driver {
 	case "mysql":
 	case "postgres":
-	case "sqlite":
-		beeLogger.Log.Fatal("Generating app code from SQLite database is not supported yet.")
+	case "sqlite", sqlite.DriverName:
+		sqlite.Check(driver)
+		driver = sqlite.DriverName
 	default:
 		beeLogger.Log.Fatal("Unknown database driver. Must be either \"mysql\", \"postgres\" or \"sqlite\"")
 	}
@@ -320,6 +458,7 @@ func gen(dbms, connStr string, mode byte, selectedTableNames map[string]bool, ap
 		mvcPath.RouterPath = path.Join(apppath, "routers")
 		createPaths(mode, mvcPath)
 		pkgPath := getPackagePath(apppath)
+		addRelations(tables, pkgPath)
 		writeSourceFiles(pkgPath, tables, mode, mvcPath)
 	} else {
 		beeLogger.Log.Fatalf("Generating app code from '%s' database is not supported yet.", dbms)
@@ -354,17 +493,168 @@ func getTableObjects(tableNames []string, db *sql.DB, dbTransformer DbTransforme
 		// create a table struct
 		tb := new(Table)
 		tb.Name = tableName
+		if i := strings.Index(tableName, "."); i > 0 {
+			tb.Schema, tb.Name = tableName[:i], tableName[i+1:]
+		}
 		tb.Fk = make(map[string]*ForeignKey)
 		dbTransformer.GetConstraints(db, tb, blackList)
+		getIndexes(dbTransformer, db, tb)
 		tables = append(tables, tb)
 	}
+	// the models of tables with the same name in several schemas are prefixed with the schema
+	names := make(map[string]int)
+	for _, tb := range tables {
+		names[tb.Name]++
+	}
+	byKey := make(map[string]*Table)
+	for _, tb := range tables {
+		if names[tb.Name] > 1 && tb.Schema != "" {
+			tb.Alias = tb.Schema + "_" + tb.Name
+		}
+		byKey[tableKey(tb.Schema, tb.Name)] = tb
+	}
 	// process columns, ignoring blacklisted tables
 	for _, tb := range tables {
 		dbTransformer.GetColumns(db, tb, blackList)
+		for _, col := range tb.Columns {
+			if !col.Tag.RelFk && !col.Tag.RelOne {
+				continue
+			}
+			if ref, ok := byKey[tb.refKey(tb.Fk[col.Tag.Column])]; ok {
+				col.Type = "*" + ref.modelName()
+			}
+		}
 	}
+	renameEnums(tables)
 	return
 }
 
+// addRelations adds many-to-many fields for the join tables, and reverse
+// fields on the tables referenced by foreign keys
+func addRelations(tables []*Table, pkgPath string) {
+	byName := make(map[string]*Table)
+	for _, tb := range tables {
+		byName[tableKey(tb.Schema, tb.Name)] = tb
+	}
+
+	for _, tb := range tables {
+		if from, to, ok := joinTable(tb, byName); ok {
+			tag := &OrmTag{RelM2M: true}
+			if tb.Pk == "" {
+				// a join table without its own primary key cannot be a model
+				if tb.Fk[tb.PkColumns[0]].Name != from.Name+"_id" || tb.Fk[tb.PkColumns[1]].Name != to.Name+"_id" {
+					beeLogger.Log.Warnf("Skipping the many-to-many relation through '%s', its columns must be named %s_id and %s_id", tb.Name, from.Name, to.Name)
+					continue
+				}
+				tag.RelTable = tb.Name
+				tb.RelTableOnly = true
+			} else {
+				tag.RelThrough = pkgPath + "/models." + tb.modelName()
+			}
+			from.addRelationField(pluralize(to.modelName()), "[]*"+to.modelName(), tag)
+			if from != to {
+				to.addRelationField(pluralize(from.modelName()), "[]*"+from.modelName(), &OrmTag{ReverseMany: true})
+			}
+			continue
+		}
+
+		// the reverse field is ambiguous when several foreign keys reference the same table:
+		// the ORM resolves every reverse field to the first of them
+		refs := make(map[string]int)
+		for _, fk := range tb.Fk {
+			refs[tb.refKey(fk)]++
+		}
+		warned := make(map[string]bool)
+		for _, col := range tb.Columns {
+			if !col.Tag.RelFk && !col.Tag.RelOne {
+				continue
+			}
+			key := tb.refKey(tb.Fk[col.Tag.Column])
+			ref, ok := byName[key]
+			if !ok {
+				continue
+			}
+			if refs[key] > 1 {
+				if !warned[key] {
+					beeLogger.Log.Warnf("Skipping the reverse relations of '%s' to '%s', which %d of its columns reference", tb.Name, ref.Name, refs[key])
+					beeLogger.Log.Hintf("Query them by field instead, e.g. o.QueryTable(new(%s)).Filter(\"%s\", %s)", tb.modelName(), col.Name, strings.ToLower(ref.modelName()))
+					warned[key] = true
+				}
+				continue
+			}
+			model := tb.modelName()
+			if col.Tag.RelOne {
+				ref.addRelationField(model, "*"+model, &OrmTag{ReverseOne: true})
+			} else {
+				ref.addRelationField(pluralize(model), "[]*"+model, &OrmTag{ReverseMany: true})
+			}
+		}
+	}
+}
+
+// joinTable tells whether a table only links two other tables, either through
+// a primary key made of two foreign keys, or through two foreign keys forming a
+// unique index, and returns the tables it links
+func joinTable(tb *Table, tables map[string]*Table) (from, to *Table, ok bool) {
+	var columns []string
+	if len(tb.PkColumns) == 2 {
+		columns = tb.PkColumns
+	} else if tb.Pk != "" && len(tb.Fk) == 2 {
+		for _, idx := range tb.Indexes {
+			if idx.Unique && len(idx.Columns) == 2 {
+				columns = idx.Columns
+			}
+		}
+	}
+	if len(columns) != 2 {
+		return nil, nil, false
+	}
+	fk1, ok1 := tb.Fk[columns[0]]
+	fk2, ok2 := tb.Fk[columns[1]]
+	if !ok1 || !ok2 {
+		return nil, nil, false
+	}
+	from, ok1 = tables[tb.refKey(fk1)]
+	to, ok2 = tables[tb.refKey(fk2)]
+	if !ok1 || !ok2 || from.Pk == "" || to.Pk == "" {
+		return nil, nil, false
+	}
+	return from, to, true
+}
+
+// addRelationField adds a field that does not map to a column, unless its name is taken
+func (tb *Table) addRelationField(name, typ string, tag *OrmTag) {
+	for _, col := range tb.Columns {
+		if col.Name == name {
+			beeLogger.Log.Warnf("Skipping the relation field '%s' of '%s', the name is already used", name, tb.Name)
+			return
+		}
+	}
+	tb.Columns = append(tb.Columns, &Column{Name: name, Type: typ, Tag: tag})
+}
+
+// pluralize returns the plural of an English noun, naively
+func pluralize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "z"),
+		strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsAny(s[len(s)-2:len(s)-1], "aeiou"):
+		return s[:len(s)-1] + "ies"
+	}
+	return s + "s"
+}
+
+// appendUnique appends s to list unless it is already there
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
 // GetConstraints gets primary key, unique key and foreign keys of a table from
 // information_schema and fill in the Table struct
 func (*MysqlDB) GetConstraints(db *sql.DB, table *Table, blackList map[string]bool) {
@@ -390,6 +680,7 @@ func (*MysqlDB) GetConstraints(db *sql.DB, table *Table, blackList map[string]bo
 			string(constraintTypeBytes), string(columnNameBytes), string(refTableSchemaBytes),
 			string(refTableNameBytes), string(refColumnNameBytes), string(refOrdinalPosBytes)
 		if constraintType == "PRIMARY KEY" {
+			table.PkColumns = append(table.PkColumns, columnName)
 			if refOrdinalPos == "1" {
 				table.Pk = columnName
 			} else {
@@ -440,6 +731,9 @@ func (mysqlDB *MysqlDB) GetColumns(db *sql.DB, table *Table, blackList map[strin
 		// create a column
 		col := new(Column)
 		col.Name = utils.CamelCase(colName)
+		col.SQLType = columnType
+		col.Nullable = isNullable == "YES"
+		col.HasDefault = columnDefaultBytes != nil
 		col.Type, err = mysqlDB.GetGoDataType(dataType)
 		if err != nil {
 			beeLogger.Log.Fatalf("%s", err)
@@ -465,7 +759,12 @@ func (mysqlDB *MysqlDB) GetColumns(db *sql.DB, table *Table, blackList map[strin
 			}
 			// check if the current column is a foreign key
 			if isFk && !isBl {
-				tag.RelFk = true
+				// a unique foreign key is a one-to-one relation
+				if table.isUnique(colName) {
+					tag.RelOne = true
+				} else {
+					tag.RelFk = true
+				}
 				refStructName := fkCol.RefTable
 				col.Name = utils.CamelCase(colName)
 				col.Type = "*" + utils.CamelCase(refStructName)
@@ -509,6 +808,11 @@ func (mysqlDB *MysqlDB) GetColumns(db *sql.DB, table *Table, blackList map[strin
 				if isSQLBitType(dataType) {
 					tag.Size = extractColSize(columnType)
 				}
+				if dataType == "enum" {
+					if values := mysqlEnumValues(columnType); len(values) > 0 {
+						col.setEnum(table.modelName()+col.Name, values, table.Name+"."+colName)
+					}
+				}
 			}
 		}
 		col.Tag = tag
@@ -516,6 +820,42 @@ func (mysqlDB *MysqlDB) GetColumns(db *sql.DB, table *Table, blackList map[strin
 	}
 }
 
+// GetIndexes retrieves the secondary indexes of a table from
+// information_schema and fill in the Table struct
+func (*MysqlDB) GetIndexes(db *sql.DB, table *Table) {
+	rows, err := db.Query(
+		`SELECT
+			index_name, column_name, non_unique
+		FROM
+			information_schema.statistics
+		WHERE
+			table_schema = database() AND table_name = ? AND index_name != 'PRIMARY'
+		ORDER BY
+			index_name, seq_in_index`,
+		table.Name)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not query INFORMATION_SCHEMA for index information: %s", err)
+	}
+	defer rows.Close()
+
+	expressions := make(map[string]bool)
+	for rows.Next() {
+		var indexName string
+		var columnName sql.NullString
+		var nonUnique int
+		if err := rows.Scan(&indexName, &columnName, &nonUnique); err != nil {
+			beeLogger.Log.Fatalf("Could not read INFORMATION_SCHEMA for index information: %s", err)
+		}
+		// the parts of functional indexes are expressions, without a column name
+		if !columnName.Valid {
+			expressions[indexName] = true
+			continue
+		}
+		table.addIndexColumn(indexName, columnName.String, nonUnique == 0)
+	}
+	table.Indexes = withoutIndexes(table.Indexes, expressions)
+}
+
 // GetGoDataType maps an SQL data type to Golang data type
 func (*MysqlDB) GetGoDataType(sqlType string) (string, error) {
 	if v, ok := typeMappingMysql[sqlType]; ok {
@@ -525,49 +865,83 @@ func (*MysqlDB) GetGoDataType(sqlType string) (string, error) {
 }
 
 // GetTableNames for PostgreSQL
+// The tables of the current schema are listed by default. With -schema, the tables
+// of the given schemas, or of all of them with *, are listed as schema.table.
 func (*PostgresDB) GetTableNames(db *sql.DB) (tables []string) {
-	rows, err := db.Query(`
-		SELECT table_name FROM information_schema.tables
+	query := `
+		SELECT table_schema, table_name FROM information_schema.tables
 		WHERE table_catalog = current_database() AND
 		table_type = 'BASE TABLE' AND
-		table_schema NOT IN ('pg_catalog', 'information_schema')`)
+		table_schema NOT IN ('pg_catalog', 'information_schema')`
+	schemas := postgresSchemas()
+	var args []interface{}
+	switch {
+	case len(schemas) == 0:
+		query += " AND table_schema = current_schema()"
+	case schemas[0] != "*":
+		placeholders := make([]string, len(schemas))
+		for i, schema := range schemas {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+			args = append(args, schema)
+		}
+		query += fmt.Sprintf(" AND table_schema IN (%s)", strings.Join(placeholders, ", "))
+	}
+	rows, err := db.Query(query+" ORDER BY table_schema, table_name", args...)
 	if err != nil {
 		beeLogger.Log.Fatalf("Could not show tables: %s", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
+		var schema, name string
+		if err := rows.Scan(&schema, &name); err != nil {
 			beeLogger.Log.Fatalf("Could not show tables: %s", err)
 		}
+		if len(schemas) > 0 {
+			name = tableKey(schema, name)
+		}
 		tables = append(tables, name)
 	}
 	return
 }
 
+// postgresSchemas returns the schemas given with -schema, or * for all of them
+func postgresSchemas() []string {
+	var schemas []string
+	for _, schema := range strings.Split(Schemas.String(), ",") {
+		schema = strings.TrimSpace(schema)
+		if schema == "*" {
+			return []string{"*"}
+		}
+		if schema != "" {
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas
+}
+
 // GetConstraints for PostgreSQL
 func (*PostgresDB) GetConstraints(db *sql.DB, table *Table, blackList map[string]bool) {
 	rows, err := db.Query(
 		`SELECT
 			c.constraint_type,
 			u.column_name,
-			cu.table_catalog AS referenced_table_catalog,
+			cu.table_schema AS referenced_table_schema,
 			cu.table_name AS referenced_table_name,
 			cu.column_name AS referenced_column_name,
 			u.ordinal_position
 		FROM
 			information_schema.table_constraints c
 		INNER JOIN
-			information_schema.key_column_usage u ON c.constraint_name = u.constraint_name
+			information_schema.key_column_usage u ON c.constraint_schema = u.constraint_schema AND c.constraint_name = u.constraint_name
 		INNER JOIN
-			information_schema.constraint_column_usage cu ON cu.constraint_name =  c.constraint_name
+			information_schema.constraint_column_usage cu ON cu.constraint_schema = c.constraint_schema AND cu.constraint_name = c.constraint_name
 		WHERE
-			c.table_catalog = current_database() AND c.table_schema NOT IN ('pg_catalog', 'information_schema')
+			c.table_catalog = current_database() AND c.table_schema = COALESCE(NULLIF($2, ''), current_schema())
 			 AND c.table_name = $1
-			AND u.table_catalog = current_database() AND u.table_schema NOT IN ('pg_catalog', 'information_schema')
-			 AND u.table_name = $2`,
-		table.Name, table.Name) //  u.position_in_unique_constraint,
+			AND u.table_catalog = current_database() AND u.table_schema = c.table_schema
+			 AND u.table_name = $1`,
+		table.Name, table.Schema) //  u.position_in_unique_constraint,
 	if err != nil {
 		beeLogger.Log.Fatalf("Could not query INFORMATION_SCHEMA for PK/UK/FK information: %s", err)
 	}
@@ -581,13 +955,15 @@ func (*PostgresDB) GetConstraints(db *sql.DB, table *Table, blackList map[string
 			string(constraintTypeBytes), string(columnNameBytes), string(refTableSchemaBytes),
 			string(refTableNameBytes), string(refColumnNameBytes), string(refOrdinalPosBytes)
 		if constraintType == "PRIMARY KEY" {
+			// the join on constraint_column_usage repeats the columns of composite keys
+			table.PkColumns = appendUnique(table.PkColumns, columnName)
 			if refOrdinalPos == "1" {
 				table.Pk = columnName
 			} else {
 				table.Pk = ""
 				// add table to blacklist so that other struct will not reference it, because we are not
 				// registering blacklisted tables
-				blackList[table.Name] = true
+				blackList[tableKey(table.Schema, table.Name)] = true
 			}
 		} else if constraintType == "UNIQUE" {
 			table.Uk = append(table.Uk, columnName)
@@ -612,27 +988,32 @@ func (postgresDB *PostgresDB) GetColumns(db *sql.DB, table *Table, blackList map
 			data_type ||
 			CASE
 				WHEN data_type = 'character' THEN '('||character_maximum_length||')'
+				WHEN data_type = 'character varying' AND character_maximum_length IS NOT NULL THEN '('||character_maximum_length||')'
 				WHEN data_type = 'numeric' THEN '(' || numeric_precision || ',' || numeric_scale ||')'
 				ELSE ''
 			END AS column_type,
 			is_nullable,
 			column_default,
-			'' AS extra
+			'' AS extra,
+			udt_schema,
+			udt_name
 		FROM
 			information_schema.columns
 		WHERE
-			table_catalog = current_database() AND table_schema NOT IN ('pg_catalog', 'information_schema')
+			table_catalog = current_database() AND table_schema = COALESCE(NULLIF($2, ''), current_schema())
 			 AND table_name = $1`,
-		table.Name)
+		table.Name, table.Schema)
 	if err != nil {
 		beeLogger.Log.Fatalf("Could not query INFORMATION_SCHEMA for column information: %s", err)
 	}
 	defer colDefRows.Close()
 
+	checks := postgresCheckEnums(db, table)
 	for colDefRows.Next() {
 		// datatype as bytes so that SQL <null> values can be retrieved
 		var colNameBytes, dataTypeBytes, columnTypeBytes, isNullableBytes, columnDefaultBytes, extraBytes []byte
-		if err := colDefRows.Scan(&colNameBytes, &dataTypeBytes, &columnTypeBytes, &isNullableBytes, &columnDefaultBytes, &extraBytes); err != nil {
+		var udtSchema, udtName string
+		if err := colDefRows.Scan(&colNameBytes, &dataTypeBytes, &columnTypeBytes, &isNullableBytes, &columnDefaultBytes, &extraBytes, &udtSchema, &udtName); err != nil {
 			beeLogger.Log.Fatalf("Could not query INFORMATION_SCHEMA for column information: %s", err)
 		}
 		colName, dataType, columnType, isNullable, columnDefault, extra :=
@@ -640,6 +1021,9 @@ func (postgresDB *PostgresDB) GetColumns(db *sql.DB, table *Table, blackList map
 		// Create a column
 		col := new(Column)
 		col.Name = utils.CamelCase(colName)
+		col.SQLType = columnType
+		col.Nullable = isNullable == "YES"
+		col.HasDefault = columnDefaultBytes != nil
 		col.Type, err = postgresDB.GetGoDataType(dataType)
 		if err != nil {
 			beeLogger.Log.Fatalf("%s", err)
@@ -660,11 +1044,16 @@ func (postgresDB *PostgresDB) GetColumns(db *sql.DB, table *Table, blackList map
 			fkCol, isFk := table.Fk[colName]
 			isBl := false
 			if isFk {
-				_, isBl = blackList[fkCol.RefTable]
+				_, isBl = blackList[table.refKey(fkCol)]
 			}
 			// check if the current column is a foreign key
 			if isFk && !isBl {
-				tag.RelFk = true
+				// a unique foreign key is a one-to-one relation
+				if table.isUnique(colName) {
+					tag.RelOne = true
+				} else {
+					tag.RelFk = true
+				}
 				refStructName := fkCol.RefTable
 				col.Name = utils.CamelCase(colName)
 				col.Type = "*" + utils.CamelCase(refStructName)
@@ -699,6 +1088,14 @@ func (postgresDB *PostgresDB) GetColumns(db *sql.DB, table *Table, blackList map
 				if isSQLStrangeType(dataType) {
 					tag.Type = dataType
 				}
+				if dataType == "USER-DEFINED" {
+					if values := postgresEnumLabels(db, udtSchema, udtName); len(values) > 0 {
+						col.setEnum(utils.CamelCase(udtName), values, "the "+udtSchema+"."+udtName+" type")
+						col.Enum.Schema = udtSchema
+					}
+				} else if values, ok := checks[colName]; ok && col.Type == "string" {
+					col.setEnum(table.modelName()+col.Name, values, table.Name+"."+colName)
+				}
 			}
 		}
 		col.Tag = tag
@@ -706,6 +1103,45 @@ func (postgresDB *PostgresDB) GetColumns(db *sql.DB, table *Table, blackList map
 	}
 }
 
+// GetIndexes for PostgreSQL, leaving out the indexes over expressions, whose
+// key holds 0 for them
+func (*PostgresDB) GetIndexes(db *sql.DB, table *Table) {
+	rows, err := db.Query(
+		`SELECT
+			i.relname AS index_name,
+			a.attname AS column_name,
+			ix.indisunique
+		FROM
+			pg_catalog.pg_index ix
+		INNER JOIN
+			pg_catalog.pg_class t ON t.oid = ix.indrelid
+		INNER JOIN
+			pg_catalog.pg_class i ON i.oid = ix.indexrelid
+		INNER JOIN
+			pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+		INNER JOIN
+			pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
+		WHERE
+			t.relname = $1 AND NOT ix.indisprimary AND NOT (0 = ANY(ix.indkey::int2[]))
+			AND n.nspname = COALESCE(NULLIF($2, ''), current_schema())
+		ORDER BY
+			i.relname, array_position(ix.indkey::int2[], a.attnum)`,
+		table.Name, table.Schema)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not query the catalog for index information: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var indexName, columnName string
+		var unique bool
+		if err := rows.Scan(&indexName, &columnName, &unique); err != nil {
+			beeLogger.Log.Fatalf("Could not read the catalog for index information: %s", err)
+		}
+		table.addIndexColumn(indexName, columnName, unique)
+	}
+}
+
 // GetGoDataType returns the Go type from the mapped Postgres type
 func (*PostgresDB) GetGoDataType(sqlType string) (string, error) {
 	if v, ok := typeMappingPostgres[sqlType]; ok {
@@ -714,16 +1150,266 @@ func (*PostgresDB) GetGoDataType(sqlType string) (string, error) {
 	return "", fmt.Errorf("data type '%s' not found", sqlType)
 }
 
+// GetTableNames for SQLite
+func (*SQLiteDB) GetTableNames(db *sql.DB) (tables []string) {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not show tables: %s", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			beeLogger.Log.Fatalf("Could not show tables: %s", err)
+		}
+		tables = append(tables, name)
+	}
+	return
+}
+
+// sqliteColumn is a row of PRAGMA table_info
+type sqliteColumn struct {
+	Name     string
+	Type     string
+	NotNull  bool
+	Default  sql.NullString
+	PkNumber int
+}
+
+// sqliteTableInfo returns the columns of a table, in their declaration order
+func sqliteTableInfo(db *sql.DB, table string) (columns []*sqliteColumn) {
+	rows, err := db.Query("PRAGMA table_info(" + sqliteQuote(table) + ")")
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not query table information: %s", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid int
+		col := new(sqliteColumn)
+		if err := rows.Scan(&cid, &col.Name, &col.Type, &col.NotNull, &col.Default, &col.PkNumber); err != nil {
+			beeLogger.Log.Fatalf("Could not read table information: %s", err)
+		}
+		columns = append(columns, col)
+	}
+	return
+}
+
+// GetConstraints for SQLite
+func (*SQLiteDB) GetConstraints(db *sql.DB, table *Table, blackList map[string]bool) {
+	var pks []*sqliteColumn
+	for _, col := range sqliteTableInfo(db, table.Name) {
+		if col.PkNumber > 0 {
+			pks = append(pks, col)
+		}
+	}
+	// PkNumber is the position of the column in the primary key
+	sort.Slice(pks, func(i, j int) bool { return pks[i].PkNumber < pks[j].PkNumber })
+	for _, col := range pks {
+		table.PkColumns = append(table.PkColumns, col.Name)
+	}
+	if len(pks) == 1 {
+		table.Pk = pks[0].Name
+	} else if len(pks) > 1 {
+		// add table to blacklist so that other struct will not reference it, because we are not
+		// registering blacklisted tables
+		blackList[table.Name] = true
+	}
+
+	rows, err := db.Query("PRAGMA foreign_key_list(" + sqliteQuote(table.Name) + ")")
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not query foreign key information: %s", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, seq int
+		var refTable, from string
+		var to, onUpdate, onDelete, match sql.NullString
+		if err := rows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			beeLogger.Log.Fatalf("Could not read foreign key information: %s", err)
+		}
+		fk := new(ForeignKey)
+		fk.Name = from
+		fk.RefTable = refTable
+		fk.RefColumn = to.String
+		if !to.Valid {
+			// the foreign key references the primary key of the table
+			fk.RefColumn = "id"
+		}
+		table.Fk[from] = fk
+	}
+
+	for _, idx := range sqliteIndexes(db, table.Name) {
+		if idx.Unique && len(idx.Columns) == 1 {
+			table.Uk = append(table.Uk, idx.Columns[0])
+		}
+	}
+}
+
+// GetColumns for SQLite
+func (sqliteDB *SQLiteDB) GetColumns(db *sql.DB, table *Table, blackList map[string]bool) {
+	checks := sqliteCheckEnums(db, table.Name)
+	for _, c := range sqliteTableInfo(db, table.Name) {
+		colName, columnType := c.Name, strings.ToLower(c.Type)
+		dataType := columnType
+		if i := strings.Index(dataType, "("); i != -1 {
+			dataType = strings.TrimSpace(dataType[:i])
+		}
+		// Create a column
+		col := new(Column)
+		col.Name = utils.CamelCase(colName)
+		col.SQLType = columnType
+		col.Nullable = !c.NotNull && c.PkNumber == 0
+		col.HasDefault = c.Default.Valid
+		col.Type, _ = sqliteDB.GetGoDataType(dataType)
+
+		// Tag info
+		tag := new(OrmTag)
+		tag.Column = colName
+		if table.Pk == colName {
+			col.Name = "Id"
+			col.Type = "int"
+			// an INTEGER PRIMARY KEY is an alias of the rowid
+			if dataType == "integer" {
+				tag.Auto = true
+			} else {
+				tag.Pk = true
+			}
+		} else {
+			fkCol, isFk := table.Fk[colName]
+			isBl := false
+			if isFk {
+				_, isBl = blackList[fkCol.RefTable]
+			}
+			// check if the current column is a foreign key
+			if isFk && !isBl {
+				// a unique foreign key is a one-to-one relation
+				if table.isUnique(colName) {
+					tag.RelOne = true
+				} else {
+					tag.RelFk = true
+				}
+				refStructName := fkCol.RefTable
+				col.Name = utils.CamelCase(colName)
+				col.Type = "*" + utils.CamelCase(refStructName)
+			} else {
+				// if the name of column is Id, and it's not primary key
+				if colName == "id" {
+					col.Name = "Id_RENAME"
+				}
+				if col.Nullable {
+					tag.Null = true
+				}
+				if isSQLStringType(dataType) && columnType != dataType {
+					tag.Size = extractColSize(strings.Replace(columnType, " ", "", -1))
+				}
+				if isSQLTemporalType(dataType) {
+					tag.Type = dataType
+					if strings.EqualFold(c.Default.String, "CURRENT_TIMESTAMP") {
+						tag.AutoNowAdd = true
+					}
+					// need to import time package
+					table.ImportTimePkg = true
+				}
+				if isSQLDecimal(dataType) && columnType != dataType {
+					tag.Digits, tag.Decimals = extractDecimal(strings.Replace(columnType, " ", "", -1))
+				}
+				if values, ok := checks[colName]; ok && col.Type == "string" {
+					col.setEnum(table.modelName()+col.Name, values, table.Name+"."+colName)
+				}
+			}
+		}
+		col.Tag = tag
+		table.Columns = append(table.Columns, col)
+	}
+}
+
+// GetIndexes for SQLite
+func (*SQLiteDB) GetIndexes(db *sql.DB, table *Table) {
+	for _, idx := range sqliteIndexes(db, table.Name) {
+		for _, column := range idx.Columns {
+			table.addIndexColumn(idx.Name, column, idx.Unique)
+		}
+	}
+}
+
+// sqliteIndexes returns the indexes of a table, except the one of its primary key
+// and the ones over expressions
+func sqliteIndexes(db *sql.DB, table string) (indexes []*Index) {
+	rows, err := db.Query("PRAGMA index_list(" + sqliteQuote(table) + ")")
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not query index information: %s", err)
+	}
+	for rows.Next() {
+		var seq int
+		var name, origin string
+		var unique, partial bool
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			beeLogger.Log.Fatalf("Could not read index information: %s", err)
+		}
+		if origin != "pk" {
+			indexes = append(indexes, &Index{Name: name, Unique: unique})
+		}
+	}
+	rows.Close()
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+
+	expressions := make(map[string]bool)
+	for _, idx := range indexes {
+		rows, err := db.Query("PRAGMA index_info(" + sqliteQuote(idx.Name) + ")")
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not query index information: %s", err)
+		}
+		for rows.Next() {
+			var seqno, cid int
+			var column sql.NullString
+			if err := rows.Scan(&seqno, &cid, &column); err != nil {
+				beeLogger.Log.Fatalf("Could not read index information: %s", err)
+			}
+			// expressions have no column name
+			if !column.Valid {
+				expressions[idx.Name] = true
+			}
+			idx.Columns = append(idx.Columns, column.String)
+		}
+		rows.Close()
+	}
+	indexes = withoutIndexes(indexes, expressions)
+	return
+}
+
+// GetGoDataType returns the Go type from the declared SQLite type, falling back
+// to the type affinity rules of SQLite for declared types that are not mapped
+func (*SQLiteDB) GetGoDataType(sqlType string) (string, error) {
+	if v, ok := typeMappingSQLite[sqlType]; ok {
+		return v, nil
+	}
+	switch {
+	case strings.Contains(sqlType, "int"):
+		return "int64", nil
+	case strings.Contains(sqlType, "char"), strings.Contains(sqlType, "clob"), strings.Contains(sqlType, "text"):
+		return "string", nil
+	case sqlType == "", strings.Contains(sqlType, "blob"):
+		return "[]byte", nil
+	}
+	// REAL and NUMERIC affinities
+	return "float64", nil
+}
+
+// sqliteQuote quotes an identifier for use in a PRAGMA statement
+func sqliteQuote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
 // deleteAndRecreatePaths removes several directories completely
 func createPaths(mode byte, paths *MvcPath) {
 	if (mode & OModel) == OModel {
-		os.Mkdir(paths.ModelPath, 0777)
+		utils.CreateGeneratedDir(paths.ModelPath)
 	}
 	if (mode & OController) == OController {
-		os.Mkdir(paths.ControllerPath, 0777)
+		utils.CreateGeneratedDir(paths.ControllerPath)
 	}
 	if (mode & ORouter) == ORouter {
-		os.Mkdir(paths.RouterPath, 0777)
+		utils.CreateGeneratedDir(paths.RouterPath)
 	}
 }
 
@@ -734,6 +1420,7 @@ func writeSourceFiles(pkgPath string, tables []*Table, mode byte, paths *MvcPath
 	if (OModel & mode) == OModel {
 		beeLogger.Log.Info("Creating model files...")
 		writeModelFiles(tables, paths.ModelPath)
+		writeEnumFiles(tables, paths.ModelPath)
 	}
 	if (OController & mode) == OController {
 		beeLogger.Log.Info("Creating controller files...")
@@ -747,41 +1434,22 @@ func writeSourceFiles(pkgPath string, tables []*Table, mode byte, paths *MvcPath
 
 // writeModelFiles generates model files
 func writeModelFiles(tables []*Table, mPath string) {
-	w := colors.NewColorWriter(os.Stdout)
-
 	for _, tb := range tables {
-		filename := getFileName(tb.Name)
-		fpath := path.Join(mPath, filename+".go")
-		var f *os.File
-		var err error
-		if utils.IsExist(fpath) {
-			beeLogger.Log.Warnf("'%s' already exists. Do you want to overwrite it? [Yes|No] ", fpath)
-			if utils.AskForConfirmation() {
-				f, err = os.OpenFile(fpath, os.O_RDWR|os.O_TRUNC, 0666)
-				if err != nil {
-					beeLogger.Log.Warnf("%s", err)
-					continue
-				}
-			} else {
-				beeLogger.Log.Warnf("Skipped create file '%s'", fpath)
-				continue
-			}
-		} else {
-			f, err = os.OpenFile(fpath, os.O_CREATE|os.O_RDWR, 0666)
-			if err != nil {
-				beeLogger.Log.Warnf("%s", err)
-				continue
-			}
+		if tb.RelTableOnly {
+			beeLogger.Log.Infof("Skipping the model of '%s', the join table of a many-to-many relation", tb.Name)
+			continue
 		}
+		filename := getFileName(tb.alias())
+		fpath := path.Join(mPath, filename+".go")
 		var template string
 		if tb.Pk == "" {
-			template = StructModelTPL
+			template = loadTemplate("appcode/struct_model.go.tpl")
 		} else {
-			template = ModelTPL
+			template = loadTemplate("appcode/model.go.tpl")
 		}
 		fileStr := strings.Replace(template, "{{modelStruct}}", tb.String(), 1)
-		fileStr = strings.Replace(fileStr, "{{modelName}}", utils.CamelCase(tb.Name), -1)
-		fileStr = strings.Replace(fileStr, "{{tableName}}", tb.Name, -1)
+		fileStr = strings.Replace(fileStr, "{{modelName}}", tb.modelName(), -1)
+		fileStr = strings.Replace(fileStr, "{{tableName}}", tb.ormTableName(), -1)
 
 		// If table contains time field, import time.Time package
 		timePkg := ""
@@ -792,102 +1460,53 @@ func writeModelFiles(tables []*Table, mPath string) {
 		}
 		fileStr = strings.Replace(fileStr, "{{timePkg}}", timePkg, -1)
 		fileStr = strings.Replace(fileStr, "{{importTimePkg}}", importTimePkg, -1)
-		if _, err := f.WriteString(fileStr); err != nil {
-			beeLogger.Log.Fatalf("Could not write model file to '%s': %s", fpath, err)
+		utils.WriteGeneratedFile(fpath, fileStr, utils.MergeExisting)
+		if Repository && tb.Pk != "" {
+			writeRepository(mPath, "models", filename, tb.modelName(), "int")
 		}
-		utils.CloseFile(f)
-		fmt.Fprintf(w, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", fpath, "\x1b[0m")
-		utils.FormatSourceCode(fpath)
 	}
 }
 
 // writeControllerFiles generates controller files
 func writeControllerFiles(tables []*Table, cPath string, pkgPath string) {
-	w := colors.NewColorWriter(os.Stdout)
-
 	for _, tb := range tables {
 		if tb.Pk == "" {
 			continue
 		}
-		filename := getFileName(tb.Name)
+		filename := getFileName(tb.alias())
 		fpath := path.Join(cPath, filename+".go")
-		var f *os.File
-		var err error
-		if utils.IsExist(fpath) {
-			beeLogger.Log.Warnf("'%s' already exists. Do you want to overwrite it? [Yes|No] ", fpath)
-			if utils.AskForConfirmation() {
-				f, err = os.OpenFile(fpath, os.O_RDWR|os.O_TRUNC, 0666)
-				if err != nil {
-					beeLogger.Log.Warnf("%s", err)
-					continue
-				}
-			} else {
-				beeLogger.Log.Warnf("Skipped create file '%s'", fpath)
-				continue
-			}
+		var fileStr string
+		if Repository {
+			fileStr = renderRepositoryController("controllers", tb.modelName(), pkgPath, "int", tb.renderEnumChecks(), tb.uniqueFields())
 		} else {
-			f, err = os.OpenFile(fpath, os.O_CREATE|os.O_RDWR, 0666)
-			if err != nil {
-				beeLogger.Log.Warnf("%s", err)
-				continue
-			}
-		}
-		fileStr := strings.Replace(CtrlTPL, "{{ctrlName}}", utils.CamelCase(tb.Name), -1)
-		fileStr = strings.Replace(fileStr, "{{pkgPath}}", pkgPath, -1)
-		if _, err := f.WriteString(fileStr); err != nil {
-			beeLogger.Log.Fatalf("Could not write controller file to '%s': %s", fpath, err)
+			fileStr = strings.Replace(loadTemplate("appcode/controller.go.tpl"), "{{ctrlName}}", tb.modelName(), -1)
+			fileStr = strings.Replace(fileStr, "{{pkgPath}}", pkgPath, -1)
+			fileStr = strings.Replace(fileStr, "{{enumChecks}}", tb.renderEnumChecks(), -1)
+			fileStr = strings.Replace(fileStr, "{{uniqueChecks}}",
+				renderUniqueChecks(tb.modelName(), "models.GetAll"+tb.modelName(), tb.uniqueFields()), -1)
 		}
-		utils.CloseFile(f)
-		fmt.Fprintf(w, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", fpath, "\x1b[0m")
-		utils.FormatSourceCode(fpath)
+		utils.WriteGeneratedFile(fpath, fileStr, utils.MergeExisting)
 	}
+	writeValidationHelpers(cPath, "controllers")
 }
 
 // writeRouterFile generates router file
 func writeRouterFile(tables []*Table, rPath string, pkgPath string) {
-	w := colors.NewColorWriter(os.Stdout)
-
 	var nameSpaces []string
 	for _, tb := range tables {
 		if tb.Pk == "" {
 			continue
 		}
 		// Add namespaces
-		nameSpace := strings.Replace(NamespaceTPL, "{{nameSpace}}", tb.Name, -1)
-		nameSpace = strings.Replace(nameSpace, "{{ctrlName}}", utils.CamelCase(tb.Name), -1)
+		nameSpace := strings.Replace(loadTemplate("appcode/namespace.go.tpl"), "{{nameSpace}}", tb.alias(), -1)
+		nameSpace = strings.Replace(nameSpace, "{{ctrlName}}", tb.modelName(), -1)
 		nameSpaces = append(nameSpaces, nameSpace)
 	}
 	// Add export controller
 	fpath := filepath.Join(rPath, "router.go")
-	routerStr := strings.Replace(RouterTPL, "{{nameSpaces}}", strings.Join(nameSpaces, ""), 1)
+	routerStr := strings.Replace(loadTemplate("appcode/router.go.tpl"), "{{nameSpaces}}", strings.Join(nameSpaces, ""), 1)
 	routerStr = strings.Replace(routerStr, "{{pkgPath}}", pkgPath, 1)
-	var f *os.File
-	var err error
-	if utils.IsExist(fpath) {
-		beeLogger.Log.Warnf("'%s' already exists. Do you want to overwrite it? [Yes|No] ", fpath)
-		if utils.AskForConfirmation() {
-			f, err = os.OpenFile(fpath, os.O_RDWR|os.O_TRUNC, 0666)
-			if err != nil {
-				beeLogger.Log.Warnf("%s", err)
-				return
-			}
-		} else {
-			beeLogger.Log.Warnf("Skipped create file '%s'", fpath)
-			return
-		}
-	} else {
-		f, err = os.OpenFile(fpath, os.O_CREATE|os.O_RDWR, 0666)
-		if err != nil {
-			beeLogger.Log.Warnf("%s", err)
-			return
-		}
-	}
-	if _, err := f.WriteString(routerStr); err != nil {
-		beeLogger.Log.Fatalf("Could not write router file to '%s': %s", fpath, err)
-	}
-	utils.CloseFile(f)
-	fmt.Fprintf(w, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", fpath, "\x1b[0m")
-	utils.FormatSourceCode(fpath)
+	utils.WriteGeneratedFile(fpath, routerStr, utils.MergeExisting)
 }
 
 func isSQLTemporalType(t string) bool {
@@ -949,6 +1568,10 @@ func getFileName(tbName string) (filename string) {
 
 func getPackagePath(curpath string) (packpath string) {
 	gopath := os.Getenv("GOPATH")
+	if _, err := os.Stat(filepath.Join(curpath, `go.mod`)); err == nil {
+		// module mode takes precedence over GOPATH
+		gopath = ""
+	}
 	if gopath == "" {
 		info := "GOPATH environment variable is not set or empty"
 		gomodpath := filepath.Join(curpath, `go.mod`)
@@ -1024,6 +1647,9 @@ import (
 	"strings"
 	{{timePkg}}
 	"github.com/beego/beego/v2/client/orm"
+
+	// bee:begin imports
+	// bee:end
 )
 
 {{modelStruct}}
@@ -1162,6 +1788,9 @@ func Delete{{modelName}}(id int) (err error) {
 	}
 	return
 }
+
+// bee:begin custom
+// bee:end
 `
 	CtrlTPL = `package controllers
 
@@ -1172,7 +1801,11 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/beego/beego/v2/core/validation"
 	beego "github.com/beego/beego/v2/server/web"
+
+	// bee:begin imports
+	// bee:end
 )
 
 // {{ctrlName}}Controller operations for {{ctrlName}}
@@ -1187,6 +1820,9 @@ func (c *{{ctrlName}}Controller) URLMapping() {
 	c.Mapping("GetAll", c.GetAll)
 	c.Mapping("Put", c.Put)
 	c.Mapping("Delete", c.Delete)
+
+	// bee:begin mappings
+	// bee:end
 }
 
 // Post ...
@@ -1194,12 +1830,16 @@ func (c *{{ctrlName}}Controller) URLMapping() {
 // @Description create {{ctrlName}}
 // @Param	body		body 	models.{{ctrlName}}	true		"body for {{ctrlName}} content"
 // @Success 201 {int} models.{{ctrlName}}
+// @Failure 400 the fields breaking validation rules
 // @Failure 403 body is empty
 // @router / [post]
 func (c *{{ctrlName}}Controller) Post() {
 	var v models.{{ctrlName}}
 	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &v); err == nil {
-		if _, err := models.Add{{ctrlName}}(&v); err == nil {
+		if errs := c.validate(&v); errs != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = errs
+		} else if _, err := models.Add{{ctrlName}}(&v); err == nil {
 			c.Ctx.Output.SetStatus(201)
 			c.Data["json"] = v
 		} else {
@@ -1299,6 +1939,7 @@ func (c *{{ctrlName}}Controller) GetAll() {
 // @Param	id		path 	string	true		"The id you want to update"
 // @Param	body		body 	models.{{ctrlName}}	true		"body for {{ctrlName}} content"
 // @Success 200 {object} models.{{ctrlName}}
+// @Failure 400 the fields breaking validation rules
 // @Failure 403 :id is not int
 // @router /:id [put]
 func (c *{{ctrlName}}Controller) Put() {
@@ -1306,7 +1947,10 @@ func (c *{{ctrlName}}Controller) Put() {
 	id, _ := strconv.Atoi(idStr)
 	v := models.{{ctrlName}}{Id: id}
 	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &v); err == nil {
-		if err := models.Update{{ctrlName}}ById(&v); err == nil {
+		if errs := c.validate(&v); errs != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = errs
+		} else if err := models.Update{{ctrlName}}ById(&v); err == nil {
 			c.Data["json"] = "OK"
 		} else {
 			c.Data["json"] = err.Error()
@@ -1334,6 +1978,20 @@ func (c *{{ctrlName}}Controller) Delete() {
 	}
 	c.ServeJSON()
 }
+
+// validate checks v against the valid tags of its fields, its enum fields against
+// their values, and its unique fields against the other {{ctrlName}} models.
+// It returns the errors found, if any.
+func (c *{{ctrlName}}Controller) validate(v *models.{{ctrlName}}) *ValidationErrors {
+	valid := validation.Validation{}
+	if _, err := valid.Valid(v); err != nil {
+		valid.SetError("", err.Error())
+	}
+{{enumChecks}}{{uniqueChecks}}	return newValidationErrors(valid.Errors)
+}
+
+// bee:begin custom
+// bee:end
 `
 	RouterTPL = `// @APIVersion 1.0.0
 // @Title beego Test API
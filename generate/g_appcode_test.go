@@ -0,0 +1,453 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"bytes"
+	"database/sql"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/beego/bee/v2/internal/pkg/sqlite"
+	beeLogger "github.com/beego/bee/v2/logger"
+)
+
+// schemaTransformer describes tables named schema.table, whose foreign keys
+// reference the orders table of the same schema
+type schemaTransformer struct {
+	PostgresDB
+}
+
+func (*schemaTransformer) GetConstraints(db *sql.DB, table *Table, blackList map[string]bool) {
+	table.Pk = "id"
+	table.PkColumns = []string{"id"}
+	if table.Name == "items" {
+		table.Fk["order_id"] = &ForeignKey{Name: "order_id", RefSchema: table.Schema, RefTable: "orders", RefColumn: "id"}
+	}
+}
+
+func (*schemaTransformer) GetColumns(db *sql.DB, table *Table, blackList map[string]bool) {
+	table.Columns = append(table.Columns, &Column{Name: "Id", Type: "int", Tag: &OrmTag{Column: "id", Auto: true}})
+	if fk, ok := table.Fk["order_id"]; ok {
+		table.Columns = append(table.Columns, &Column{Name: "OrderId", Type: "*" + fk.RefTable, Tag: &OrmTag{Column: "order_id", RelFk: true}})
+	}
+}
+
+func (*schemaTransformer) GetIndexes(db *sql.DB, table *Table) {}
+
+func TestTableObjectsAcrossSchemas(t *testing.T) {
+	tables := getTableObjects([]string{"public.orders", "sales.orders", "sales.items"}, nil, &schemaTransformer{})
+	models := make(map[string]*Table)
+	for _, tb := range tables {
+		models[tb.modelName()] = tb
+	}
+	for _, name := range []string{"PublicOrders", "SalesOrders", "Items"} {
+		if _, ok := models[name]; !ok {
+			t.Fatalf("expected a model %s, got %v", name, models)
+		}
+	}
+
+	if got := models["Items"].Columns[1].Type; got != "*SalesOrders" {
+		t.Errorf("expected the foreign key to reference *SalesOrders, got %s", got)
+	}
+	if got := models["SalesOrders"].ormTableName(); got != `sales\".\"orders` {
+		t.Errorf("unexpected table name %s", got)
+	}
+	if got := models["Items"].String(); !strings.HasPrefix(got, "type Items struct {") {
+		t.Errorf("unexpected struct:\n%s", got)
+	}
+}
+
+// openSQLite opens an in-memory SQLite database holding the statements
+func openSQLite(t *testing.T, statements ...string) *sql.DB {
+	if !sqlite.Supported {
+		t.Skip("SQLite needs cgo")
+	}
+	db, err := sql.Open(sqlite.DriverName, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection opens its own in-memory database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %s", stmt, err)
+		}
+	}
+	return db
+}
+
+func TestSQLiteDB(t *testing.T) {
+	db := openSQLite(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email varchar(64) NOT NULL UNIQUE, name text)",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id integer NOT NULL REFERENCES users(id), title varchar(128) NOT NULL, created_at datetime DEFAULT CURRENT_TIMESTAMP)",
+		"CREATE INDEX posts_title ON posts (title)",
+		"CREATE INDEX posts_lower_title ON posts (user_id, lower(title))",
+		"CREATE TABLE tags (post_id integer REFERENCES posts, name varchar(32), PRIMARY KEY (name, post_id))",
+	)
+	trans := &SQLiteDB{}
+
+	if got := strings.Join(trans.GetTableNames(db), ","); got != "posts,tags,users" {
+		t.Fatalf("unexpected tables %s", got)
+	}
+
+	blackList := make(map[string]bool)
+	tables := make(map[string]*Table)
+	for _, name := range []string{"users", "posts", "tags"} {
+		tb := &Table{Name: name, Fk: make(map[string]*ForeignKey)}
+		trans.GetConstraints(db, tb, blackList)
+		trans.GetIndexes(db, tb)
+		tables[name] = tb
+	}
+
+	users, posts, tags := tables["users"], tables["posts"], tables["tags"]
+	if users.Pk != "id" || strings.Join(users.Uk, ",") != "email" {
+		t.Errorf("unexpected users constraints: pk %q, uk %q", users.Pk, users.Uk)
+	}
+	if fk := posts.Fk["user_id"]; fk == nil || fk.RefTable != "users" || fk.RefColumn != "id" {
+		t.Errorf("unexpected posts foreign key %+v", fk)
+	}
+	if len(posts.Indexes) != 1 || posts.Indexes[0].Name != "posts_title" || posts.Indexes[0].Unique {
+		t.Errorf("unexpected posts indexes %+v", posts.Indexes)
+	}
+	if tags.Pk != "" || strings.Join(tags.PkColumns, ",") != "name,post_id" || !blackList["tags"] {
+		t.Errorf("unexpected tags primary key %q %q, blacklisted %v", tags.Pk, tags.PkColumns, blackList["tags"])
+	}
+	if fk := tags.Fk["post_id"]; fk == nil || fk.RefTable != "posts" || fk.RefColumn != "id" {
+		t.Errorf("unexpected tags foreign key %+v", fk)
+	}
+
+	for _, tb := range tables {
+		trans.GetColumns(db, tb, blackList)
+	}
+	columns := func(tb *Table) string {
+		var s []string
+		for _, col := range tb.Columns {
+			s = append(s, col.Name+" "+col.Type+" "+col.Tag.String())
+		}
+		return strings.Join(s, "\n")
+	}
+	tests := []struct {
+		table    *Table
+		expected string
+	}{
+		{users, "Id int `orm:\"column(id);auto\"`\nEmail string `orm:\"column(email);size(64)\"`\nName string `orm:\"column(name);null\"`"},
+		{posts, "Id int `orm:\"column(id);auto\"`\nUserId *Users `orm:\"column(user_id);rel(fk)\"`\nTitle string `orm:\"column(title);size(128)\"`\nCreatedAt time.Time `orm:\"column(created_at);type(datetime);null;auto_now_add\"`"},
+	}
+	for _, tt := range tests {
+		if got := columns(tt.table); got != tt.expected {
+			t.Errorf("unexpected columns of %s:\n%s\nexpected:\n%s", tt.table.Name, got, tt.expected)
+		}
+	}
+}
+
+// openPostgres opens the PostgreSQL database of BEE_TEST_POSTGRES, and
+// runs the statements on it
+func openPostgres(t *testing.T, statements ...string) *sql.DB {
+	conn := os.Getenv("BEE_TEST_POSTGRES")
+	if conn == "" {
+		t.Skip("BEE_TEST_POSTGRES is not set, e.g. to postgres://postgres@localhost/bee_test?sslmode=disable")
+	}
+	db, err := sql.Open("postgres", conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %s", stmt, err)
+		}
+	}
+	return db
+}
+
+func TestPostgresDBAcrossSchemas(t *testing.T) {
+	// the constraints of same-named tables are named the same in both schemas,
+	// and tags has a composite primary key in one schema only
+	db := openPostgres(t,
+		"DROP SCHEMA IF EXISTS bee_test_a, bee_test_b CASCADE",
+		"CREATE SCHEMA bee_test_a",
+		"CREATE SCHEMA bee_test_b",
+		"CREATE TABLE bee_test_a.orders (id serial PRIMARY KEY)",
+		"CREATE TABLE bee_test_b.orders (id serial PRIMARY KEY)",
+		"CREATE TABLE bee_test_a.tags (name text, kind text, PRIMARY KEY (name, kind))",
+		"CREATE TABLE bee_test_b.tags (id serial PRIMARY KEY)",
+		"CREATE TABLE bee_test_a.items (id serial PRIMARY KEY, order_id integer REFERENCES bee_test_a.orders)",
+		"CREATE TABLE bee_test_b.items (id serial PRIMARY KEY, order_id integer REFERENCES bee_test_b.orders, tag_id integer REFERENCES bee_test_b.tags)",
+	)
+	t.Cleanup(func() { db.Exec("DROP SCHEMA bee_test_a, bee_test_b CASCADE") })
+
+	tables := getTableObjects([]string{
+		"bee_test_a.orders", "bee_test_a.items", "bee_test_a.tags",
+		"bee_test_b.orders", "bee_test_b.items", "bee_test_b.tags",
+	}, db, &PostgresDB{})
+	models := make(map[string]*Table)
+	for _, tb := range tables {
+		models[tb.modelName()] = tb
+	}
+	for name, pk := range map[string]string{"BeeTestAOrders": "id", "BeeTestBOrders": "id", "BeeTestATags": "", "BeeTestBTags": "id"} {
+		if tb, ok := models[name]; !ok || tb.Pk != pk {
+			t.Fatalf("expected a model %s with the primary key %q, got %v", name, pk, models)
+		}
+	}
+	if got := strings.Join(models["BeeTestATags"].PkColumns, ","); got != "name,kind" {
+		t.Errorf("unexpected primary key columns %s", got)
+	}
+
+	fieldTypes := func(tb *Table) map[string]string {
+		types := make(map[string]string)
+		for _, col := range tb.Columns {
+			types[col.Tag.Column] = col.Type
+		}
+		return types
+	}
+	// the foreign keys reference the table of their own schema
+	if got := fieldTypes(models["BeeTestAItems"])["order_id"]; got != "*BeeTestAOrders" {
+		t.Errorf("expected bee_test_a.items to reference *BeeTestAOrders, got %s", got)
+	}
+	types := fieldTypes(models["BeeTestBItems"])
+	if got := types["order_id"]; got != "*BeeTestBOrders" {
+		t.Errorf("expected bee_test_b.items to reference *BeeTestBOrders, got %s", got)
+	}
+	// the composite key of bee_test_a.tags does not blacklist bee_test_b.tags
+	if got := types["tag_id"]; got != "*BeeTestBTags" {
+		t.Errorf("expected bee_test_b.items to reference *BeeTestBTags, got %s", got)
+	}
+}
+
+func TestAddRelations(t *testing.T) {
+	db := openSQLite(t,
+		"CREATE TABLE author (id INTEGER PRIMARY KEY, name text NOT NULL)",
+		"CREATE TABLE book (id INTEGER PRIMARY KEY, title text NOT NULL)",
+		// a join table whose primary key is made of the two foreign keys
+		"CREATE TABLE author_book (author_id integer NOT NULL REFERENCES author, book_id integer NOT NULL REFERENCES book, PRIMARY KEY (author_id, book_id))",
+		"CREATE TABLE tag (id INTEGER PRIMARY KEY, name text NOT NULL)",
+		// a join table with its own primary key and a unique index over the foreign keys
+		"CREATE TABLE book_tag (id INTEGER PRIMARY KEY, book_id integer NOT NULL REFERENCES book, tag_id integer NOT NULL REFERENCES tag, UNIQUE (book_id, tag_id))",
+		// a composite primary key whose columns are not named after the tables cannot be used
+		"CREATE TABLE sequel (first_id integer NOT NULL REFERENCES book, next_id integer NOT NULL REFERENCES book, PRIMARY KEY (first_id, next_id))",
+		"CREATE TABLE review (id INTEGER PRIMARY KEY, book_id integer NOT NULL REFERENCES book, body text)",
+		// two foreign keys reference author, so neither has a reverse field
+		"CREATE TABLE letter (id INTEGER PRIMARY KEY, sender_id integer NOT NULL REFERENCES author, recipient_id integer NOT NULL REFERENCES author)",
+		"CREATE TABLE biography (id INTEGER PRIMARY KEY, author_id integer NOT NULL UNIQUE REFERENCES author, body text)",
+	)
+	trans := &SQLiteDB{}
+	tables := getTableObjects(trans.GetTableNames(db), db, trans)
+	var out bytes.Buffer
+	beeLogger.Log.SetOutput(&out)
+	addRelations(tables, "app")
+	beeLogger.Log.SetOutput(os.Stdout)
+	if n := strings.Count(out.String(), "Skipping the reverse relations of 'letter' to 'author', which 2 of its columns reference"); n != 1 {
+		t.Errorf("expected one warning about the reverse relations of letter, got:\n%s", out.String())
+	}
+	// author_book only serves as the rel_table of Author.Books, it gets no model
+	for _, tb := range tables {
+		if tb.RelTableOnly != (tb.Name == "author_book") {
+			t.Errorf("unexpected RelTableOnly %t for %s", tb.RelTableOnly, tb.Name)
+		}
+	}
+
+	relations := make(map[string]string)
+	for _, tb := range tables {
+		var fields []string
+		for _, col := range tb.Columns {
+			if col.Tag.RelM2M || col.Tag.ReverseMany || col.Tag.ReverseOne {
+				fields = append(fields, col.Name+" "+col.Type+" "+col.Tag.String())
+			}
+		}
+		relations[tb.Name] = strings.Join(fields, "\n")
+	}
+	expected := map[string]string{
+		"author": "Books []*Book `orm:\"rel(m2m);rel_table(author_book)\"`\n" +
+			"Biography *Biography `orm:\"reverse(one)\"`",
+		"book": "Authors []*Author `orm:\"reverse(many)\"`\n" +
+			"Tags []*Tag `orm:\"rel(m2m);rel_through(app/models.BookTag)\"`\n" +
+			"Reviews []*Review `orm:\"reverse(many)\"`",
+		"tag":         "Books []*Book `orm:\"reverse(many)\"`",
+		"author_book": "",
+		"book_tag":    "",
+		"sequel":      "",
+		"review":      "",
+		"letter":      "",
+		"biography":   "",
+	}
+	for name, want := range expected {
+		if got := relations[name]; got != want {
+			t.Errorf("unexpected relation fields of %s:\n%s\nexpected:\n%s", name, got, want)
+		}
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected []string
+	}{
+		{"mysql", mysqlEnumValues("enum('new','in-progress','it''s, done')"), []string{"new", "in-progress", "it's, done"}},
+		{"mysql set", mysqlEnumValues("set('a','b')"), nil},
+		{"mysql varchar", mysqlEnumValues("varchar(64)"), nil},
+		{"sql strings", sqlStrings("'a', 'b'::text"), []string{"a", "b"}},
+		{"sql not strings", sqlStrings("'a', 1"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if strings.Join(tt.values, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("expected %q, got %q", tt.expected, tt.values)
+			}
+		})
+	}
+
+	checks := []struct {
+		def      string
+		column   string
+		expected []string
+	}{
+		{"CHECK (((status)::text = ANY ((ARRAY['new'::character varying, 'done'::character varying])::text[])))", "status", []string{"new", "done"}},
+		{"CHECK ((kind = ANY (ARRAY['a'::text, 'b'::text])))", "kind", []string{"a", "b"}},
+		{`CHECK (("Kind" = ANY (ARRAY['a'::text])))`, "Kind", []string{"a"}},
+		{"CHECK ((price > (0)::numeric))", "", nil},
+	}
+	for _, tt := range checks {
+		column, values := postgresCheckEnum(tt.def)
+		if column != tt.column || strings.Join(values, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("expected %s %q for %s, got %s %q", tt.column, tt.expected, tt.def, column, values)
+		}
+	}
+
+	if got := enumConstName("in-progress"); got != "InProgress" {
+		t.Errorf("unexpected constant name %s", got)
+	}
+}
+
+func TestRenderEnum(t *testing.T) {
+	src, err := renderEnum(&Enum{Name: "PostStatus", Values: []string{"new", "in-progress", "it's \"done\"", "A_B", ""}, Source: "posts.status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(src, "{{") {
+		t.Errorf("unexpected placeholder left in the enum:\n%s", src)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "post_status_enum.go", src, 0); err != nil {
+		t.Errorf("the enum does not parse: %s\n%s", err, src)
+	}
+	for _, s := range []string{"PostStatusInProgress PostStatus = \"in-progress\"", "PostStatusItSDone PostStatus = \"it's \\\"done\\\"\"", "PostStatusAB PostStatus = \"A_B\"", "PostStatusEmpty PostStatus = \"\""} {
+		if !strings.Contains(src, s) {
+			t.Errorf("expected the enum to contain %s:\n%s", s, src)
+		}
+	}
+
+	// values giving the same constant can not be told apart
+	_, err = renderEnum(&Enum{Name: "PostStatus", Values: []string{"in-progress", "in progress"}, Source: "posts.status"})
+	if err == nil || !strings.Contains(err.Error(), `"in-progress" and "in progress" of PostStatus both give the constant PostStatusInProgress`) {
+		t.Errorf("expected a collision error, got %v", err)
+	}
+}
+
+func TestRenameEnums(t *testing.T) {
+	enumColumn := func(name, schema string) *Column {
+		col := &Column{Name: "Status", Tag: &OrmTag{Column: "status"}}
+		col.setEnum(name, []string{"a"}, "the "+schema+".status type")
+		col.Enum.Schema = schema
+		return col
+	}
+	tables := []*Table{
+		{Name: "orders", Schema: "public", Columns: []*Column{enumColumn("Status", "public")}},
+		{Name: "orders", Schema: "sales", Alias: "sales_orders", Columns: []*Column{enumColumn("Status", "sales"), enumColumn("Kind", "sales")}},
+		{Name: "kind", Schema: "sales", Columns: []*Column{enumColumn("Kind", "sales")}},
+	}
+	renameEnums(tables)
+
+	var got []string
+	for _, tb := range tables {
+		for _, col := range tb.Columns {
+			got = append(got, col.Type+"="+col.Enum.Name)
+		}
+	}
+	// the enum types of both schemas are prefixed, and the one named like a model is suffixed
+	if expected := "PublicStatus=PublicStatus,SalesStatus=SalesStatus,KindEnum=KindEnum,KindEnum=KindEnum"; strings.Join(got, ",") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(got, ","))
+	}
+}
+
+func TestRepositoryTemplates(t *testing.T) {
+	replacer := strings.NewReplacer("{{packageName}}", "models", "{{modelName}}", "Post", "{{idType}}", "int")
+	sources := map[string]string{
+		"repository.go":      replacer.Replace(repositoryTpl),
+		"fake.go":            replacer.Replace(fakeRepositoryTpl),
+		"fake_repository.go": replacer.Replace(fakeRepositoryHelpersTpl),
+		"controller.go":      renderRepositoryController("controllers", "Post", "app", "int", "", []uniqueField{{Field: "Slug", Column: "slug"}}),
+		"validation.go":      strings.Replace(validationTpl, "{{packageName}}", "controllers", -1),
+	}
+	for name, src := range sources {
+		if strings.Contains(src, "{{") {
+			t.Errorf("%s has a placeholder left:\n%s", name, src)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), name, src, 0); err != nil {
+			t.Errorf("%s does not parse: %s", name, err)
+		}
+	}
+}
+
+func TestValidRules(t *testing.T) {
+	tests := []struct {
+		col      *Column
+		expected string
+	}{
+		{&Column{Type: "string", Tag: &OrmTag{Size: "20"}}, "Required;MaxSize(20)"},
+		{&Column{Type: "string", Nullable: true, Tag: &OrmTag{Size: "20"}}, "MaxSize(20)"},
+		{&Column{Type: "string", HasDefault: true, Tag: &OrmTag{}}, ""},
+		{&Column{Type: "time.Time", Tag: &OrmTag{AutoNowAdd: true}}, ""},
+		{&Column{Type: "time.Time", Tag: &OrmTag{}}, "Required"},
+		{&Column{Type: "int", Tag: &OrmTag{}}, ""},
+		{&Column{Type: "int", Tag: &OrmTag{Auto: true}}, ""},
+		{&Column{Type: "PostStatus", Enum: &Enum{Name: "PostStatus"}, Tag: &OrmTag{Size: "20"}}, "Required"},
+		{&Column{Type: "PostStatus", Enum: &Enum{Name: "PostStatus"}, Nullable: true, Tag: &OrmTag{}}, ""},
+		{&Column{Type: "PostStatus", Enum: &Enum{Name: "PostStatus"}, HasDefault: true, Tag: &OrmTag{}}, ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tt.col.validRules(), ";"); got != tt.expected {
+			t.Errorf("expected %q for %+v, got %q", tt.expected, tt.col, got)
+		}
+	}
+
+	tb := &Table{Name: "post", Columns: []*Column{
+		{Name: "Title", Type: "string", Tag: &OrmTag{}},
+		{Name: "Status", Type: "PostStatus", Enum: &Enum{Name: "PostStatus"}, Tag: &OrmTag{}},
+		{Name: "Kind", Type: "PostKind", Enum: &Enum{Name: "PostKind"}, Nullable: true, Tag: &OrmTag{}},
+	}}
+	checks := tb.renderEnumChecks()
+	// UnmarshalText accepts the empty value, rejected for the required enums only
+	for _, s := range []string{"if !v.Status.Valid() {", `if v.Kind != "" && !v.Kind.Valid() {`, `valid.SetError("Kind", "Kind must be one of the values of PostKind").Name = "Valid"`} {
+		if !strings.Contains(checks, s) {
+			t.Errorf("expected the checks to contain %s:\n%s", s, checks)
+		}
+	}
+	controller := strings.NewReplacer("{{ctrlName}}", "Post", "{{pkgPath}}", "app", "{{enumChecks}}", checks, "{{uniqueChecks}}", "").Replace(CtrlTPL)
+	if _, err := parser.ParseFile(token.NewFileSet(), "post.go", controller, 0); err != nil {
+		t.Errorf("the controller does not parse: %s\n%s", err, controller)
+	}
+
+	if got := strings.Join(fieldValidRules("string:64"), ";"); got != "Required;MaxSize(64)" {
+		t.Errorf("unexpected rules %s", got)
+	}
+	if got := addStructTag("`orm:\"size(64)\"`", "valid", "Required"); got != "`orm:\"size(64)\" valid:\"Required\"`" {
+		t.Errorf("unexpected tag %s", got)
+	}
+}
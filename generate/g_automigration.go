@@ -0,0 +1,619 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"database/sql"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
+)
+
+// schemaDialect renders the DDL statements used to reconcile a database schema
+// with the models. It is implemented by the migration drivers.
+type schemaDialect interface {
+	columnType(col *modelColumn) string
+	columnDefinition(typ string, null bool, dflt string) string
+	createTableSQL(tb *modelTable) string
+	dropTableSQL(table string) string
+	addColumnSQL(table, column, def string) string
+	dropColumnSQL(table, column string) string
+	modifyColumnSQL(table, column, typ string, null bool) []string
+	renameColumnSQL(table, from, to string) string
+	createIndexSQL(table string, idx *Index) string
+	dropIndexSQL(table string, idx *Index) string
+	sameColumnType(typ, dbType string) bool
+}
+
+// modelTable is a table described by a model registered with orm.RegisterModel
+type modelTable struct {
+	Model   string
+	Name    string
+	Pk      string
+	Columns []*modelColumn
+	Indexes []*Index
+}
+
+// modelColumn is a column described by a field of a model
+type modelColumn struct {
+	Field  string
+	Name   string
+	GoType string
+	Tag    *OrmTag
+}
+
+// ormFieldTypes lists the Go types that map to a column
+var ormFieldTypes = map[string]bool{
+	"bool": true, "string": true, "time.Time": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
+
+// GenerateAutoMigration compares the models found in modelsPath with the schema of
+// the database and returns the Up and Down statements reconciling them.
+// Both are empty when the database is up to date.
+func GenerateAutoMigration(driver, connStr, modelsPath string) (upsql, downsql string) {
+	dialect, ok := NewDBDriver().(schemaDialect)
+	if !ok {
+		beeLogger.Log.Fatalf("Generating migrations from models is not supported for '%s'", driver)
+	}
+	trans, ok := dbDriver[driver]
+	if !ok {
+		beeLogger.Log.Fatalf("Generating migrations from models is not supported for '%s'", driver)
+	}
+
+	beeLogger.Log.Infof("Parsing models in '%s'", modelsPath)
+	models := parseModels(modelsPath)
+	if len(models) == 0 {
+		beeLogger.Log.Fatal("Could not find any model registered with orm.RegisterModel")
+	}
+
+	db, err := sql.Open(driver, connStr)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to '%s' database using '%s': %s", driver, connStr, err)
+	}
+	defer db.Close()
+
+	beeLogger.Log.Info("Analyzing database tables...")
+	var tableNames []string
+	for _, name := range trans.GetTableNames(db) {
+		for _, m := range models {
+			if m.Name == name {
+				tableNames = append(tableNames, name)
+				break
+			}
+		}
+	}
+	dbTables := make(map[string]*Table)
+	for _, tb := range getTableObjects(tableNames, db, trans) {
+		dbTables[tb.Name] = tb
+	}
+
+	up, down := diffSchema(dialect, models, dbTables)
+	return renderSQLCalls(up), renderSQLCalls(down)
+}
+
+// diffSchema returns the statements migrating dbTables to models and the
+// statements reverting them, in the order they must be run
+func diffSchema(dialect schemaDialect, models []*modelTable, dbTables map[string]*Table) (up, down []string) {
+	var revert [][]string
+	add := func(u []string, d ...string) {
+		up = append(up, u...)
+		revert = append(revert, d)
+	}
+
+	for _, mt := range models {
+		dbTable, ok := dbTables[mt.Name]
+		if !ok {
+			var u []string
+			u = append(u, dialect.createTableSQL(mt))
+			for _, idx := range mt.Indexes {
+				u = append(u, dialect.createIndexSQL(mt.Name, idx))
+			}
+			add(u, dialect.dropTableSQL(mt.Name))
+			continue
+		}
+
+		dbColumns := make(map[string]*Column)
+		for _, c := range dbTable.Columns {
+			dbColumns[c.Tag.Column] = c
+		}
+		modelColumns := make(map[string]bool)
+		for _, col := range mt.Columns {
+			modelColumns[col.Name] = true
+			typ := dialect.columnType(col)
+			dbCol, ok := dbColumns[col.Name]
+			if !ok {
+				add([]string{dialect.addColumnSQL(mt.Name, col.Name, dialect.columnDefinition(typ, col.Tag.Null, sqlDefault(col)))},
+					dialect.dropColumnSQL(mt.Name, col.Name))
+				continue
+			}
+			if col.Name == mt.Pk || col.Name == dbTable.Pk {
+				continue
+			}
+			if !dialect.sameColumnType(typ, dbCol.SQLType) || col.Tag.Null != dbCol.Nullable {
+				add(dialect.modifyColumnSQL(mt.Name, col.Name, typ, col.Tag.Null),
+					dialect.modifyColumnSQL(mt.Name, col.Name, dbCol.SQLType, dbCol.Nullable)...)
+			}
+		}
+		for _, c := range dbTable.Columns {
+			if modelColumns[c.Tag.Column] {
+				continue
+			}
+			add([]string{dialect.dropColumnSQL(mt.Name, c.Tag.Column)},
+				dialect.addColumnSQL(mt.Name, c.Tag.Column, dialect.columnDefinition(c.SQLType, c.Nullable, "")))
+		}
+
+		dbIndexes := make(map[string]*Index)
+		for _, idx := range dbTable.Indexes {
+			// indexes without columns are over expressions the models cannot describe
+			if len(idx.Columns) == 0 {
+				continue
+			}
+			// indexes backing foreign keys are managed by the database
+			if _, isFk := dbTable.Fk[idx.Columns[0]]; isFk && len(idx.Columns) == 1 && !idx.Unique {
+				continue
+			}
+			dbIndexes[indexKey(idx)] = idx
+		}
+		modelIndexes := make(map[string]bool)
+		for _, idx := range mt.Indexes {
+			modelIndexes[indexKey(idx)] = true
+			if _, ok := dbIndexes[indexKey(idx)]; !ok {
+				add([]string{dialect.createIndexSQL(mt.Name, idx)}, dialect.dropIndexSQL(mt.Name, idx))
+			}
+		}
+		for _, idx := range dbTable.Indexes {
+			if dbIndexes[indexKey(idx)] != idx || modelIndexes[indexKey(idx)] {
+				continue
+			}
+			add([]string{dialect.dropIndexSQL(mt.Name, idx)}, dialect.createIndexSQL(mt.Name, idx))
+		}
+	}
+
+	for i := len(revert) - 1; i >= 0; i-- {
+		down = append(down, revert[i]...)
+	}
+	return
+}
+
+// renderSQLCalls turns statements into m.SQL() calls of a migration
+func renderSQLCalls(statements []string) string {
+	var calls []string
+	for _, s := range statements {
+		calls = append(calls, "m.SQL("+strconv.Quote(s)+")")
+	}
+	return strings.Join(calls, "\n")
+}
+
+// indexKey identifies an index by its kind and columns rather than by its name
+func indexKey(idx *Index) string {
+	return strconv.FormatBool(idx.Unique) + ":" + strings.Join(idx.Columns, ",")
+}
+
+// indexName builds the name of an index declared in a model
+func indexName(table string, unique bool, columns []string) string {
+	suffix := "idx"
+	if unique {
+		suffix = "uniq"
+	}
+	return table + "_" + strings.Join(columns, "_") + "_" + suffix
+}
+
+// sizeOr returns size, or def when size is empty
+func sizeOr(size, def string) string {
+	if size == "" {
+		return def
+	}
+	return size
+}
+
+// sqlDefault renders the default(...) option of a column as an SQL literal
+func sqlDefault(col *modelColumn) string {
+	if col.Tag.Default == "" {
+		return ""
+	}
+	switch col.GoType {
+	case "string", "time.Time":
+		return "'" + strings.Replace(col.Tag.Default, "'", "''", -1) + "'"
+	}
+	return col.Tag.Default
+}
+
+// splitSQLType splits a column type like decimal(10,2) into its base type and arguments
+func splitSQLType(t string) (base, args string) {
+	if i := strings.Index(t, "("); i >= 0 {
+		base = strings.TrimSpace(t[:i])
+		if j := strings.Index(t[i:], ")"); j >= 0 {
+			args = strings.Replace(t[i+1:i+j], " ", "", -1)
+		}
+		return
+	}
+	return strings.TrimSpace(strings.TrimSuffix(t, " unsigned")), ""
+}
+
+// parseOrmTag parses the value of an orm struct tag, e.g. column(id);auto
+func parseOrmTag(s string) *OrmTag {
+	tag := new(OrmTag)
+	for _, opt := range strings.Split(s, ";") {
+		opt = strings.TrimSpace(opt)
+		name, arg := opt, ""
+		if i := strings.Index(opt, "("); i >= 0 && strings.HasSuffix(opt, ")") {
+			name, arg = opt[:i], opt[i+1:len(opt)-1]
+		}
+		switch name {
+		case "auto":
+			tag.Auto = true
+		case "pk":
+			tag.Pk = true
+		case "null":
+			tag.Null = true
+		case "index":
+			tag.Index = true
+		case "unique":
+			tag.Unique = true
+		case "auto_now":
+			tag.AutoNow = true
+		case "auto_now_add":
+			tag.AutoNowAdd = true
+		case "column":
+			tag.Column = arg
+		case "size":
+			tag.Size = arg
+		case "digits":
+			tag.Digits = arg
+		case "decimals":
+			tag.Decimals = arg
+		case "type":
+			tag.Type = arg
+		case "default":
+			tag.Default = arg
+		case "rel":
+			switch arg {
+			case "fk":
+				tag.RelFk = true
+			case "one":
+				tag.RelOne = true
+			case "m2m":
+				tag.RelM2M = true
+			}
+		case "reverse":
+			switch arg {
+			case "one":
+				tag.ReverseOne = true
+			case "many":
+				tag.ReverseMany = true
+			}
+		}
+	}
+	return tag
+}
+
+// modelParser collects the declarations of a models package needed to
+// rebuild the tables of its registered models
+type modelParser struct {
+	structs    map[string]*ast.StructType
+	named      map[string]string // underlying type of the named basic types, such as enums
+	registered []string
+	prefixes   map[string]string
+	tableNames map[string]string
+	indexes    map[string][][]string
+	uniques    map[string][][]string
+}
+
+// parseModels parses the Go files in modelsPath and returns the tables of
+// every model registered with orm.RegisterModel
+func parseModels(modelsPath string) []*modelTable {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, modelsPath, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not parse models: %s", err)
+	}
+
+	p := &modelParser{
+		structs:    make(map[string]*ast.StructType),
+		named:      make(map[string]string),
+		prefixes:   make(map[string]string),
+		tableNames: make(map[string]string),
+		indexes:    make(map[string][][]string),
+		uniques:    make(map[string][][]string),
+	}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			ast.Inspect(f, p.visit)
+		}
+	}
+
+	var tables []*modelTable
+	for _, name := range p.registered {
+		if tb := p.table(name); tb != nil {
+			tables = append(tables, tb)
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+func (p *modelParser) visit(n ast.Node) bool {
+	switch x := n.(type) {
+	case *ast.TypeSpec:
+		switch t := x.Type.(type) {
+		case *ast.StructType:
+			p.structs[x.Name.Name] = t
+		case *ast.Ident:
+			p.named[x.Name.Name] = t.Name
+		}
+	case *ast.FuncDecl:
+		if x.Recv == nil || len(x.Recv.List) != 1 || x.Body == nil || len(x.Body.List) != 1 {
+			return true
+		}
+		ret, ok := x.Body.List[0].(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			return true
+		}
+		recv := strings.TrimPrefix(types.ExprString(x.Recv.List[0].Type), "*")
+		switch x.Name.Name {
+		case "TableName":
+			if s, ok := stringLiteral(ret.Results[0]); ok {
+				p.tableNames[recv] = s
+			}
+		case "TableIndex":
+			p.indexes[recv] = stringListsLiteral(ret.Results[0])
+		case "TableUnique":
+			p.uniques[recv] = stringListsLiteral(ret.Results[0])
+		}
+	case *ast.CallExpr:
+		sel, ok := x.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		args := x.Args
+		prefix := ""
+		switch sel.Sel.Name {
+		case "RegisterModel":
+		case "RegisterModelWithPrefix":
+			if len(args) == 0 {
+				return true
+			}
+			prefix, _ = stringLiteral(args[0])
+			args = args[1:]
+		default:
+			return true
+		}
+		for _, arg := range args {
+			if name := modelArgName(arg); name != "" {
+				p.registered = append(p.registered, name)
+				p.prefixes[name] = prefix
+			}
+		}
+	}
+	return true
+}
+
+// table builds the table of a registered model
+func (p *modelParser) table(model string) *modelTable {
+	st, ok := p.structs[model]
+	if !ok {
+		beeLogger.Log.Warnf("Could not find the declaration of model '%s'", model)
+		return nil
+	}
+	tb := &modelTable{Model: model, Name: p.tableNames[model]}
+	if tb.Name == "" {
+		tb.Name = utils.SnakeString(model)
+	}
+	tb.Name = p.prefixes[model] + tb.Name
+	tb.Columns = p.columns(model, st)
+
+	for _, col := range tb.Columns {
+		if col.Tag.Pk || col.Tag.Auto {
+			tb.Pk = col.Name
+			break
+		}
+	}
+	if tb.Pk == "" {
+		// like the ORM, an integer field named Id is an auto increment primary key
+		for _, col := range tb.Columns {
+			if col.Field == "Id" && strings.Contains(col.GoType, "int") {
+				col.Tag.Auto = true
+				tb.Pk = col.Name
+				break
+			}
+		}
+	}
+
+	fieldColumns := make(map[string]string)
+	for _, col := range tb.Columns {
+		fieldColumns[col.Field] = col.Name
+		if col.Name == tb.Pk {
+			continue
+		}
+		if col.Tag.Index {
+			tb.addIndex(false, []string{col.Name})
+		}
+		if col.Tag.Unique || col.Tag.RelOne {
+			tb.addIndex(true, []string{col.Name})
+		}
+	}
+	for unique, lists := range map[bool][][]string{false: p.indexes[model], true: p.uniques[model]} {
+		for _, fields := range lists {
+			var cols []string
+			for _, f := range fields {
+				if c, ok := fieldColumns[f]; ok {
+					cols = append(cols, c)
+				} else {
+					beeLogger.Log.Warnf("Unknown field '%s' in indexes of model '%s'", f, model)
+				}
+			}
+			if len(cols) > 0 {
+				tb.addIndex(unique, cols)
+			}
+		}
+	}
+	sort.Slice(tb.Indexes, func(i, j int) bool { return tb.Indexes[i].Name < tb.Indexes[j].Name })
+	return tb
+}
+
+// columns returns the columns of a model struct, inlining embedded structs
+func (p *modelParser) columns(model string, st *ast.StructType) []*modelColumn {
+	var cols []*modelColumn
+	for _, field := range st.Fields.List {
+		ormTag := ""
+		if field.Tag != nil {
+			if s, err := strconv.Unquote(field.Tag.Value); err == nil {
+				ormTag = reflect.StructTag(s).Get("orm")
+			}
+		}
+		if ormTag == "-" {
+			continue
+		}
+		goType := types.ExprString(field.Type)
+
+		if len(field.Names) == 0 {
+			if embedded, ok := p.structs[strings.TrimPrefix(goType, "*")]; ok {
+				cols = append(cols, p.columns(model, embedded)...)
+			}
+			continue
+		}
+
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			tag := parseOrmTag(ormTag)
+			col := &modelColumn{Field: name.Name, Name: tag.Column, GoType: strings.TrimPrefix(goType, "*"), Tag: tag}
+			switch {
+			case tag.ReverseOne, tag.ReverseMany:
+				continue
+			case tag.RelM2M:
+				beeLogger.Log.Warnf("Skipping many-to-many field '%s.%s': join tables are not generated", model, name.Name)
+				continue
+			case tag.RelFk, tag.RelOne:
+				if col.Name == "" {
+					col.Name = utils.SnakeString(name.Name) + "_id"
+				}
+				col.GoType = p.pkType(col.GoType)
+			default:
+				if basic, ok := p.named[col.GoType]; ok {
+					col.GoType = basic
+				}
+			}
+			if col.Name == "" {
+				col.Name = utils.SnakeString(name.Name)
+			}
+			if !ormFieldTypes[col.GoType] {
+				beeLogger.Log.Warnf("Skipping field '%s.%s': unsupported type '%s'", model, name.Name, goType)
+				continue
+			}
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// pkType returns the Go type of the primary key of a model, int64 if unknown
+func (p *modelParser) pkType(model string) string {
+	st, ok := p.structs[model]
+	if !ok {
+		return "int64"
+	}
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			tag := ""
+			if field.Tag != nil {
+				if s, err := strconv.Unquote(field.Tag.Value); err == nil {
+					tag = reflect.StructTag(s).Get("orm")
+				}
+			}
+			t := parseOrmTag(tag)
+			if t.Pk || t.Auto || name.Name == "Id" {
+				return types.ExprString(field.Type)
+			}
+		}
+	}
+	return "int64"
+}
+
+// addIndex adds an index over columns unless an equivalent one exists
+func (tb *modelTable) addIndex(unique bool, columns []string) {
+	idx := &Index{Name: indexName(tb.Name, unique, columns), Columns: columns, Unique: unique}
+	for _, v := range tb.Indexes {
+		if indexKey(v) == indexKey(idx) {
+			return
+		}
+	}
+	tb.Indexes = append(tb.Indexes, idx)
+}
+
+// modelArgName returns the model name of a new(Model) or &Model{} argument
+func modelArgName(arg ast.Expr) string {
+	switch x := arg.(type) {
+	case *ast.CallExpr:
+		if fn, ok := x.Fun.(*ast.Ident); ok && fn.Name == "new" && len(x.Args) == 1 {
+			if id, ok := x.Args[0].(*ast.Ident); ok {
+				return id.Name
+			}
+		}
+	case *ast.UnaryExpr:
+		if lit, ok := x.X.(*ast.CompositeLit); ok {
+			if id, ok := lit.Type.(*ast.Ident); ok {
+				return id.Name
+			}
+		}
+	}
+	return ""
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// stringListsLiteral reads a [][]string{{"a", "b"}} literal
+func stringListsLiteral(expr ast.Expr) (lists [][]string) {
+	outer, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return
+	}
+	for _, elt := range outer.Elts {
+		inner, ok := elt.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		var list []string
+		for _, e := range inner.Elts {
+			if s, ok := stringLiteral(e); ok {
+				list = append(list, s)
+			}
+		}
+		lists = append(lists, list)
+	}
+	return
+}
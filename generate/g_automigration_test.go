@@ -0,0 +1,184 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const syncdbModels = `package models
+
+import (
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+type Account struct {
+	Id      int
+	Age     int
+	Count   uint
+	Score   float32
+	Ratio   float64
+	Active  bool
+	Name    string
+	Created time.Time
+	Group   *Group ` + "`orm:\"rel(fk)\"`" + `
+}
+
+type Group struct {
+	Id int
+}
+
+func init() {
+	orm.RegisterModel(new(Account), new(Group))
+}
+`
+
+const testModels = `package models
+
+import "github.com/beego/beego/v2/client/orm"
+
+type User struct {
+	Id       int
+	Name     string ` + "`orm:\"size(64)\"`" + `
+	Email    string ` + "`orm:\"unique\"`" + `
+	Nickname string ` + "`orm:\"null\"`" + `
+	Profile  *Profile ` + "`orm:\"rel(fk)\"`" + `
+	Posts    []*Post ` + "`orm:\"reverse(many)\"`" + `
+	secret   string
+}
+
+type Profile struct {
+	Id int
+}
+
+func (u *User) TableIndex() [][]string {
+	return [][]string{{"Name", "Nickname"}}
+}
+
+func init() {
+	orm.RegisterModel(new(User), new(Profile))
+}
+`
+
+func TestDiffSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "user.go"), []byte(testModels), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	models := parseModels(dir)
+	if len(models) != 2 || models[0].Name != "profile" || models[1].Name != "user" {
+		t.Fatalf("unexpected models: %+v", models)
+	}
+
+	dbTables := map[string]*Table{
+		"user": {
+			Name: "user",
+			Pk:   "id",
+			Fk:   map[string]*ForeignKey{},
+			Columns: []*Column{
+				{Tag: &OrmTag{Column: "id"}, SQLType: "int(11)"},
+				{Tag: &OrmTag{Column: "name"}, SQLType: "varchar(32)"},
+				{Tag: &OrmTag{Column: "email"}, SQLType: "varchar(255)"},
+				{Tag: &OrmTag{Column: "nickname"}, SQLType: "varchar(255)", Nullable: true},
+				{Tag: &OrmTag{Column: "legacy"}, SQLType: "int(11)", Nullable: true},
+			},
+			// an index over expressions has no columns
+			Indexes: []*Index{{Name: "old_idx", Columns: []string{"legacy"}}, {Name: "lower_name_idx"}},
+		},
+	}
+
+	up, down := diffSchema(mysqlDriver{}, models, dbTables)
+	expectedUp := []string{
+		"CREATE TABLE `profile` (`id` integer NOT NULL AUTO_INCREMENT, PRIMARY KEY (`id`)) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"ALTER TABLE `user` MODIFY COLUMN `name` varchar(64) NOT NULL",
+		"ALTER TABLE `user` ADD COLUMN `profile_id` integer NOT NULL",
+		"ALTER TABLE `user` DROP COLUMN `legacy`",
+		"CREATE UNIQUE INDEX `user_email_uniq` ON `user` (`email`)",
+		"CREATE INDEX `user_name_nickname_idx` ON `user` (`name`, `nickname`)",
+		"DROP INDEX `old_idx` ON `user`",
+	}
+	expectedDown := []string{
+		"CREATE INDEX `old_idx` ON `user` (`legacy`)",
+		"DROP INDEX `user_name_nickname_idx` ON `user`",
+		"DROP INDEX `user_email_uniq` ON `user`",
+		"ALTER TABLE `user` ADD COLUMN `legacy` int(11) NULL",
+		"ALTER TABLE `user` DROP COLUMN `profile_id`",
+		"ALTER TABLE `user` MODIFY COLUMN `name` varchar(32) NOT NULL",
+		"DROP TABLE `profile`",
+	}
+	if !reflect.DeepEqual(up, expectedUp) {
+		t.Errorf("unexpected up statements:\n%q", up)
+	}
+	if !reflect.DeepEqual(down, expectedDown) {
+		t.Errorf("unexpected down statements:\n%q", down)
+	}
+}
+
+// TestDiffSchemaSyncdb checks that the tables created by orm.RunSyncdb for the models
+// need no migration
+func TestDiffSchemaSyncdb(t *testing.T) {
+	dir, err := ioutil.TempDir("", "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "account.go"), []byte(syncdbModels), 0644); err != nil {
+		t.Fatal(err)
+	}
+	models := parseModels(dir)
+
+	tables := func(types map[string]string) map[string]*Table {
+		account := &Table{Name: "account", Pk: "id", Fk: map[string]*ForeignKey{}}
+		for _, c := range []string{"id", "age", "count", "score", "ratio", "active", "name", "created", "group_id"} {
+			account.Columns = append(account.Columns, &Column{Tag: &OrmTag{Column: c}, SQLType: types[c]})
+		}
+		group := &Table{Name: "group", Pk: "id", Fk: map[string]*ForeignKey{},
+			Columns: []*Column{{Tag: &OrmTag{Column: "id"}, SQLType: types["id"]}}}
+		return map[string]*Table{"account": account, "group": group}
+	}
+	tests := []struct {
+		name    string
+		dialect schemaDialect
+		types   map[string]string
+	}{
+		{"mysql", mysqlDriver{}, map[string]string{
+			"id": "int(11)", "age": "int(11)", "count": "int(10) unsigned", "score": "double", "ratio": "double",
+			"active": "tinyint(1)", "name": "varchar(255)", "created": "datetime", "group_id": "int(11)",
+		}},
+		{"postgres", postgresqlDriver{}, map[string]string{
+			"id": "integer", "age": "integer", "count": "bigint", "score": "double precision", "ratio": "double precision",
+			"active": "boolean", "name": "character varying(255)", "created": "timestamp with time zone", "group_id": "integer",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down := diffSchema(tt.dialect, models, tables(tt.types))
+			if len(up) != 0 || len(down) != 0 {
+				t.Errorf("expected no statements, got up %q and down %q", up, down)
+			}
+		})
+	}
+}
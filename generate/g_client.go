@@ -0,0 +1,380 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/v2/server/web/swagger"
+
+	"github.com/beego/bee/v2/generate/swaggergen"
+	beeLogger "github.com/beego/bee/v2/logger"
+)
+
+// clientAPI is the API a client is generated for, as documented by bee generate docs
+type clientAPI struct {
+	Title    string
+	BaseURL  string
+	Services []*clientService
+	Enums    []*clientEnum
+	models   *structInferrer // models and inline schemas of the operations
+}
+
+// clientEnum is a model whose values are constants, i.e. type Status string
+type clientEnum struct {
+	Name   string
+	Type   string
+	Values []*clientEnumValue
+}
+
+// clientEnumValue is a constant of an enum
+type clientEnumValue struct {
+	Name  string
+	Value string // Go literal of the value
+}
+
+// clientService groups the operations of a controller
+type clientService struct {
+	Name       string
+	Operations []*clientOperation
+}
+
+// clientOperation is an operation of the API, a method of the client
+type clientOperation struct {
+	Name       string
+	Method     string
+	Path       string
+	Summary    string
+	Params     []*clientParam
+	ParamsType string // struct of the query, header and form parameters, empty when there are none
+	Result     string // type of the body of the successful response, empty when there is none
+}
+
+// clientParam is a parameter of an operation
+type clientParam struct {
+	Name        string
+	Field       string // field of the parameters struct
+	Arg         string // argument of the method, for path and body parameters
+	In          string
+	Type        string
+	Required    bool
+	Description string
+}
+
+// GenerateClient generates a client of the API of the application, in the out
+// directory. The API is read from the annotations of the controllers, as
+// bee generate docs does. Generated files are overwritten.
+func GenerateClient(lang, out, currpath string) {
+	if out == "" {
+		out = "client"
+	}
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(currpath, out)
+	}
+	var reserved []string
+	if lang == "ts" || lang == "typescript" {
+		reserved = tsGlobals
+	}
+	api := readClientAPI(swaggergen.ParseDocs(currpath), reserved)
+	if len(api.Services) == 0 {
+		beeLogger.Log.Fatal("The API has no operation. Check the @router annotations of the controllers")
+	}
+	beeLogger.Log.Infof("Using '%s' as output directory", out)
+
+	switch lang {
+	case "", "go":
+		writeGoClient(api, out)
+	case "ts", "typescript":
+		writeTSClient(api, out)
+	default:
+		beeLogger.Log.Fatalf("Unknown client language '%s'. Use go or ts", lang)
+	}
+}
+
+// readClientAPI reads the models and the operations of the documentation of the API.
+// The models named like a reserved name are renamed.
+func readClientAPI(spec *swagger.Swagger, reserved []string) *clientAPI {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read the documentation of the API: %s", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	doc, err := decodeOrdered(dec)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read the documentation of the API: %s", err)
+	}
+	root := doc.(*jsonObject)
+
+	scheme, host := "http", spec.Host
+	if len(spec.Schemes) > 0 {
+		scheme = spec.Schemes[0]
+	}
+	if host == "" {
+		host = "localhost:8080"
+	}
+	api := &clientAPI{
+		Title:   spec.Infos.Title,
+		BaseURL: scheme + "://" + host + spec.BasePath,
+		models: &structInferrer{
+			names:  map[string]bool{"Client": true, "APIError": true},
+			refs:   make(map[string]string),
+			schema: root,
+			plain:  true,
+		},
+	}
+
+	for _, name := range reserved {
+		api.models.names[name] = true
+	}
+
+	// definitions are named after their struct, without the package
+	defs := objectValue(root, "definitions")
+	var objects []string
+	for _, key := range defs.Keys {
+		def := objectValue(defs, key)
+		if def.Values["properties"] == nil && def.Values["enum"] == nil {
+			continue
+		}
+		name := api.models.uniqueName(goIdent(key[strings.LastIndex(key, ".")+1:]))
+		api.models.names[name] = true
+		api.models.refs["#/definitions/"+key] = name
+		if def.Values["properties"] == nil {
+			api.Enums = append(api.Enums, api.enum(name, def))
+			continue
+		}
+		objects = append(objects, key)
+
+		// the fields without omitempty are always in the JSON of the model
+		if _, ok := def.Values["required"]; !ok {
+			def.Keys = append(def.Keys, "required")
+		}
+		required, _ := def.Values["required"].([]interface{})
+		for _, prop := range objectValue(def, "properties").Keys {
+			if !swaggergen.OmitEmpty(key, prop) {
+				required = append(required, prop)
+			}
+		}
+		def.Values["required"] = required
+	}
+	for _, key := range objects {
+		if err := api.models.schemaStruct(api.models.refs["#/definitions/"+key], objectValue(defs, key), false); err != nil {
+			beeLogger.Log.Fatalf("Could not read the model '%s': %s", key, err)
+		}
+	}
+
+	services := make(map[string]*clientService)
+	paths := objectValue(root, "paths")
+	for _, p := range paths.Keys {
+		item := objectValue(paths, p)
+		for _, method := range specMethods {
+			op, ok := item.Values[method].(*jsonObject)
+			if !ok {
+				continue
+			}
+			service, o := api.operation(p, method, op)
+			s, ok := services[service]
+			if !ok {
+				s = &clientService{Name: service}
+				services[service] = s
+				api.Services = append(api.Services, s)
+			}
+			name := o.Name
+			for i := 2; s.hasOperation(o.Name); i++ {
+				o.Name = fmt.Sprintf("%s%d", name, i)
+			}
+			s.Operations = append(s.Operations, o)
+		}
+	}
+	sort.Slice(api.Services, func(i, j int) bool { return api.Services[i].Name < api.Services[j].Name })
+
+	// the parameters structs are named after their operation, and their service when it is taken
+	for _, s := range api.Services {
+		for _, o := range s.Operations {
+			for _, p := range o.Params {
+				if p.In != "path" && p.In != "body" {
+					name := o.Name + "Params"
+					if api.models.names[name] {
+						name = s.Name + name
+					}
+					o.ParamsType = api.models.uniqueName(name)
+					api.models.names[o.ParamsType] = true
+					break
+				}
+			}
+		}
+	}
+	return api
+}
+
+// operation reads an operation and returns the name of its service, the controller
+// of the operation
+func (api *clientAPI) operation(p, method string, op *jsonObject) (string, *clientOperation) {
+	// bee generate docs names the operations Controller.Title
+	service, name := "Default", stringValue(op, "operationId")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		service, name = strings.TrimSuffix(name[:i], "Controller"), name[i+1:]
+	} else if tags, ok := op.Values["tags"].([]interface{}); ok && len(tags) > 0 {
+		service = fmt.Sprint(tags[0])
+	}
+	service = goIdent(service)
+	if name = goIdent(name); name == "" {
+		name = goIdent(method)
+		for _, segment := range strings.Split(p, "/") {
+			name += goIdent(segment)
+		}
+	}
+
+	summary := stringValue(op, "summary")
+	if summary == "" {
+		summary = strings.Split(strings.TrimSpace(strings.Replace(stringValue(op, "description"), "<br>", "", -1)), "\n")[0]
+	}
+	o := &clientOperation{Name: name, Method: strings.ToUpper(method), Path: p, Summary: oneLine(summary)}
+
+	params, _ := op.Values["parameters"].([]interface{})
+	for _, param := range params {
+		param, ok := param.(*jsonObject)
+		if !ok {
+			continue
+		}
+		cp := &clientParam{
+			Name:        stringValue(param, "name"),
+			In:          stringValue(param, "in"),
+			Required:    param.Values["required"] == true,
+			Description: oneLine(stringValue(param, "description")),
+		}
+		switch {
+		case cp.In == "body":
+			cp.Type = api.typeOf(o.Name+"Body", objectValue(param, "schema"))
+		case stringValue(param, "type") == "file":
+			beeLogger.Log.Warnf("Skipping file parameter '%s' of '%s': file uploads are not supported", cp.Name, o.Name)
+			continue
+		default:
+			cp.Type = api.typeOf(o.Name+goIdent(cp.Name), param)
+		}
+		o.addParam(cp)
+	}
+	// path parameters are always documented, unless the annotations are incomplete
+	for _, m := range pathParamRegex.FindAllStringSubmatch(p, -1) {
+		if !o.hasParam(m[1], "path") {
+			o.addParam(&clientParam{Name: m[1], In: "path", Type: "string", Required: true})
+		}
+	}
+
+	responses := objectValue(op, "responses")
+	codes := append([]string(nil), responses.Keys...)
+	sort.Strings(codes)
+	for _, code := range codes {
+		schema, ok := objectValue(responses, code).Values["schema"].(*jsonObject)
+		if strings.HasPrefix(code, "2") && ok {
+			o.Result = api.typeOf(o.Name+"Result", schema)
+			break
+		}
+	}
+	return service, o
+}
+
+// enum reads a model documented with the constants of its type. bee generate docs
+// documents them as "Name = literal".
+func (api *clientAPI) enum(name string, def *jsonObject) *clientEnum {
+	base := &jsonObject{Keys: []string{"type", "format"}, Values: map[string]interface{}{"type": def.Values["type"], "format": def.Values["format"]}}
+	e := &clientEnum{Name: name, Type: api.typeOf(name, base)}
+	if e.Type == "interface{}" {
+		e.Type = "string"
+	}
+	values, _ := def.Values["enum"].([]interface{})
+	for _, v := range values {
+		constName, literal := "", fmt.Sprint(v)
+		if s, ok := v.(string); ok {
+			literal = strconv.Quote(s)
+			if i := strings.Index(s, " = "); i > 0 {
+				constName, literal = goIdent(s[:i]), s[i+3:]
+				if unquoted, err := strconv.Unquote(literal); err == nil {
+					literal = strconv.Quote(unquoted)
+				}
+			}
+		}
+		if constName == "" {
+			constName = name + goIdent(strings.Trim(literal, `"`))
+		}
+		constName = api.models.uniqueName(constName)
+		api.models.names[constName] = true
+		e.Values = append(e.Values, &clientEnumValue{Name: constName, Value: literal})
+	}
+	return e
+}
+
+// typeOf returns the Go type of a schema, adding the structs of its inline objects to the models
+func (api *clientAPI) typeOf(name string, schema *jsonObject) string {
+	typ, _, _, err := api.models.schemaType(name, schema)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read the type of '%s': %s", name, err)
+	}
+	return typ
+}
+
+// isStruct reports whether a type is a struct of the models
+func (api *clientAPI) isStruct(typ string) bool {
+	for _, st := range api.models.structs {
+		if st.Name == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *clientService) hasOperation(name string) bool {
+	for _, o := range s.Operations {
+		if o.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addParam adds a parameter, naming its argument and its field uniquely
+func (o *clientOperation) addParam(p *clientParam) {
+	arg, field := argName(p.Name), goIdent(p.Name)
+	if field == "" {
+		field = "Param"
+	}
+	p.Arg, p.Field = arg, field
+	for i := 2; ; i++ {
+		taken := p.Arg == "ctx" || p.Arg == "params"
+		for _, other := range o.Params {
+			taken = taken || other.Arg == p.Arg || other.Field == p.Field
+		}
+		if !taken {
+			break
+		}
+		p.Arg, p.Field = fmt.Sprintf("%s%d", arg, i), fmt.Sprintf("%s%d", field, i)
+	}
+	o.Params = append(o.Params, p)
+}
+
+func (o *clientOperation) hasParam(name, in string) bool {
+	for _, p := range o.Params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,359 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/beego/bee/v2/utils"
+)
+
+// writeGoClient writes a Go package with a service per controller and a method per operation
+func writeGoClient(api *clientAPI, out string) {
+	pkg := strings.ToLower(nonIdentRegex.ReplaceAllString(filepath.Base(out), ""))
+	if pkg == "" || (pkg[0] >= '0' && pkg[0] <= '9') {
+		pkg = "client"
+	}
+
+	var services, inits strings.Builder
+	for _, s := range api.Services {
+		fmt.Fprintf(&services, "\n\t%s *%sService", s.Name, s.Name)
+		fmt.Fprintf(&inits, "\n\tc.%s = &%sService{client: c}", s.Name, s.Name)
+	}
+	utils.WriteGeneratedFile(filepath.Join(out, "client.go"), strings.NewReplacer(
+		"{{packageName}}", pkg,
+		"{{title}}", api.Title,
+		"{{baseURL}}", api.BaseURL,
+		"{{services}}", services.String(),
+		"{{serviceInits}}", inits.String(),
+	).Replace(loadTemplate("client/go/client.go.tpl")), utils.OverwriteExisting)
+
+	models := goClientHeader + "package " + pkg + "\n"
+	if api.models.hasTime {
+		models += "\nimport \"time\"\n"
+	}
+	for _, e := range api.Enums {
+		models += "\n" + goEnum(e)
+	}
+	if len(api.models.structs) > 0 {
+		models += "\n" + api.models.String()
+	}
+	for _, s := range api.Services {
+		for _, o := range s.Operations {
+			if o.ParamsType != "" {
+				models += "\n" + goParamsStruct(o)
+			}
+		}
+	}
+	utils.WriteGeneratedFile(filepath.Join(out, "models.go"), models, utils.OverwriteExisting)
+
+	for _, s := range api.Services {
+		utils.WriteGeneratedFile(filepath.Join(out, utils.SnakeString(s.Name)+".go"), goClientService(api, pkg, s), utils.OverwriteExisting)
+	}
+}
+
+// goEnum renders an enum as a type and its constants
+func goEnum(e *clientEnum) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s is one of the constants below\ntype %s %s\n", e.Name, e.Name, e.Type)
+	if len(e.Values) > 0 {
+		b.WriteString("\nconst (\n")
+		for _, v := range e.Values {
+			fmt.Fprintf(&b, "\t%s %s = %s\n", v.Name, e.Name, v.Value)
+		}
+		b.WriteString(")\n")
+	}
+	return b.String()
+}
+
+// goParamsStruct renders the struct of the query, header and form parameters of an
+// operation. Optional parameters are pointers, nil ones are not sent.
+func goParamsStruct(o *clientOperation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s are the parameters of %s\ntype %s struct {\n", o.ParamsType, o.Name, o.ParamsType)
+	for _, p := range o.Params {
+		if p.In == "path" || p.In == "body" {
+			continue
+		}
+		if p.Description != "" && p.Description != p.Name {
+			fmt.Fprintf(&b, "\t// %s\n", p.Description)
+		}
+		fmt.Fprintf(&b, "\t%s %s\n", p.Field, goParamType(p))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// goParamType returns the type of the field of a parameter
+func goParamType(p *clientParam) string {
+	if p.Required || strings.HasPrefix(p.Type, "[]") || strings.HasPrefix(p.Type, "map[") || p.Type == "interface{}" {
+		return p.Type
+	}
+	return "*" + p.Type
+}
+
+// goClientService renders the service of a controller
+func goClientService(api *clientAPI, pkg string, s *clientService) string {
+	var methods strings.Builder
+	usesURL, usesTime := false, false
+	for _, o := range s.Operations {
+		args := []string{"ctx context.Context"}
+		var pathExpr []string
+		last := 0
+		for _, m := range pathParamRegex.FindAllStringSubmatchIndex(o.Path, -1) {
+			if o.Path[last:m[0]] != "" {
+				pathExpr = append(pathExpr, fmt.Sprintf("%q", o.Path[last:m[0]]))
+			}
+			for _, p := range o.Params {
+				if p.In == "path" && p.Name == o.Path[m[2]:m[3]] {
+					pathExpr = append(pathExpr, "url.PathEscape(formatValue("+p.Arg+"))")
+					args = append(args, p.Arg+" "+p.Type)
+				}
+			}
+			usesURL = true
+			last = m[1]
+		}
+		if last < len(o.Path) || last == 0 {
+			pathExpr = append(pathExpr, fmt.Sprintf("%q", o.Path[last:]))
+		}
+		for _, p := range o.Params {
+			if p.In == "body" {
+				typ := p.Type
+				if api.isStruct(typ) {
+					typ = "*" + typ
+				}
+				args = append(args, p.Arg+" "+typ)
+			}
+		}
+		if o.ParamsType != "" {
+			args = append(args, "params *"+o.ParamsType)
+		}
+
+		result := o.Result
+		if api.isStruct(result) {
+			result = "*" + result
+		}
+		usesTime = usesTime || strings.Contains(strings.Join(args, ","), "time.") || strings.Contains(result, "time.")
+
+		summary := o.Summary
+		if summary == "" {
+			summary = fmt.Sprintf("calls %s %s", o.Method, o.Path)
+		}
+		fmt.Fprintf(&methods, "\n// %s %s\n", o.Name, summary)
+		if result != "" {
+			fmt.Fprintf(&methods, "func (s *%sService) %s(%s) (%s, error) {\n", s.Name, o.Name, strings.Join(args, ", "), result)
+		} else {
+			fmt.Fprintf(&methods, "func (s *%sService) %s(%s) error {\n", s.Name, o.Name, strings.Join(args, ", "))
+		}
+		fmt.Fprintf(&methods, "\tr := newRequest(%q, %s)\n", o.Method, strings.Join(pathExpr, " + "))
+		for _, p := range o.Params {
+			if p.In == "body" {
+				fmt.Fprintf(&methods, "\tr.body = %s\n", p.Arg)
+			}
+		}
+		if o.ParamsType != "" {
+			methods.WriteString("\tif params != nil {\n")
+			for _, p := range o.Params {
+				var values string
+				switch p.In {
+				case "path", "body":
+					continue
+				case "header":
+					values = "r.header"
+				case "formData":
+					values = "r.form"
+				default:
+					values = "r.query"
+				}
+				typ := goParamType(p)
+				switch {
+				case typ == p.Type && p.Required:
+					fmt.Fprintf(&methods, "\t\t%s.Set(%q, formatValue(params.%s))\n", values, p.Name, p.Field)
+				case typ == p.Type:
+					fmt.Fprintf(&methods, "\t\tif params.%s != nil {\n\t\t\t%s.Set(%q, formatValue(params.%s))\n\t\t}\n", p.Field, values, p.Name, p.Field)
+				default:
+					fmt.Fprintf(&methods, "\t\tif params.%s != nil {\n\t\t\t%s.Set(%q, formatValue(*params.%s))\n\t\t}\n", p.Field, values, p.Name, p.Field)
+				}
+			}
+			methods.WriteString("\t}\n")
+		}
+
+		switch {
+		case result == "":
+			methods.WriteString("\treturn s.client.do(ctx, r, nil)\n")
+		case strings.HasPrefix(result, "*"):
+			fmt.Fprintf(&methods, "\tout := new(%s)\n\tif err := s.client.do(ctx, r, out); err != nil {\n\t\treturn nil, err\n\t}\n\treturn out, nil\n", result[1:])
+		default:
+			fmt.Fprintf(&methods, "\tvar out %s\n\terr := s.client.do(ctx, r, &out)\n\treturn out, err\n", result)
+		}
+		methods.WriteString("}\n")
+	}
+
+	imports := []string{`"context"`}
+	if usesURL {
+		imports = append(imports, `"net/url"`)
+	}
+	if usesTime {
+		imports = append(imports, `"time"`)
+	}
+	return fmt.Sprintf("%spackage %s\n\nimport (\n\t%s\n)\n\n// %sService calls the operations of the %s controller\ntype %sService struct {\n\tclient *Client\n}\n%s",
+		goClientHeader, pkg, strings.Join(imports, "\n\t"), s.Name, s.Name, s.Name, methods.String())
+}
+
+const goClientHeader = "// Code generated by bee generate client. DO NOT EDIT.\n\n"
+
+var goClientTpl = `// Code generated by bee generate client. DO NOT EDIT.
+
+// Package {{packageName}} is a client of the {{title}} API.
+package {{packageName}}
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the URL of the API given by its documentation
+const DefaultBaseURL = "{{baseURL}}"
+
+// Client calls the operations of the API
+type Client struct {
+	// BaseURL is the URL the paths of the operations are appended to
+	BaseURL string
+	// HTTPClient sends the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+	// Header is sent with every request, i.e. for authentication
+	Header http.Header
+{{services}}
+}
+
+// NewClient returns a client of the API at baseURL, or at DefaultBaseURL when it is empty
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	c := &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: httpClient, Header: make(http.Header)}{{serviceInits}}
+	return c
+}
+
+// APIError is the error of a response with a status code other than 2xx
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	if body := bytes.TrimSpace(e.Body); len(body) > 0 {
+		return fmt.Sprintf("%s: %s", e.Status, body)
+	}
+	return e.Status
+}
+
+// request is the HTTP request of an operation
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	form   url.Values
+	body   interface{}
+}
+
+func newRequest(method, path string) *request {
+	return &request{method: method, path: path, query: url.Values{}, header: http.Header{}, form: url.Values{}}
+}
+
+// do sends a request and decodes the JSON body of the response into out, unless it is nil
+func (c *Client) do(ctx context.Context, r *request, out interface{}) error {
+	u := c.BaseURL + r.path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+	var body io.Reader
+	contentType := ""
+	switch {
+	case r.body != nil:
+		data, err := json.Marshal(r.body)
+		if err != nil {
+			return err
+		}
+		body, contentType = bytes.NewReader(data), "application/json"
+	case len(r.form) > 0:
+		body, contentType = strings.NewReader(r.form.Encode()), "application/x-www-form-urlencoded"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, u, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// formatValue formats a path, query, header or form parameter. Lists are comma separated.
+func formatValue(v interface{}) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		values := make([]string, rv.Len())
+		for i := range values {
+			values[i] = formatValue(rv.Index(i).Interface())
+		}
+		return strings.Join(values, ",")
+	}
+	return fmt.Sprint(v)
+}
+`
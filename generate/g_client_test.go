@@ -0,0 +1,120 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/beego/beego/v2/server/web/swagger"
+)
+
+func TestGoClientService(t *testing.T) {
+	api := readClientAPI(&swagger.Swagger{
+		BasePath: "/v1",
+		Paths: map[string]*swagger.Item{
+			"/user/{uid}": {Put: &swagger.Operation{
+				OperationID: "UserController.Update",
+				Parameters: []swagger.Parameter{
+					{In: "path", Name: "uid", Type: "string", Required: true},
+					{In: "query", Name: "notify", Type: "boolean"},
+					{In: "body", Name: "body", Required: true, Schema: &swagger.Schema{Ref: "#/definitions/models.User"}},
+				},
+				Responses: map[string]swagger.Response{
+					"200": {Schema: &swagger.Schema{Ref: "#/definitions/models.User"}},
+					"403": {Description: ":uid is not int"},
+				},
+			}},
+		},
+		Definitions: map[string]swagger.Schema{
+			"models.User": {Type: "object", Properties: map[string]swagger.Propertie{"Username": {Type: "string"}}},
+		},
+	}, nil)
+	if api.BaseURL != "http://localhost:8080/v1" {
+		t.Errorf("unexpected base URL %s", api.BaseURL)
+	}
+	if len(api.Services) != 1 || api.Services[0].Name != "User" {
+		t.Fatalf("unexpected services: %+v", api.Services)
+	}
+
+	got := goClientService(api, "client", api.Services[0])
+	for _, expected := range []string{
+		"func (s *UserService) Update(ctx context.Context, uid string, body *User, params *UpdateParams) (*User, error) {\n",
+		"\tr := newRequest(\"PUT\", \"/user/\" + url.PathEscape(formatValue(uid)))\n",
+		"\tr.body = body\n",
+		"\t\tif params.Notify != nil {\n\t\t\tr.query.Set(\"notify\", formatValue(*params.Notify))\n",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected %q in:\n%s", expected, got)
+		}
+	}
+}
+
+func TestTSClient(t *testing.T) {
+	api := readClientAPI(&swagger.Swagger{
+		Paths: map[string]*swagger.Item{
+			"/pet/{id}": {Get: &swagger.Operation{
+				OperationID: "PetController.Get",
+				Parameters: []swagger.Parameter{
+					{In: "path", Name: "id", Type: "integer", Format: "int64", Required: true},
+					{In: "header", Name: "X-Trace", Type: "string"},
+				},
+				Responses: map[string]swagger.Response{
+					"200": {Schema: &swagger.Schema{Ref: "#/definitions/models.Pet"}},
+				},
+			}},
+		},
+		Definitions: map[string]swagger.Schema{
+			"models.Pet": {Type: "object", Properties: map[string]swagger.Propertie{
+				"status": {Ref: "#/definitions/models.Status"},
+				"tags":   {Type: "array", Items: &swagger.Propertie{Type: "string"}},
+				"labels": {Type: "object", AdditionalProperties: &swagger.Propertie{Type: "string"}},
+				"owner":  {Ref: "#/definitions/models.Object"},
+			}},
+			"models.Object": {Type: "object", Properties: map[string]swagger.Propertie{
+				"name": {Type: "string"},
+			}},
+			"models.Status": {Type: "string", Enum: []interface{}{`StatusSold = "sold"`}},
+		},
+	}, tsGlobals)
+
+	got := tsEnum(api.Enums[0]) + tsClientService(api, api.Services[0])
+	for _, st := range api.models.structs {
+		got += tsInterface(st)
+	}
+	for _, expected := range []string{
+		"export enum Status {\n  Sold = \"sold\",\n}\n",
+		"  labels: Record<string, string>;\n",
+		"  status: Status;\n",
+		"  owner: Object2;\n",
+		"export interface Object2 {\n  name: string;\n}\n",
+		"  tags: string[];\n",
+		"import type { GetParams, Pet } from \"./models\";\n",
+		"  get(id: number, params: GetParams = {}, signal?: AbortSignal): Promise<Pet> {\n",
+		"      path: \"/pet/\" + encodeURIComponent(String(id)),\n",
+		"      headers: { \"X-Trace\": params.xTrace },\n",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected %q in:\n%s", expected, got)
+		}
+	}
+
+	// omitempty structs are pointers in Go
+	for typ, expected := range map[string]string{"*Object2": "Object2", "[]*Object2": "Object2[]", "uint16": "number", "*float32": "number"} {
+		if got := tsType(typ); got != expected {
+			t.Errorf("tsType(%s) = %s, expected %s", typ, got, expected)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/beego/bee/v2/utils"
+)
+
+var (
+	tsIdentRegex    = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	tsTypeNameRegex = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*`)
+)
+
+// tsReserved are the reserved words of TypeScript that cannot name an argument
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true,
+	"if": true, "import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"yield": true, "let": true, "static": true, "implements": true, "interface": true,
+	"package": true, "private": true, "protected": true, "public": true, "await": true,
+	"arguments": true, "eval": true, "signal": true,
+}
+
+// tsGlobals are the global types of TypeScript and of the generated client that
+// models must not shadow
+var tsGlobals = []string{
+	"AbortController", "AbortSignal", "Array", "ArrayBuffer", "BigInt", "Blob", "Boolean",
+	"DataView", "Date", "Error", "Exclude", "Extract", "File", "FormData", "Function",
+	"Headers", "Intl", "JSON", "Map", "Math", "Number", "Object", "Omit", "Partial", "Pick",
+	"Promise", "Proxy", "Readonly", "Record", "Reflect", "RegExp", "Request", "RequestInit",
+	"Required", "Response", "Set", "String", "Symbol", "URL", "URLSearchParams", "WeakMap", "WeakSet",
+}
+
+// writeTSClient writes a TypeScript module with a service class per controller and a
+// method per operation, calling the API with fetch
+func writeTSClient(api *clientAPI, out string) {
+	var imports, services, inits strings.Builder
+	for _, s := range api.Services {
+		file := utils.SnakeString(s.Name)
+		fmt.Fprintf(&imports, "import { %sService } from \"./%s\";\nexport * from \"./%s\";\n", s.Name, file, file)
+		member := tsMember(s.Name)
+		if member == "baseURL" || member == "headers" || member == "send" || member == "request" {
+			member += "Service"
+		}
+		fmt.Fprintf(&services, "\n  readonly %s: %sService;", member, s.Name)
+		fmt.Fprintf(&inits, "\n    this.%s = new %sService(this);", member, s.Name)
+	}
+	utils.WriteGeneratedFile(filepath.Join(out, "client.ts"), strings.NewReplacer(
+		"{{title}}", api.Title,
+		"{{baseURL}}", api.BaseURL,
+		"{{imports}}", imports.String(),
+		"{{services}}", services.String(),
+		"{{serviceInits}}", inits.String(),
+	).Replace(loadTemplate("client/ts/client.ts.tpl")), utils.OverwriteExisting)
+
+	var models []string
+	for _, e := range api.Enums {
+		models = append(models, tsEnum(e))
+	}
+	for _, st := range api.models.structs {
+		models = append(models, tsInterface(st))
+	}
+	for _, s := range api.Services {
+		for _, o := range s.Operations {
+			if o.ParamsType != "" {
+				models = append(models, tsParamsInterface(o))
+			}
+		}
+	}
+	utils.WriteGeneratedFile(filepath.Join(out, "models.ts"), goClientHeader+strings.Join(models, "\n"), utils.OverwriteExisting)
+
+	for _, s := range api.Services {
+		utils.WriteGeneratedFile(filepath.Join(out, utils.SnakeString(s.Name)+".ts"), tsClientService(api, s), utils.OverwriteExisting)
+	}
+}
+
+// tsType turns the Go type of a model into a TypeScript type
+func tsType(typ string) string {
+	switch {
+	case strings.HasPrefix(typ, "*"):
+		// optional structs, the property is optional already
+		return tsType(typ[1:])
+	case strings.HasPrefix(typ, "[]"):
+		elem := tsType(typ[2:])
+		if strings.Contains(elem, " ") && !strings.HasPrefix(elem, "Record<") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case strings.HasPrefix(typ, "map[string]"):
+		return "Record<string, " + tsType(typ[len("map[string]"):]) + ">"
+	}
+	switch typ {
+	case "bool":
+		return "boolean"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return "number"
+	case "string", "time.Time":
+		return "string"
+	case "interface{}", "":
+		return "unknown"
+	}
+	return typ
+}
+
+// tsKey returns a property name, quoted when it is not an identifier
+func tsKey(key string) string {
+	if tsIdentRegex.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// tsMember returns the lowerCamel name of a member, i.e. the pet service of a client
+func tsMember(name string) string {
+	return argName(name)
+}
+
+// tsArg returns the name of an argument, which must not be a reserved word
+func tsArg(name string) string {
+	if tsReserved[name] {
+		return name + "Param"
+	}
+	return name
+}
+
+// tsEnum renders an enum. Its members are named after the constants, without the
+// name of the enum.
+func tsEnum(e *clientEnum) string {
+	var b strings.Builder
+	if e.Type == "bool" || len(e.Values) == 0 {
+		var values []string
+		for _, v := range e.Values {
+			values = append(values, v.Value)
+		}
+		if len(values) == 0 {
+			values = []string{tsType(e.Type)}
+		}
+		fmt.Fprintf(&b, "export type %s = %s;\n", e.Name, strings.Join(values, " | "))
+		return b.String()
+	}
+	fmt.Fprintf(&b, "export enum %s {\n", e.Name)
+	for _, v := range e.Values {
+		member := v.Name
+		if trimmed := strings.TrimPrefix(member, e.Name); trimmed != member && tsIdentRegex.MatchString(trimmed) {
+			member = trimmed
+		}
+		fmt.Fprintf(&b, "  %s = %s,\n", member, v.Value)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// tsInterface renders a struct of the models. Fields with omitempty are optional.
+func tsInterface(st *inferredStruct) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "export interface %s {\n", st.Name)
+	for _, f := range st.Fields {
+		optional := ""
+		if f.Optional {
+			optional = "?"
+		}
+		fmt.Fprintf(&b, "  %s%s: %s;\n", tsKey(f.Key), optional, tsType(f.Type))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// tsParamsInterface renders the query, header and form parameters of an operation
+func tsParamsInterface(o *clientOperation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "/** parameters of %s */\nexport interface %s {\n", o.Name, o.ParamsType)
+	for _, p := range o.Params {
+		if p.In == "path" || p.In == "body" {
+			continue
+		}
+		if p.Description != "" && p.Description != p.Name {
+			fmt.Fprintf(&b, "  /** %s */\n", p.Description)
+		}
+		optional := "?"
+		if p.Required {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "  %s%s: %s;\n", p.Arg, optional, tsType(p.Type))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// tsClientService renders the service class of a controller
+func tsClientService(api *clientAPI, s *clientService) string {
+	var methods strings.Builder
+	used := make(map[string]bool)
+	useTypes := func(types ...string) {
+		for _, typ := range types {
+			for _, name := range tsTypeNameRegex.FindAllString(tsType(typ), -1) {
+				if api.models.names[name] {
+					used[name] = true
+				}
+			}
+		}
+	}
+
+	for _, o := range s.Operations {
+		var args []string
+		var pathExpr []string
+		last := 0
+		for _, m := range pathParamRegex.FindAllStringSubmatchIndex(o.Path, -1) {
+			if o.Path[last:m[0]] != "" {
+				pathExpr = append(pathExpr, strconv.Quote(o.Path[last:m[0]]))
+			}
+			for _, p := range o.Params {
+				if p.In == "path" && p.Name == o.Path[m[2]:m[3]] {
+					pathExpr = append(pathExpr, "encodeURIComponent(String("+tsArg(p.Arg)+"))")
+					args = append(args, tsArg(p.Arg)+": "+tsType(p.Type))
+					useTypes(p.Type)
+				}
+			}
+			last = m[1]
+		}
+		if last < len(o.Path) || last == 0 {
+			pathExpr = append(pathExpr, strconv.Quote(o.Path[last:]))
+		}
+		var body string
+		for _, p := range o.Params {
+			if p.In == "body" {
+				body = tsArg(p.Arg)
+				args = append(args, body+": "+tsType(p.Type))
+				useTypes(p.Type)
+			}
+		}
+		values := make(map[string][]string)
+		if o.ParamsType != "" {
+			required := false
+			for _, p := range o.Params {
+				var in string
+				switch p.In {
+				case "path", "body":
+					continue
+				case "header":
+					in = "headers"
+				case "formData":
+					in = "form"
+				default:
+					in = "query"
+				}
+				values[in] = append(values[in], fmt.Sprintf("%s: params.%s", tsKey(p.Name), p.Arg))
+				required = required || p.Required
+			}
+			if required {
+				args = append(args, "params: "+o.ParamsType)
+			} else {
+				args = append(args, "params: "+o.ParamsType+" = {}")
+			}
+			useTypes(o.ParamsType)
+		}
+		args = append(args, "signal?: AbortSignal")
+
+		result := "void"
+		if o.Result != "" {
+			result = tsType(o.Result)
+			useTypes(o.Result)
+		}
+
+		summary := o.Summary
+		if summary == "" {
+			summary = fmt.Sprintf("calls %s %s", o.Method, o.Path)
+		}
+		fmt.Fprintf(&methods, "\n  /** %s */\n", summary)
+		fmt.Fprintf(&methods, "  %s(%s): Promise<%s> {\n", tsMember(o.Name), strings.Join(args, ", "), result)
+		fmt.Fprintf(&methods, "    return this.client.request<%s>({\n", result)
+		fmt.Fprintf(&methods, "      method: %q,\n      path: %s,\n", o.Method, strings.Join(pathExpr, " + "))
+		for _, in := range []string{"query", "headers", "form"} {
+			if len(values[in]) > 0 {
+				fmt.Fprintf(&methods, "      %s: { %s },\n", in, strings.Join(values[in], ", "))
+			}
+		}
+		switch body {
+		case "":
+		case "body":
+			methods.WriteString("      body,\n")
+		default:
+			fmt.Fprintf(&methods, "      body: %s,\n", body)
+		}
+		methods.WriteString("      signal,\n    });\n  }\n")
+	}
+
+	var b strings.Builder
+	b.WriteString(goClientHeader)
+	b.WriteString("import type { Client } from \"./client\";\n")
+	if len(used) > 0 {
+		var names []string
+		for name := range used {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&b, "import type { %s } from \"./models\";\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(&b, "\n/** calls the operations of the %s controller */\nexport class %sService {\n  constructor(private readonly client: Client) {}\n%s}\n",
+		s.Name, s.Name, methods.String())
+	return b.String()
+}
+
+var tsClientTpl = `// Code generated by bee generate client. DO NOT EDIT.
+
+// {{title}} client.
+{{imports}}export * from "./models";
+
+/** URL of the API given by its documentation */
+export const DEFAULT_BASE_URL = "{{baseURL}}";
+
+export interface ClientOptions {
+  /** URL the paths of the operations are appended to, DEFAULT_BASE_URL when missing */
+  baseURL?: string;
+  /** headers sent with every request, i.e. for authentication */
+  headers?: Record<string, string>;
+  /** function sending the requests, the global fetch when missing */
+  fetch?: (input: string, init: RequestInit) => Promise<Response>;
+}
+
+/** error of a response with a status code other than 2xx */
+export class APIError extends Error {
+  constructor(readonly status: number, readonly statusText: string, readonly body: string) {
+    super(body ? status + " " + statusText + ": " + body : status + " " + statusText);
+    this.name = "APIError";
+    Object.setPrototypeOf(this, APIError.prototype);
+  }
+}
+
+/** HTTP request of an operation */
+export interface ClientRequest {
+  method: string;
+  path: string;
+  query?: Record<string, unknown>;
+  headers?: Record<string, unknown>;
+  form?: Record<string, unknown>;
+  body?: unknown;
+  signal?: AbortSignal;
+}
+
+/** Client calls the operations of the API */
+export class Client {
+  readonly baseURL: string;
+  readonly headers: Record<string, string>;
+  private readonly send: (input: string, init: RequestInit) => Promise<Response>;
+{{services}}
+
+  constructor(options: ClientOptions = {}) {
+    this.baseURL = (options.baseURL || DEFAULT_BASE_URL).replace(/\/+$/, "");
+    this.headers = options.headers || {};
+    this.send = options.fetch || ((input, init) => fetch(input, init));{{serviceInits}}
+  }
+
+  /** sends a request and decodes the JSON body of the response */
+  async request<T>(r: ClientRequest): Promise<T> {
+    let url = this.baseURL + r.path;
+    const query = encodeValues(r.query);
+    if (query) {
+      url += "?" + query;
+    }
+    const headers: Record<string, string> = { ...this.headers, Accept: "application/json" };
+    for (const [k, v] of Object.entries(r.headers || {})) {
+      if (v !== undefined && v !== null) {
+        headers[k] = formatValue(v);
+      }
+    }
+    let body: string | undefined;
+    if (r.body !== undefined) {
+      body = JSON.stringify(r.body);
+      headers["Content-Type"] = "application/json";
+    } else if (r.form) {
+      body = encodeValues(r.form);
+      headers["Content-Type"] = "application/x-www-form-urlencoded";
+    }
+
+    const resp = await this.send(url, { method: r.method, headers, body, signal: r.signal });
+    const text = await resp.text();
+    if (!resp.ok) {
+      throw new APIError(resp.status, resp.statusText, text);
+    }
+    return (text ? JSON.parse(text) : undefined) as T;
+  }
+}
+
+/** formats a query, header or form parameter. Lists are comma separated. */
+export function formatValue(v: unknown): string {
+  if (Array.isArray(v)) {
+    return v.map(formatValue).join(",");
+  }
+  if (v instanceof Date) {
+    return v.toISOString();
+  }
+  return String(v);
+}
+
+function encodeValues(values?: Record<string, unknown>): string {
+  const params = new URLSearchParams();
+  for (const [k, v] of Object.entries(values || {})) {
+    if (v !== undefined && v !== null) {
+      params.append(k, formatValue(v));
+    }
+  }
+  return params.toString();
+}
+`
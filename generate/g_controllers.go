@@ -15,19 +15,20 @@
 package generate
 
 import (
-	"fmt"
 	"os"
 	"path"
 	"strings"
 
 	beeLogger "github.com/beego/bee/v2/logger"
-	"github.com/beego/bee/v2/logger/colors"
 	"github.com/beego/bee/v2/utils"
 )
 
 func GenerateController(cname, currpath string) {
-	w := colors.NewColorWriter(os.Stdout)
+	generateController(cname, currpath, utils.FailIfExists)
+}
 
+// generateController writes the controller, with the given policy for an existing file
+func generateController(cname, currpath string, existing utils.ExistingPolicy) {
 	p, f := path.Split(cname)
 	controllerName := strings.Title(f)
 	packageName := "controllers"
@@ -40,45 +41,39 @@ func GenerateController(cname, currpath string) {
 	beeLogger.Log.Infof("Using '%s' as controller name", controllerName)
 	beeLogger.Log.Infof("Using '%s' as package name", packageName)
 
-	fp := path.Join(currpath, "controllers", p)
-	if _, err := os.Stat(fp); os.IsNotExist(err) {
-		// Create the controller's directory
-		if err := os.MkdirAll(fp, 0777); err != nil {
-			beeLogger.Log.Fatalf("Could not create controllers directory: %s", err)
-		}
-	}
-
-	fpath := path.Join(fp, strings.ToLower(controllerName)+".go")
-	if f, err := os.OpenFile(fpath, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0666); err == nil {
-		defer utils.CloseFile(f)
-
-		modelPath := path.Join(currpath, "models", strings.ToLower(controllerName)+".go")
-
-		var content string
-		if _, err := os.Stat(modelPath); err == nil {
-			beeLogger.Log.Infof("Using matching model '%s'", controllerName)
-			content = strings.Replace(controllerModelTpl, "{{packageName}}", packageName, -1)
-			pkgPath := getPackagePath(currpath)
-			content = strings.Replace(content, "{{pkgPath}}", pkgPath, -1)
-		} else {
-			content = strings.Replace(controllerTpl, "{{packageName}}", packageName, -1)
-		}
-
-		content = strings.Replace(content, "{{controllerName}}", controllerName, -1)
-		f.WriteString(content)
-
-		// Run 'gofmt' on the generated source code
-		utils.FormatSourceCode(fpath)
-		fmt.Fprintf(w, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", fpath, "\x1b[0m")
+	fpath := path.Join(currpath, "controllers", p, strings.ToLower(controllerName)+".go")
+	modelPath := path.Join(currpath, "models", strings.ToLower(controllerName)+".go")
+
+	var content string
+	if _, err := os.Stat(modelPath); err == nil && Repository {
+		beeLogger.Log.Infof("Using the repository of the matching model '%s'", controllerName)
+		unique := modelUniqueFields(path.Join(currpath, "models"), controllerName)
+		content = renderRepositoryController(packageName, controllerName, getPackagePath(currpath), "int64", "", unique)
+		writeValidationHelpers(path.Dir(fpath), packageName)
+	} else if err == nil {
+		beeLogger.Log.Infof("Using matching model '%s'", controllerName)
+		content = strings.Replace(loadTemplate("controller_model.go.tpl"), "{{packageName}}", packageName, -1)
+		pkgPath := getPackagePath(currpath)
+		content = strings.Replace(content, "{{pkgPath}}", pkgPath, -1)
+		unique := modelUniqueFields(path.Join(currpath, "models"), controllerName)
+		content = strings.Replace(content, "{{uniqueChecks}}",
+			renderUniqueChecks(controllerName, "models.GetAll"+controllerName, unique), -1)
+		writeValidationHelpers(path.Dir(fpath), packageName)
 	} else {
-		beeLogger.Log.Fatalf("Could not create controller file: %s", err)
+		content = strings.Replace(loadTemplate("controller.go.tpl"), "{{packageName}}", packageName, -1)
 	}
+
+	content = strings.Replace(content, "{{controllerName}}", controllerName, -1)
+	utils.WriteGeneratedFile(fpath, content, existing)
 }
 
 var controllerTpl = `package {{packageName}}
 
 import (
 	beego "github.com/beego/beego/v2/server/web"
+
+	// bee:begin imports
+	// bee:end
 )
 
 // {{controllerName}}Controller operations for {{controllerName}}
@@ -93,6 +88,9 @@ func (c *{{controllerName}}Controller) URLMapping() {
 	c.Mapping("GetAll", c.GetAll)
 	c.Mapping("Put", c.Put)
 	c.Mapping("Delete", c.Delete)
+
+	// bee:begin mappings
+	// bee:end
 }
 
 // Post ...
@@ -155,6 +153,9 @@ func (c *{{controllerName}}Controller) Put() {
 func (c *{{controllerName}}Controller) Delete() {
 
 }
+
+// bee:begin custom
+// bee:end
 `
 
 var controllerModelTpl = `package {{packageName}}
@@ -166,7 +167,11 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/beego/beego/v2/core/validation"
 	beego "github.com/beego/beego/v2/server/web"
+
+	// bee:begin imports
+	// bee:end
 )
 
 //  {{controllerName}}Controller operations for {{controllerName}}
@@ -181,6 +186,9 @@ func (c *{{controllerName}}Controller) URLMapping() {
 	c.Mapping("GetAll", c.GetAll)
 	c.Mapping("Put", c.Put)
 	c.Mapping("Delete", c.Delete)
+
+	// bee:begin mappings
+	// bee:end
 }
 
 // Post ...
@@ -188,12 +196,16 @@ func (c *{{controllerName}}Controller) URLMapping() {
 // @Description create {{controllerName}}
 // @Param	body		body 	models.{{controllerName}}	true		"body for {{controllerName}} content"
 // @Success 201 {int} models.{{controllerName}}
+// @Failure 400 the fields breaking validation rules
 // @Failure 403 body is empty
 // @router / [post]
 func (c *{{controllerName}}Controller) Post() {
 	var v models.{{controllerName}}
 	json.Unmarshal(c.Ctx.Input.RequestBody, &v)
-	if _, err := models.Add{{controllerName}}(&v); err == nil {
+	if errs := c.validate(&v); errs != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = errs
+	} else if _, err := models.Add{{controllerName}}(&v); err == nil {
 		c.Ctx.Output.SetStatus(201)
 		c.Data["json"] = v
 	} else {
@@ -290,6 +302,7 @@ func (c *{{controllerName}}Controller) GetAll() {
 // @Param	id		path 	string	true		"The id you want to update"
 // @Param	body		body 	models.{{controllerName}}	true		"body for {{controllerName}} content"
 // @Success 200 {object} models.{{controllerName}}
+// @Failure 400 the fields breaking validation rules
 // @Failure 403 :id is not int
 // @router /:id [put]
 func (c *{{controllerName}}Controller) Put() {
@@ -297,7 +310,10 @@ func (c *{{controllerName}}Controller) Put() {
 	id, _ := strconv.ParseInt(idStr, 0, 64)
 	v := models.{{controllerName}}{Id: id}
 	json.Unmarshal(c.Ctx.Input.RequestBody, &v)
-	if err := models.Update{{controllerName}}ById(&v); err == nil {
+	if errs := c.validate(&v); errs != nil {
+		c.Ctx.Output.SetStatus(400)
+		c.Data["json"] = errs
+	} else if err := models.Update{{controllerName}}ById(&v); err == nil {
 		c.Data["json"] = "OK"
 	} else {
 		c.Data["json"] = err.Error()
@@ -322,4 +338,17 @@ func (c *{{controllerName}}Controller) Delete() {
 	}
 	c.ServeJSON()
 }
+
+// validate checks v against the valid tags of its fields, and its unique fields
+// against the other {{controllerName}} models. It returns the errors found, if any.
+func (c *{{controllerName}}Controller) validate(v *models.{{controllerName}}) *ValidationErrors {
+	valid := validation.Validation{}
+	if _, err := valid.Valid(v); err != nil {
+		valid.SetError("", err.Error())
+	}
+{{uniqueChecks}}	return newValidationErrors(valid.Errors)
+}
+
+// bee:begin custom
+// bee:end
 `
@@ -0,0 +1,328 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
+)
+
+// Enum is a column restricted to a list of values, by an ENUM type or a CHECK
+// constraint, generated as a Go type with a constant per value
+type Enum struct {
+	Name   string
+	Values []string
+	Source string // SQL definition the values come from
+	Schema string // schema of the PostgreSQL enum type the values come from
+}
+
+var (
+	sqlStringRegex = regexp.MustCompile(`'((?:[^']|'')*)'`)
+	// CHECK (status IN ('a', 'b')) in a SQLite table definition
+	sqliteCheckInRegex = regexp.MustCompile("(?is)CHECK\\s*\\(\\s*[\"`\\[]?(\\w+)[\"`\\]]?\\s+IN\\s*\\(([^)]*)\\)\\s*\\)")
+	// CHECK (((status)::text = ANY ((ARRAY['a'::character varying, ...])::text[]))) as
+	// PostgreSQL returns CHECK (status IN ('a', 'b'))
+	postgresCheckAnyRegex = regexp.MustCompile(`(?s)^CHECK \(+"?(\w+)"?\)?(?:::\w+(?: \w+)*)? = ANY \(+ARRAY\[([^\]]*)\]`)
+)
+
+// setEnum makes the type of a column an enum of the given values
+func (col *Column) setEnum(name string, values []string, source string) {
+	col.Enum = &Enum{Name: name, Values: values, Source: source}
+	col.Type = name
+}
+
+// renameEnum renames the enum of a column
+func (col *Column) renameEnum(name string) {
+	col.Enum.Name = name
+	col.Type = name
+}
+
+// mysqlEnumValues returns the values of a MySQL enum('a','b') column type
+func mysqlEnumValues(columnType string) []string {
+	if !strings.HasPrefix(columnType, "enum(") || !strings.HasSuffix(columnType, ")") {
+		return nil
+	}
+	return sqlStrings(columnType[len("enum(") : len(columnType)-1])
+}
+
+// postgresCheckEnum returns the column and the values of a PostgreSQL CHECK
+// constraint definition restricting a column to a list of strings
+func postgresCheckEnum(def string) (column string, values []string) {
+	m := postgresCheckAnyRegex.FindStringSubmatch(def)
+	if m == nil {
+		return "", nil
+	}
+	return m[1], sqlStrings(m[2])
+}
+
+// sqlStrings returns the values of a list of SQL string literals, or nil if
+// the list has anything else than string literals
+func sqlStrings(list string) []string {
+	var values []string
+	for _, item := range splitSQLList(list) {
+		item = strings.TrimSpace(item)
+		if i := strings.Index(item, "::"); i != -1 {
+			// drop the cast of a PostgreSQL literal
+			item = item[:i]
+		}
+		m := sqlStringRegex.FindStringSubmatch(item)
+		if m == nil || m[0] != item {
+			return nil
+		}
+		values = append(values, strings.Replace(m[1], "''", "'", -1))
+	}
+	return values
+}
+
+// splitSQLList splits a comma separated list, ignoring the commas of string literals
+func splitSQLList(list string) []string {
+	var items []string
+	quoted := false
+	start := 0
+	for i, r := range list {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			items = append(items, list[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, list[start:])
+}
+
+// sqliteCheckEnums returns the values allowed by the CHECK (column IN (...))
+// constraints of a SQLite table, by column
+func sqliteCheckEnums(db *sql.DB, table string) map[string][]string {
+	var ddl string
+	if err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&ddl); err != nil {
+		beeLogger.Log.Fatalf("Could not read the definition of '%s': %s", table, err)
+	}
+	enums := make(map[string][]string)
+	for _, m := range sqliteCheckInRegex.FindAllStringSubmatch(ddl, -1) {
+		if values := sqlStrings(m[2]); len(values) > 0 {
+			enums[m[1]] = values
+		}
+	}
+	return enums
+}
+
+// postgresCheckEnums returns the values allowed by the CHECK constraints over
+// a single column of a PostgreSQL table, by column
+func postgresCheckEnums(db *sql.DB, table *Table) map[string][]string {
+	rows, err := db.Query(
+		`SELECT
+			pg_get_constraintdef(c.oid)
+		FROM
+			pg_catalog.pg_constraint c
+		INNER JOIN
+			pg_catalog.pg_class t ON t.oid = c.conrelid
+		INNER JOIN
+			pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+		WHERE
+			c.contype = 'c' AND array_length(c.conkey, 1) = 1
+			AND t.relname = $1 AND n.nspname = COALESCE(NULLIF($2, ''), current_schema())`,
+		table.Name, table.Schema)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not query the catalog for check constraints: %s", err)
+	}
+	defer rows.Close()
+
+	enums := make(map[string][]string)
+	for rows.Next() {
+		var def string
+		if err := rows.Scan(&def); err != nil {
+			beeLogger.Log.Fatalf("Could not read the catalog for check constraints: %s", err)
+		}
+		if column, values := postgresCheckEnum(def); len(values) > 0 {
+			enums[column] = values
+		}
+	}
+	return enums
+}
+
+// postgresEnumLabels returns the values of a PostgreSQL enum type, nil if the type is not an enum
+func postgresEnumLabels(db *sql.DB, schema, name string) (labels []string) {
+	rows, err := db.Query(
+		`SELECT
+			e.enumlabel
+		FROM
+			pg_catalog.pg_enum e
+		INNER JOIN
+			pg_catalog.pg_type t ON t.oid = e.enumtypid
+		INNER JOIN
+			pg_catalog.pg_namespace n ON n.oid = t.typnamespace
+		WHERE
+			t.typname = $1 AND n.nspname = $2
+		ORDER BY
+			e.enumsortorder`,
+		name, schema)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not query the catalog for enum types: %s", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			beeLogger.Log.Fatalf("Could not read the catalog for enum types: %s", err)
+		}
+		labels = append(labels, label)
+	}
+	return
+}
+
+// renameEnums prefixes the enum types of the same name in several schemas
+// with their schema, and renames the enums named like a model
+func renameEnums(tables []*Table) {
+	schemas := make(map[string]map[string]bool)
+	for _, tb := range tables {
+		for _, col := range tb.Columns {
+			if col.Enum != nil && col.Enum.Schema != "" {
+				if schemas[col.Enum.Name] == nil {
+					schemas[col.Enum.Name] = make(map[string]bool)
+				}
+				schemas[col.Enum.Name][col.Enum.Schema] = true
+			}
+		}
+	}
+	models := make(map[string]bool)
+	for _, tb := range tables {
+		models[tb.modelName()] = true
+	}
+	for _, tb := range tables {
+		for _, col := range tb.Columns {
+			if col.Enum == nil {
+				continue
+			}
+			if len(schemas[col.Enum.Name]) > 1 {
+				col.renameEnum(utils.CamelCase(col.Enum.Schema) + col.Enum.Name)
+			}
+			if models[col.Enum.Name] {
+				col.renameEnum(col.Enum.Name + "Enum")
+			}
+		}
+	}
+}
+
+// writeEnumFiles writes a file for each enum of the columns of the tables
+func writeEnumFiles(tables []*Table, mPath string) {
+	enums := make(map[string]*Enum)
+	for _, tb := range tables {
+		for _, col := range tb.Columns {
+			if col.Enum == nil {
+				continue
+			}
+			if e, ok := enums[col.Enum.Name]; ok && strings.Join(e.Values, ",") != strings.Join(col.Enum.Values, ",") {
+				beeLogger.Log.Warnf("Enum '%s' has different values in '%s', keeping the first ones", col.Enum.Name, tb.Name)
+				continue
+			}
+			enums[col.Enum.Name] = col.Enum
+		}
+	}
+	var names []string
+	for name := range enums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		content, err := renderEnum(enums[name])
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not generate the enum '%s': %s. Rename one of the values of %s", name, err, enums[name].Source)
+		}
+		fpath := path.Join(mPath, getFileName(utils.SnakeString(name))+"_enum.go")
+		utils.WriteGeneratedFile(fpath, content, utils.MergeExisting)
+	}
+}
+
+// renderEnum returns the source code of an enum type, or an error when two of its
+// values give the same constant
+func renderEnum(e *Enum) (string, error) {
+	var consts, cases strings.Builder
+	used := make(map[string]string)
+	for i, v := range e.Values {
+		name := e.Name + enumConstName(v)
+		if other, ok := used[name]; ok {
+			return "", fmt.Errorf("the values %q and %q of %s both give the constant %s", other, v, e.Name, name)
+		}
+		used[name] = v
+		fmt.Fprintf(&consts, "\t%s %s = %q\n", name, e.Name, v)
+		if i > 0 {
+			cases.WriteString(", ")
+		}
+		cases.WriteString(name)
+	}
+	content := strings.Replace(loadTemplate("appcode/enum.go.tpl"), "{{enumName}}", e.Name, -1)
+	content = strings.Replace(content, "{{enumSource}}", e.Source, -1)
+	content = strings.Replace(content, "{{enumConsts}}", consts.String(), -1)
+	return strings.Replace(content, "{{enumValues}}", cases.String(), -1), nil
+}
+
+// enumConstName returns the suffix of the constant of an enum value, its words in
+// CamelCase like the fields of the models
+func enumConstName(value string) string {
+	words := strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "Empty"
+	}
+	return utils.CamelCase(strings.Join(words, "_"))
+}
+
+var enumTpl = `package models
+
+import "fmt"
+
+// {{enumName}} is one of the values of {{enumSource}}
+type {{enumName}} string
+
+// Values of {{enumName}}
+const (
+{{enumConsts}})
+
+// Valid tells whether v is one of the values of {{enumName}}
+func (v {{enumName}}) Valid() bool {
+	switch v {
+	case {{enumValues}}:
+		return true
+	}
+	return false
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (v {{enumName}}) MarshalText() ([]byte, error) {
+	return []byte(v), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. The empty string, the zero
+// value of a NULL column, is accepted along with the values of {{enumName}}.
+func (v *{{enumName}}) UnmarshalText(text []byte) error {
+	e := {{enumName}}(text)
+	if e != "" && !e.Valid() {
+		return fmt.Errorf("invalid {{enumName}} %q", text)
+	}
+	*v = e
+	return nil
+}
+`
@@ -0,0 +1,885 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/v2/server/web"
+	"gopkg.in/yaml.v2"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
+)
+
+// specReader reads the models and the operations of a Swagger 2.0 or OpenAPI 3 specification
+type specReader struct {
+	doc     *jsonObject
+	openAPI bool
+	names   map[string]bool   // names of the structs of the models package
+	refs    map[string]string // structs of the definitions, by reference
+}
+
+// specController is a controller serving the operations of a path prefix
+type specController struct {
+	Name        string
+	Prefix      string
+	Description string
+	Operations  []*specOperation
+	inf         *structInferrer // request and response structs of the operations
+}
+
+// specOperation is an operation of the specification, served by a controller method
+type specOperation struct {
+	Name        string
+	Method      string
+	Route       string
+	Summary     string
+	Description string
+	Tags        []string // tags of the specification, when bee generate docs would derive others
+	Params      []*specParam
+	Responses   []*specResponse
+}
+
+// specParam is a parameter of an operation
+type specParam struct {
+	Name        string
+	Arg         string // argument of the controller method, empty for files
+	In          string
+	Type        string // type of the @Param annotation
+	GoType      string
+	Default     string
+	Required    bool
+	Description string
+}
+
+// specResponse is a response of an operation
+type specResponse struct {
+	Code        string
+	Kind        string // {object} or {array}, empty when the response has no body
+	Type        string // type of the @Success or @Failure annotation
+	GoType      string
+	Description string
+}
+
+var specMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+var pathParamRegex = regexp.MustCompile(`\{([^}]+)\}`)
+
+// GenerateFromSpec generates the models, the annotated controllers and the router of
+// the API described by a Swagger 2.0 or OpenAPI 3 specification, in JSON or YAML,
+// so that bee generate docs gives back an equivalent specification.
+// Existing files are kept.
+func GenerateFromSpec(specFile, currpath string) {
+	if specFile == "" {
+		beeLogger.Log.Hint("Give the specification to generate from, i.e. -spec=openapi.yaml")
+		beeLogger.Log.Fatal("Wrong number of arguments. Run: bee help generate")
+	}
+	beeLogger.Log.Infof("Using '%s' as specification", specFile)
+	r := &specReader{
+		doc:   readSpecFile(specFile),
+		names: make(map[string]bool),
+		refs:  make(map[string]string),
+	}
+	r.openAPI = r.doc.Values["openapi"] != nil
+
+	for name, content := range r.models() {
+		utils.WriteGeneratedFile(path.Join(currpath, "models", utils.SnakeString(name)+".go"), content, utils.KeepExisting)
+	}
+
+	controllers := r.controllers()
+	if len(controllers) == 0 {
+		beeLogger.Log.Fatalf("'%s' has no operation", specFile)
+	}
+	pkgPath := getPackagePath(currpath)
+	for _, c := range controllers {
+		if len(c.inf.structs) > 0 {
+			utils.WriteGeneratedFile(path.Join(currpath, "models", utils.SnakeString(c.Name)+"_types.go"), renderSpecModel(c.inf), utils.KeepExisting)
+		}
+		for _, e := range c.inf.enums {
+			utils.WriteGeneratedFile(path.Join(currpath, "models", utils.SnakeString(e.Name)+".go"), renderSpecEnum(e), utils.KeepExisting)
+		}
+		utils.WriteGeneratedFile(path.Join(currpath, "controllers", utils.SnakeString(c.Name)+".go"), renderSpecController(c, pkgPath), utils.KeepExisting)
+	}
+	utils.WriteGeneratedFile(path.Join(currpath, "routers", "router.go"), r.router(controllers, pkgPath), utils.KeepExisting)
+	beeLogger.Log.Hint("Run 'bee generate routers' to register the routes of the controllers")
+}
+
+// readSpecFile reads a specification, in YAML unless the file has the .json extension
+func readSpecFile(file string) *jsonObject {
+	var doc interface{}
+	if strings.ToLower(filepath.Ext(file)) == ".json" {
+		doc = readJSONFile(file)
+	} else {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not read '%s': %s", file, err)
+		}
+		var ms yaml.MapSlice
+		if err := yaml.Unmarshal(data, &ms); err != nil {
+			beeLogger.Log.Fatalf("Could not parse '%s': %s", file, err)
+		}
+		doc = yamlValue(ms)
+	}
+	spec, ok := doc.(*jsonObject)
+	if !ok || (spec.Values["swagger"] == nil && spec.Values["openapi"] == nil) {
+		beeLogger.Log.Fatalf("'%s' is not a Swagger 2.0 or OpenAPI 3 specification", file)
+	}
+	return spec
+}
+
+// yamlValue converts a decoded YAML value to the values returned by decodeOrdered
+func yamlValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		obj := &jsonObject{Values: make(map[string]interface{})}
+		for _, item := range v {
+			// response codes are integer keys
+			k := fmt.Sprint(item.Key)
+			if _, ok := obj.Values[k]; !ok {
+				obj.Keys = append(obj.Keys, k)
+			}
+			obj.Values[k] = yamlValue(item.Value)
+		}
+		return obj
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i := range v {
+			list[i] = yamlValue(v[i])
+		}
+		return list
+	case int, int64, uint64, float64:
+		return json.Number(fmt.Sprint(v))
+	}
+	return v
+}
+
+// objectValue returns the object of a key, empty if there is none
+func objectValue(obj *jsonObject, key string) *jsonObject {
+	if v, ok := obj.Values[key].(*jsonObject); ok {
+		return v
+	}
+	return &jsonObject{Values: make(map[string]interface{})}
+}
+
+// stringValue returns the scalar of a key as a string, empty if there is none
+func stringValue(obj *jsonObject, key string) string {
+	switch v := obj.Values[key].(type) {
+	case string:
+		return v
+	case json.Number, bool:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// inferrer returns a struct inferrer of the models package of the specification
+func (r *specReader) inferrer() *structInferrer {
+	return &structInferrer{names: r.names, refs: r.refs, schema: r.doc, plain: true, spec: true}
+}
+
+// resolve follows the $ref of a parameter, request body or response
+func (r *specReader) resolve(obj *jsonObject) *jsonObject {
+	resolved, err := r.inferrer().resolveRef(obj)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read the specification: %s", err)
+	}
+	return resolved
+}
+
+// models returns the files of the object definitions, by struct name. The
+// definitions are registered first so that they reference each other by name.
+func (r *specReader) models() map[string]string {
+	defs, prefix := objectValue(r.doc, "definitions"), "#/definitions/"
+	if r.openAPI {
+		defs, prefix = objectValue(objectValue(r.doc, "components"), "schemas"), "#/components/schemas/"
+	}
+
+	var objects []string
+	for _, name := range defs.Keys {
+		schema := objectValue(defs, name)
+		// other definitions, such as lists or enums, are inlined where they are used
+		if schema.Values["properties"] == nil && schema.Values["allOf"] == nil {
+			continue
+		}
+		structName := r.inferrer().uniqueName(goIdent(name))
+		r.names[structName] = true
+		r.refs[prefix+name] = structName
+		objects = append(objects, name)
+	}
+
+	files := make(map[string]string)
+	for _, name := range objects {
+		inf := r.inferrer()
+		structName := r.refs[prefix+name]
+		if err := inf.schemaStruct(structName, objectValue(defs, name), false); err != nil {
+			beeLogger.Log.Fatalf("Could not read the definition '%s': %s", name, err)
+		}
+		files[structName] = renderSpecModel(inf)
+		for _, e := range inf.enums {
+			files[e.Name] = renderSpecEnum(e)
+		}
+	}
+	return files
+}
+
+// controllers groups the operations by the first segment of their path
+func (r *specReader) controllers() []*specController {
+	var controllers []*specController
+	byPrefix := make(map[string]*specController)
+	names := make(map[string]bool)
+
+	paths := objectValue(r.doc, "paths")
+	for _, p := range paths.Keys {
+		item := objectValue(paths, p)
+		segment := strings.SplitN(strings.Trim(p, "/"), "/", 2)[0]
+		prefix, name := "/"+segment, goIdent(segment)
+		if segment == "" || strings.Contains(segment, "{") {
+			prefix, name = "", "Default"
+		}
+
+		c, ok := byPrefix[prefix]
+		if !ok {
+			base := name
+			for i := 2; names[name]; i++ {
+				name = base + strconv.Itoa(i)
+			}
+			names[name] = true
+			c = &specController{Name: name, Prefix: prefix, inf: r.inferrer()}
+			byPrefix[prefix] = c
+			controllers = append(controllers, c)
+		}
+		for _, method := range specMethods {
+			op, ok := item.Values[method].(*jsonObject)
+			if !ok {
+				continue
+			}
+			c.Operations = append(c.Operations, r.operation(c, p, method, item, op))
+			if c.Description == "" {
+				c.Description = r.tagDescription(op)
+			}
+		}
+	}
+	return controllers
+}
+
+// tagDescription returns the description of the first tag of an operation
+func (r *specReader) tagDescription(op *jsonObject) string {
+	tags, _ := op.Values["tags"].([]interface{})
+	if len(tags) == 0 {
+		return ""
+	}
+	list, _ := r.doc.Values["tags"].([]interface{})
+	for _, t := range list {
+		if t, ok := t.(*jsonObject); ok && stringValue(t, "name") == fmt.Sprint(tags[0]) {
+			return oneLine(stringValue(t, "description"))
+		}
+	}
+	return ""
+}
+
+// operation reads an operation of a path, along with the parameters of the path
+func (r *specReader) operation(c *specController, p, method string, item, op *jsonObject) *specOperation {
+	o := &specOperation{
+		Method:      method,
+		Summary:     oneLine(stringValue(op, "summary")),
+		Description: strings.TrimSpace(stringValue(op, "description")),
+	}
+
+	name := goIdent(stringValue(op, "operationId"))
+	if name == "" {
+		name = goIdent(method)
+		for _, segment := range strings.Split(p, "/") {
+			if m := pathParamRegex.FindStringSubmatch(segment); m != nil {
+				name += "By" + goIdent(m[1])
+			} else {
+				name += goIdent(segment)
+			}
+		}
+	}
+	// methods of beego.Controller can not be redefined with other arguments
+	if _, ok := reflect.TypeOf(&web.Controller{}).MethodByName(name); ok {
+		name += "Handler"
+	}
+	o.Name = name
+	for i := 2; c.hasOperation(o.Name); i++ {
+		o.Name = name + strconv.Itoa(i)
+	}
+
+	// bee generate docs tags the operations with the path prefix of their controller
+	tags, _ := op.Values["tags"].([]interface{})
+	for _, t := range tags {
+		o.Tags = append(o.Tags, fmt.Sprint(t))
+	}
+	if len(o.Tags) == 1 && c.Prefix != "" && o.Tags[0] == strings.Trim(c.Prefix, "/") {
+		o.Tags = nil
+	}
+
+	route := strings.TrimPrefix(p, c.Prefix)
+	if route == "" {
+		route = "/"
+	}
+	o.Route = pathParamRegex.ReplaceAllStringFunc(route, func(s string) string {
+		return ":" + argName(s[1:len(s)-1])
+	})
+
+	// parameters of the operation override the ones of the path
+	var params []*jsonObject
+	seen := make(map[string]bool)
+	for _, list := range []interface{}{op.Values["parameters"], item.Values["parameters"]} {
+		list, _ := list.([]interface{})
+		for _, param := range list {
+			param, ok := param.(*jsonObject)
+			if !ok {
+				continue
+			}
+			param = r.resolve(param)
+			key := stringValue(param, "in") + " " + stringValue(param, "name")
+			if !seen[key] {
+				seen[key] = true
+				params = append(params, param)
+			}
+		}
+	}
+	for _, param := range params {
+		if p := r.param(c, o, param); p != nil {
+			o.addParam(p)
+		}
+	}
+	if body, ok := op.Values["requestBody"].(*jsonObject); ok {
+		r.requestBody(c, o, r.resolve(body))
+	}
+	r.responses(c, o, objectValue(op, "responses"))
+	return o
+}
+
+// param reads a parameter other than an OpenAPI 3 request body
+func (r *specReader) param(c *specController, o *specOperation, param *jsonObject) *specParam {
+	p := &specParam{
+		Name:        stringValue(param, "name"),
+		In:          stringValue(param, "in"),
+		Required:    param.Values["required"] == true,
+		Description: oneLine(stringValue(param, "description")),
+	}
+	// Swagger 2.0 describes the type in the parameter, OpenAPI 3 in its schema
+	schema := param
+	if s, ok := param.Values["schema"].(*jsonObject); ok {
+		schema = s
+	}
+
+	switch p.In {
+	case "cookie":
+		beeLogger.Log.Warnf("Skipping cookie parameter '%s' of '%s': beego does not read parameters from cookies", p.Name, o.Name)
+		return nil
+	case "body":
+		p.Type, p.GoType = r.bodyType(c, o.Name+"Request", schema)
+		p.Arg = argName(p.Name)
+		return p
+	case "path":
+		// beego reads the path parameter of the name of the argument
+		p.Name = argName(p.Name)
+	}
+
+	if stringValue(schema, "type") == "file" || stringValue(schema, "format") == "binary" {
+		p.Type = "file"
+		return p
+	}
+	p.Arg = argName(p.Name)
+	p.Type, p.GoType = r.paramType(c, o.Name+goIdent(p.Name), schema)
+	switch d := schema.Values["default"].(type) {
+	case string, json.Number, bool:
+		if s := fmt.Sprint(d); s != "" && !strings.ContainsAny(s, " \t\"") {
+			p.Default = s
+		}
+	}
+	return p
+}
+
+// requestBody reads the request body of an OpenAPI 3 operation, as a body
+// parameter or as form parameters
+func (r *specReader) requestBody(c *specController, o *specOperation, body *jsonObject) {
+	content := objectValue(body, "content")
+	for _, media := range []string{"application/x-www-form-urlencoded", "multipart/form-data"} {
+		if _, ok := content.Values[media]; !ok || len(content.Keys) > 1 {
+			continue
+		}
+		schema := r.resolve(objectValue(objectValue(content, media), "schema"))
+		props, required, err := c.inf.schemaProperties(schema)
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not read the request body of '%s': %s", o.Name, err)
+		}
+		for _, key := range props.Keys {
+			prop := objectValue(props, key)
+			param := &jsonObject{
+				Keys: []string{"name", "in", "required", "description", "schema"},
+				Values: map[string]interface{}{
+					"name":        key,
+					"in":          "formData",
+					"required":    required[key],
+					"description": prop.Values["description"],
+					"schema":      prop,
+				},
+			}
+			if p := r.param(c, o, param); p != nil {
+				o.addParam(p)
+			}
+		}
+		return
+	}
+
+	schema := mediaSchema(content)
+	if schema == nil {
+		return
+	}
+	p := &specParam{
+		Name:        "body",
+		Arg:         "body",
+		In:          "body",
+		Required:    body.Values["required"] == true,
+		Description: oneLine(stringValue(body, "description")),
+	}
+	p.Type, p.GoType = r.bodyType(c, o.Name+"Request", schema)
+	o.addParam(p)
+}
+
+// responses reads the responses of an operation along with their schema
+func (r *specReader) responses(c *specController, o *specOperation, responses *jsonObject) {
+	for _, code := range responses.Keys {
+		resp := r.resolve(objectValue(responses, code))
+		res := &specResponse{Code: code, Description: oneLine(stringValue(resp, "description"))}
+		if res.Description == "" {
+			status, _ := strconv.Atoi(code)
+			res.Description = http.StatusText(status)
+		}
+		o.Responses = append(o.Responses, res)
+
+		schema, _ := resp.Values["schema"].(*jsonObject)
+		if r.openAPI {
+			schema = mediaSchema(objectValue(resp, "content"))
+		}
+		if schema == nil {
+			continue
+		}
+		name := o.Name + "Response"
+		if !strings.HasPrefix(code, "2") {
+			name = o.Name + goIdent(code) + "Error"
+		}
+		typ, _, _, err := c.inf.schemaType(name, schema)
+		if err != nil {
+			beeLogger.Log.Fatalf("Could not read the response %s of '%s': %s", code, o.Name, err)
+		}
+		res.GoType = qualifyType(typ)
+		res.Kind, res.Type = "{object}", res.GoType
+		if strings.HasPrefix(res.GoType, "[]") {
+			res.Kind, res.Type = "{array}", res.GoType[2:]
+		}
+		if !strings.HasPrefix(res.Type, "models.") && !isBasicType(res.Type) {
+			// documented as a free form object
+			res.Kind, res.Type = "{object}", "json.RawMessage"
+		}
+	}
+}
+
+// paramType returns the annotation type and the Go type of a path, query, header or form parameter
+func (r *specReader) paramType(c *specController, name string, schema *jsonObject) (string, string) {
+	schema = r.resolve(schema)
+	if schemaTypes(schema)["object"] || schema.Values["properties"] != nil {
+		beeLogger.Log.Warnf("Object parameter '%s' is read as a string", name)
+		return "string", "string"
+	}
+	typ, _, _, err := c.inf.schemaType(name, schema)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read the parameter '%s': %s", name, err)
+	}
+	elem := strings.TrimPrefix(typ, "[]")
+	switch {
+	case elem == "time.Time":
+		// the swagger type of time.Time is parsed as a model by bee generate docs
+		return strings.Replace(typ, "time.Time", "string", 1), typ
+	case !isBasicType(elem) || strings.HasPrefix(elem, "[]"):
+		beeLogger.Log.Warnf("Parameter '%s' of type '%s' is read as a string", name, typ)
+		return "string", "string"
+	}
+	return typ, typ
+}
+
+// bodyType returns the annotation type and the Go type of a request body
+func (r *specReader) bodyType(c *specController, name string, schema *jsonObject) (string, string) {
+	typ, _, _, err := c.inf.schemaType(name, schema)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read the body of '%s': %s", name, err)
+	}
+	typ = qualifyType(typ)
+	elem := strings.TrimPrefix(typ, "[]")
+	switch {
+	case strings.HasPrefix(typ, "models."):
+		// struct bodies are decoded from JSON into a pointer
+		return typ, "*" + typ
+	case strings.HasPrefix(elem, "models."), isBasicType(elem) && elem != "time.Time":
+		return typ, typ
+	}
+	beeLogger.Log.Warnf("Body of '%s' of type '%s' is read as a string", name, typ)
+	return "string", "string"
+}
+
+// router renders the router file, documenting the API in the comments of bee generate docs
+func (r *specReader) router(controllers []*specController, pkgPath string) string {
+	var info strings.Builder
+	infos := objectValue(r.doc, "info")
+	contact, license := objectValue(infos, "contact"), objectValue(infos, "license")
+	for _, a := range []struct{ annotation, value string }{
+		{"@APIVersion", stringValue(infos, "version")},
+		{"@Title", oneLine(stringValue(infos, "title"))},
+		{"@Description", strings.TrimSpace(stringValue(infos, "description"))},
+		{"@Contact", stringValue(contact, "email")},
+		{"@Name", stringValue(contact, "name")},
+		{"@URL", stringValue(contact, "url")},
+		{"@TermsOfServiceUrl", stringValue(infos, "termsOfService")},
+		{"@License", stringValue(license, "name")},
+		{"@LicenseUrl", stringValue(license, "url")},
+	} {
+		if a.value == "" {
+			continue
+		}
+		for _, line := range strings.Split(a.value, "\n") {
+			fmt.Fprintf(&info, "// %s %s\n", a.annotation, strings.TrimSpace(line))
+		}
+	}
+
+	basePath, host := stringValue(r.doc, "basePath"), stringValue(r.doc, "host")
+	var schemes []string
+	if list, ok := r.doc.Values["schemes"].([]interface{}); ok {
+		for _, s := range list {
+			schemes = append(schemes, fmt.Sprint(s))
+		}
+	}
+	if servers, ok := r.doc.Values["servers"].([]interface{}); ok && len(servers) > 0 {
+		if server, ok := servers[0].(*jsonObject); ok {
+			if u, err := url.Parse(stringValue(server, "url")); err == nil {
+				basePath, host = u.Path, u.Host
+				if u.Scheme != "" {
+					schemes = []string{u.Scheme}
+				}
+			}
+		}
+	}
+	if len(schemes) > 0 {
+		fmt.Fprintf(&info, "// @Schemes %s\n", strings.Join(schemes, ","))
+	}
+	if host != "" {
+		fmt.Fprintf(&info, "// @Host %s\n", host)
+	}
+
+	var nameSpaces strings.Builder
+	for _, c := range controllers {
+		if c.Prefix == "" {
+			fmt.Fprintf(&nameSpaces, "\n\t\tbeego.NSInclude(\n\t\t\t&controllers.%sController{},\n\t\t),", c.Name)
+			continue
+		}
+		fmt.Fprintf(&nameSpaces, "\n\t\tbeego.NSNamespace(%q,\n\t\t\tbeego.NSInclude(\n\t\t\t\t&controllers.%sController{},\n\t\t\t),\n\t\t),", c.Prefix, c.Name)
+	}
+
+	return strings.NewReplacer(
+		"{{info}}", info.String(),
+		"{{pkgPath}}", pkgPath,
+		"{{basePath}}", strings.TrimSuffix(basePath, "/"),
+		"{{nameSpaces}}", nameSpaces.String(),
+	).Replace(loadTemplate("fromspec/router.go.tpl"))
+}
+
+func (c *specController) hasOperation(name string) bool {
+	for _, o := range c.Operations {
+		if o.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addParam adds a parameter, renaming its argument if another parameter has the same
+func (o *specOperation) addParam(p *specParam) {
+	if p.Arg != "" {
+		arg := p.Arg
+		for i := 2; o.hasArg(p.Arg); i++ {
+			p.Arg = arg + strconv.Itoa(i)
+		}
+	}
+	o.Params = append(o.Params, p)
+}
+
+func (o *specOperation) hasArg(arg string) bool {
+	for _, p := range o.Params {
+		if p.Arg == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaSchema returns the schema of the JSON content of a request body or response,
+// or of its only content
+func mediaSchema(content *jsonObject) *jsonObject {
+	for _, media := range content.Keys {
+		if strings.Contains(media, "json") || len(content.Keys) == 1 {
+			if schema, ok := objectValue(content, media).Values["schema"].(*jsonObject); ok {
+				return schema
+			}
+		}
+	}
+	return nil
+}
+
+// renderSpecModel renders the file of the structs of an inferrer
+func renderSpecModel(inf *structInferrer) string {
+	importTimePkg := ""
+	if inf.hasTime {
+		importTimePkg = "import \"time\"\n"
+	}
+	return strings.NewReplacer(
+		"{{modelStruct}}", inf.String(),
+		"{{importTimePkg}}", importTimePkg,
+	).Replace(loadTemplate("fromspec/model.go.tpl"))
+}
+
+// renderSpecEnum renders an enum of the specification
+func renderSpecEnum(e *Enum) string {
+	content, err := renderEnum(e)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not generate the enum '%s': %s. Rename one of the values in the specification", e.Name, err)
+	}
+	return content
+}
+
+// renderSpecController renders a controller with a method per operation,
+// annotated for bee generate routers and bee generate docs
+func renderSpecController(c *specController, pkgPath string) string {
+	var methods strings.Builder
+	usesModels, usesTime := false, false
+	uses := func(typ string) {
+		usesModels = usesModels || strings.Contains(typ, "models.")
+		usesTime = usesTime || strings.Contains(typ, "time.")
+	}
+
+	for _, o := range c.Operations {
+		summary := o.Summary
+		if summary == "" {
+			summary = "..."
+		}
+		fmt.Fprintf(&methods, "\n// %s %s\n// @Title %s\n", o.Name, summary, o.Name)
+		if o.Summary != "" {
+			fmt.Fprintf(&methods, "// @Summary %s\n", o.Summary)
+		}
+		for _, line := range strings.Split(o.Description, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(&methods, "// @Description %s\n", line)
+			}
+		}
+		if len(o.Tags) > 0 {
+			fmt.Fprintf(&methods, "// @Tags %s\n", strings.Join(o.Tags, ","))
+		}
+
+		var args []string
+		for _, p := range o.Params {
+			name := p.Name
+			if p.Arg != "" && p.Arg != p.Name {
+				name += "=>" + p.Arg
+			}
+			description := p.Description
+			if description == "" {
+				description = p.Name
+			}
+			if p.Default != "" {
+				fmt.Fprintf(&methods, "// @Param\t%s\t%s\t%s\t%s\t%t\t\"%s\"\n", name, p.In, p.Type, p.Default, p.Required, description)
+			} else {
+				fmt.Fprintf(&methods, "// @Param\t%s\t%s\t%s\t%t\t\"%s\"\n", name, p.In, p.Type, p.Required, description)
+			}
+			if p.Arg != "" {
+				args = append(args, p.Arg+" "+p.GoType)
+				uses(p.GoType)
+			}
+		}
+
+		var success *specResponse
+		for _, res := range o.Responses {
+			if !strings.HasPrefix(res.Code, "2") {
+				if res.Kind != "" {
+					fmt.Fprintf(&methods, "// @Failure %s %s %s %s\n", res.Code, res.Kind, res.Type, res.Description)
+				} else {
+					fmt.Fprintf(&methods, "// @Failure %s %s\n", res.Code, res.Description)
+				}
+				continue
+			}
+			if success == nil {
+				success = res
+			}
+			if res.Kind != "" {
+				fmt.Fprintf(&methods, "// @Success %s %s %s %s\n", res.Code, res.Kind, res.Type, res.Description)
+			} else {
+				fmt.Fprintf(&methods, "// @Success %s %s\n", res.Code, res.Description)
+			}
+		}
+		fmt.Fprintf(&methods, "// @router %s [%s]\n", o.Route, o.Method)
+
+		fmt.Fprintf(&methods, "func (c *%sController) %s(%s) {\n\t// TODO: implement\n", c.Name, o.Name, strings.Join(args, ", "))
+		if success != nil && success.Code != "200" {
+			if _, err := strconv.Atoi(success.Code); err == nil {
+				fmt.Fprintf(&methods, "\tc.Ctx.Output.SetStatus(%s)\n", success.Code)
+			}
+		}
+		if success != nil && success.GoType != "" {
+			fmt.Fprintf(&methods, "\tc.Data[\"json\"] = %s\n\tc.ServeJSON()\n", zeroValue(success.GoType))
+			uses(success.GoType)
+		}
+		methods.WriteString("}\n")
+	}
+
+	var imports string
+	if usesTime {
+		imports += "\n\t\"time\"\n"
+	}
+	if usesModels {
+		imports += "\n\t\"" + pkgPath + "/models\"\n"
+	}
+	description := c.Description
+	if description == "" {
+		description = "operations for " + c.Name
+	}
+	return strings.NewReplacer(
+		"{{imports}}", imports,
+		"{{controllerName}}", c.Name,
+		"{{description}}", description,
+		"{{methods}}", methods.String(),
+	).Replace(loadTemplate("fromspec/controller.go.tpl"))
+}
+
+// qualifyType prefixes the structs of a Go type with the models package
+func qualifyType(typ string) string {
+	elem := strings.TrimLeft(typ, "[]*")
+	if elem != "" && elem[0] >= 'A' && elem[0] <= 'Z' {
+		return typ[:len(typ)-len(elem)] + "models." + elem
+	}
+	return typ
+}
+
+// isBasicType reports whether a Go type is documented as such by bee generate docs
+func isBasicType(typ string) bool {
+	switch typ {
+	case "bool", "string", "int32", "int64", "float32", "float64", "time.Time":
+		return true
+	}
+	return false
+}
+
+// zeroValue returns the expression of an empty value of a Go type
+func zeroValue(typ string) string {
+	switch {
+	case strings.HasPrefix(typ, "models."):
+		return "&" + typ + "{}"
+	case strings.HasPrefix(typ, "[]"), strings.HasPrefix(typ, "map["), typ == "time.Time":
+		return typ + "{}"
+	case typ == "string":
+		return `""`
+	case typ == "bool":
+		return "false"
+	case typ == "interface{}":
+		return "nil"
+	}
+	return "0"
+}
+
+// goIdent turns a name of the specification into an exported Go identifier,
+// i.e. listPets into ListPets and X-Request-ID into XRequestID
+func goIdent(s string) string {
+	var b strings.Builder
+	for _, part := range nonIdentRegex.Split(s, -1) {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	ident := b.String()
+	if ident != "" && ident[0] >= '0' && ident[0] <= '9' {
+		ident = "X" + ident
+	}
+	return ident
+}
+
+// argName turns a parameter name into the name of a method argument,
+// i.e. X-Request-ID into xRequestID
+func argName(s string) string {
+	ident := goIdent(s)
+	upper := 0
+	for upper < len(ident) && ident[upper] >= 'A' && ident[upper] <= 'Z' {
+		upper++
+	}
+	switch {
+	case upper == len(ident):
+		ident = strings.ToLower(ident)
+	case upper > 1 && ident[upper] >= 'a' && ident[upper] <= 'z':
+		ident = strings.ToLower(ident[:upper-1]) + ident[upper-1:]
+	default:
+		ident = strings.ToLower(ident[:1]) + ident[1:]
+	}
+	// the receiver and the imported packages are not shadowed
+	switch {
+	case ident == "":
+		return "param"
+	case token.Lookup(ident).IsKeyword(), ident == "c", ident == "models", ident == "time", ident == "beego":
+		return ident + "Param"
+	}
+	return ident
+}
+
+// oneLine joins the lines of a text, for annotations taking a single line
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(strings.Replace(s, `"`, "'", -1)), " ")
+}
+
+var specControllerTpl = `package controllers
+
+import ({{imports}}
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+// {{controllerName}}Controller {{description}}
+type {{controllerName}}Controller struct {
+	beego.Controller
+}
+{{methods}}`
+
+var specRouterTpl = `{{info}}package routers
+
+import (
+	"{{pkgPath}}/controllers"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+func init() {
+	ns := beego.NewNamespace("{{basePath}}",{{nameSpaces}}
+	)
+	beego.AddNamespace(ns)
+}
+`
@@ -0,0 +1,230 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/beego/bee/v2/generate/swaggergen"
+	"github.com/beego/beego/v2/server/web/swagger"
+)
+
+func TestSpecController(t *testing.T) {
+	doc := decodeTestJSON(t, `{
+		"swagger": "2.0",
+		"paths": {
+			"/pets/{pet-id}": {
+				"get": {
+					"operationId": "showPet",
+					"parameters": [
+						{"name": "pet-id", "in": "path", "required": true, "type": "integer", "format": "int64"},
+						{"name": "X-Trace", "in": "header", "type": "string", "description": "trace id"}
+					],
+					"responses": {
+						"200": {"description": "the pet", "schema": {"$ref": "#/definitions/Pet"}},
+						"404": {"description": "not found"}
+					}
+				}
+			}
+		},
+		"definitions": {"Pet": {"type": "object", "properties": {"name": {"type": "string"}}}}
+	}`)
+	r := &specReader{doc: doc.(*jsonObject), names: make(map[string]bool), refs: make(map[string]string)}
+	r.models()
+	controllers := r.controllers()
+	if len(controllers) != 1 || controllers[0].Name != "Pets" || controllers[0].Prefix != "/pets" {
+		t.Fatalf("unexpected controllers: %+v", controllers)
+	}
+
+	got := renderSpecController(controllers[0], "app")
+	for _, expected := range []string{
+		"// @Param\tpetId\tpath\tint64\ttrue\t\"petId\"\n",
+		"// @Param\tX-Trace=>xTrace\theader\tstring\tfalse\t\"trace id\"\n",
+		"// @Success 200 {object} models.Pet the pet\n",
+		"// @Failure 404 not found\n",
+		"// @router /:petId [get]\n",
+		"func (c *PetsController) ShowPet(petId int64, xTrace string) {\n",
+		"\tc.Data[\"json\"] = &models.Pet{}\n",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected %q in:\n%s", expected, got)
+		}
+	}
+}
+
+// operationKeys returns the operations of the paths of a swagger document, as METHOD /path
+func operationKeys(paths map[string]*swagger.Item) []string {
+	var keys []string
+	for p, item := range paths {
+		for method, op := range map[string]*swagger.Operation{
+			"GET": item.Get, "POST": item.Post, "PUT": item.Put, "PATCH": item.Patch,
+			"DELETE": item.Delete, "HEAD": item.Head, "OPTIONS": item.Options,
+		} {
+			if op != nil {
+				keys = append(keys, method+" "+p)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestSpecRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("resolves the generated packages")
+	}
+	doc := decodeTestJSON(t, `{
+		"swagger": "2.0",
+		"basePath": "/v1",
+		"paths": {
+			"/pets": {
+				"get": {"operationId": "listPets", "tags": ["pet"], "responses": {"200": {"description": "the pets", "schema": {"type": "array", "items": {"$ref": "#/definitions/Pet"}}}}},
+				"post": {
+					"operationId": "createPet",
+					"tags": ["pet"],
+					"parameters": [{"name": "body", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Pet"}}],
+					"responses": {"201": {"description": "created"}}
+				}
+			},
+			"/pets/{petId}": {
+				"parameters": [{"name": "petId", "in": "path", "required": true, "type": "integer", "format": "int64"}],
+				"get": {
+					"operationId": "showPet",
+					"tags": ["pet"],
+					"responses": {
+						"200": {"description": "the pet", "schema": {"$ref": "#/definitions/Pet"}},
+						"404": {"description": "not found", "schema": {"$ref": "#/definitions/Error"}}
+					}
+				},
+				"delete": {"operationId": "deletePet", "responses": {"204": {"description": "deleted"}}}
+			},
+			"/stores/{storeId}/orders": {
+				"get": {
+					"parameters": [{"name": "storeId", "in": "path", "required": true, "type": "string"}],
+					"responses": {"200": {"description": "the orders"}}
+				}
+			},
+			"/health": {
+				"get": {"responses": {"200": {"description": "ok"}}}
+			}
+		},
+		"tags": [{"name": "pet", "description": "the pets"}],
+		"definitions": {
+			"Pet": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {"id": {"type": "integer", "format": "int64"}, "name": {"type": "string"}, "status": {"type": "string", "enum": ["available", "sold"]}}
+			},
+			"Error": {"type": "object", "properties": {"message": {"type": "string"}}}
+		}
+	}`)
+
+	// generate inside the module, for the packages of the application to resolve
+	dir, err := ioutil.TempDir(".", "spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pkgPath := "github.com/beego/bee/v2/generate/" + filepath.Base(dir)
+
+	r := &specReader{doc: doc.(*jsonObject), names: make(map[string]bool), refs: make(map[string]string)}
+	files := make(map[string]string)
+	for name, content := range r.models() {
+		files["models/"+name+".go"] = content
+	}
+	controllers := r.controllers()
+	for _, c := range controllers {
+		if len(c.inf.structs) > 0 {
+			files["models/"+c.Name+"_types.go"] = renderSpecModel(c.inf)
+		}
+		for _, e := range c.inf.enums {
+			files["models/"+e.Name+".go"] = renderSpecEnum(e)
+		}
+		files["controllers/"+c.Name+".go"] = renderSpecController(c, pkgPath)
+	}
+	files["routers/router.go"] = r.router(controllers, pkgPath)
+	writeTestFiles(t, dir, files)
+
+	docs := swaggergen.ParseDocs(dir)
+	if docs.BasePath != "/v1" {
+		t.Errorf("unexpected base path %s", docs.BasePath)
+	}
+	expected := []string{
+		"DELETE /pets/{petId}",
+		"GET /health",
+		"GET /pets",
+		"GET /pets/{petId}",
+		"GET /stores/{storeId}/orders",
+		"POST /pets",
+	}
+	if got := operationKeys(docs.Paths); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected operations:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	show := docs.Paths["/pets/{petId}"].Get
+	if show == nil {
+		t.Fatal("GET /pets/{petId} is not documented")
+	}
+	if s := show.Responses["404"].Schema; s == nil || s.Ref != "#/definitions/models.Error" {
+		t.Errorf("unexpected 404 schema %+v", s)
+	}
+	if strings.Join(show.Tags, ",") != "pet" {
+		t.Errorf("unexpected tags %v", show.Tags)
+	}
+	if len(docs.Tags) == 0 || docs.Tags[0].Name != "pet" {
+		t.Errorf("unexpected root tags %+v", docs.Tags)
+	}
+
+	pet, ok := docs.Definitions["models.Pet"]
+	if !ok {
+		t.Fatalf("models.Pet is not documented, got %v", definitionNames(docs.Definitions))
+	}
+	if strings.Join(pet.Required, ",") != "name" {
+		t.Errorf("unexpected required properties %v", pet.Required)
+	}
+	var props []string
+	for name := range pet.Properties {
+		props = append(props, name)
+	}
+	sort.Strings(props)
+	if strings.Join(props, ",") != "id,name,status" {
+		t.Errorf("unexpected properties %v", props)
+	}
+	status, ok := docs.Definitions["models.PetStatus"]
+	if !ok || pet.Properties["status"].Ref != "#/definitions/models.PetStatus" {
+		t.Fatalf("the status enum is not documented, got %v", definitionNames(docs.Definitions))
+	}
+	if fmt.Sprint(status.Enum) != `[PetStatusAvailable = "available" PetStatusSold = "sold"]` {
+		t.Errorf("unexpected status values %v", status.Enum)
+	}
+	if _, ok := docs.Definitions["models.Error"]; !ok {
+		t.Errorf("models.Error is not documented, got %v", definitionNames(docs.Definitions))
+	}
+}
+
+func definitionNames(defs map[string]swagger.Schema) []string {
+	var names []string
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -16,13 +16,11 @@ package generate
 
 import (
 	"fmt"
-	"os"
 	"path"
 	"strings"
 	"time"
 
-	"github.com/beego/bee/v2/logger"
-	"github.com/beego/bee/v2/logger/colors"
+	beeLogger "github.com/beego/bee/v2/logger"
 	"github.com/beego/bee/v2/utils"
 )
 
@@ -35,6 +33,7 @@ const (
 type DBDriver interface {
 	GenerateCreateUp(tableName string) string
 	GenerateCreateDown(tableName string) string
+	GenerateAlter(tableName string) (upsql, downsql string)
 }
 
 type mysqlDriver struct{}
@@ -49,6 +48,11 @@ func (m mysqlDriver) GenerateCreateDown(tableName string) string {
 	return downsql
 }
 
+func (m mysqlDriver) GenerateAlter(tableName string) (upsql, downsql string) {
+	up, down := generateAlterSQL(m, m.getSQLType, tableName)
+	return renderSQLCalls(up), renderSQLCalls(down)
+}
+
 func (m mysqlDriver) generateSQLFromFields(fields string) string {
 	sql, tags := "", ""
 	fds := strings.Split(fields, ",")
@@ -106,6 +110,154 @@ func (m mysqlDriver) getSQLType(ktype string) (tp, tag string) {
 	return "", ""
 }
 
+// quote quotes an identifier
+func (m mysqlDriver) quote(name string) string {
+	return "`" + name + "`"
+}
+
+// columnType maps a model field to a MySQL column type, the one orm.RunSyncdb creates
+func (m mysqlDriver) columnType(col *modelColumn) string {
+	tag := col.Tag
+	if tag.Decimals != "" {
+		return "decimal(" + tag.Digits + "," + tag.Decimals + ")"
+	}
+	switch col.GoType {
+	case "bool":
+		return "tinyint(1)"
+	case "string":
+		switch tag.Type {
+		case "text", "longtext":
+			return "longtext"
+		case "char":
+			return "char(" + sizeOr(tag.Size, "255") + ")"
+		case "json", "jsonb":
+			return "json"
+		}
+		return "varchar(" + sizeOr(tag.Size, "255") + ")"
+	case "time.Time":
+		switch tag.Type {
+		case "date", "time", "timestamp":
+			return tag.Type
+		}
+		return "datetime"
+	case "int8":
+		return "tinyint"
+	case "int16":
+		return "smallint"
+	case "int", "int32":
+		return "integer"
+	case "int64":
+		return "bigint"
+	case "uint8":
+		return "tinyint unsigned"
+	case "uint16":
+		return "smallint unsigned"
+	case "uint", "uint32":
+		return "integer unsigned"
+	case "uint64":
+		return "bigint unsigned"
+	case "float32", "float64":
+		return "double precision"
+	}
+	return ""
+}
+
+// columnDefinition renders a column type with its nullability and default value
+func (m mysqlDriver) columnDefinition(typ string, null bool, dflt string) string {
+	def := typ + " NOT NULL"
+	if null {
+		def = typ + " NULL"
+	}
+	if dflt != "" {
+		def += " DEFAULT " + dflt
+	}
+	return def
+}
+
+func (m mysqlDriver) createTableSQL(tb *modelTable) string {
+	var defs []string
+	for _, col := range tb.Columns {
+		def := m.quote(col.Name) + " " + m.columnType(col)
+		if col.Name == tb.Pk {
+			def += " NOT NULL"
+			if col.Tag.Auto {
+				def += " AUTO_INCREMENT"
+			}
+		} else {
+			def = m.quote(col.Name) + " " + m.columnDefinition(m.columnType(col), col.Tag.Null, sqlDefault(col))
+		}
+		defs = append(defs, def)
+	}
+	if tb.Pk != "" {
+		defs = append(defs, "PRIMARY KEY ("+m.quote(tb.Pk)+")")
+	}
+	return "CREATE TABLE " + m.quote(tb.Name) + " (" + strings.Join(defs, ", ") + ") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+}
+
+func (m mysqlDriver) dropTableSQL(table string) string {
+	return "DROP TABLE " + m.quote(table)
+}
+
+func (m mysqlDriver) addColumnSQL(table, column, def string) string {
+	return "ALTER TABLE " + m.quote(table) + " ADD COLUMN " + m.quote(column) + " " + def
+}
+
+func (m mysqlDriver) dropColumnSQL(table, column string) string {
+	return "ALTER TABLE " + m.quote(table) + " DROP COLUMN " + m.quote(column)
+}
+
+func (m mysqlDriver) modifyColumnSQL(table, column, typ string, null bool) []string {
+	return []string{"ALTER TABLE " + m.quote(table) + " MODIFY COLUMN " + m.quote(column) + " " + m.columnDefinition(typ, null, "")}
+}
+
+// renameColumnSQL uses RENAME COLUMN, from MySQL 8.0 and MariaDB 10.5.2
+func (m mysqlDriver) renameColumnSQL(table, from, to string) string {
+	return "ALTER TABLE " + m.quote(table) + " RENAME COLUMN " + m.quote(from) + " TO " + m.quote(to)
+}
+
+func (m mysqlDriver) createIndexSQL(table string, idx *Index) string {
+	var cols []string
+	for _, c := range idx.Columns {
+		cols = append(cols, m.quote(c))
+	}
+	create := "CREATE INDEX "
+	if idx.Unique {
+		create = "CREATE UNIQUE INDEX "
+	}
+	return create + m.quote(idx.Name) + " ON " + m.quote(table) + " (" + strings.Join(cols, ", ") + ")"
+}
+
+func (m mysqlDriver) dropIndexSQL(table string, idx *Index) string {
+	return "DROP INDEX " + m.quote(idx.Name) + " ON " + m.quote(table)
+}
+
+// sameColumnType reports whether a rendered column type matches the type reported by MySQL.
+// Integer display widths are ignored.
+func (m mysqlDriver) sameColumnType(typ, dbType string) bool {
+	aliases := map[string]string{"integer": "int", "bool": "tinyint", "boolean": "tinyint", "numeric": "decimal", "double precision": "double"}
+	normalize := func(t string) string {
+		t = strings.ToLower(strings.TrimSpace(t))
+		base, args := splitSQLType(t)
+		if v, ok := aliases[base]; ok {
+			base = v
+		}
+		switch base {
+		case "varchar", "char", "decimal":
+			base += "(" + args + ")"
+		case "tinyint":
+			// tinyint(1) is how MySQL stores booleans
+			if args == "1" {
+				base = "tinyint(1)"
+			}
+		}
+		if strings.Contains(t, "unsigned") {
+			base += " unsigned"
+		}
+		return base
+	}
+	return normalize(typ) == normalize(dbType)
+}
+
 type postgresqlDriver struct{}
 
 func (m postgresqlDriver) GenerateCreateUp(tableName string) string {
@@ -118,6 +270,11 @@ func (m postgresqlDriver) GenerateCreateDown(tableName string) string {
 	return downsql
 }
 
+func (m postgresqlDriver) GenerateAlter(tableName string) (upsql, downsql string) {
+	up, down := generateAlterSQL(m, m.getSQLType, tableName)
+	return renderSQLCalls(up), renderSQLCalls(down)
+}
+
 func (m postgresqlDriver) generateSQLFromFields(fields string) string {
 	sql, tags := "", ""
 	fds := strings.Split(fields, ",")
@@ -174,6 +331,243 @@ func (m postgresqlDriver) getSQLType(ktype string) (tp, tag string) {
 	return "", ""
 }
 
+// quote quotes an identifier. Postgres identifiers are left bare so that
+// unquoted, case-folded names keep working.
+func (m postgresqlDriver) quote(name string) string {
+	return name
+}
+
+// columnType maps a model field to a Postgres column type, the one orm.RunSyncdb creates
+// without its CHECK constraints
+func (m postgresqlDriver) columnType(col *modelColumn) string {
+	tag := col.Tag
+	if tag.Decimals != "" {
+		return "numeric(" + tag.Digits + "," + tag.Decimals + ")"
+	}
+	switch col.GoType {
+	case "bool":
+		return "boolean"
+	case "string":
+		switch tag.Type {
+		case "text", "longtext":
+			return "text"
+		case "char":
+			return "char(" + sizeOr(tag.Size, "255") + ")"
+		case "json", "jsonb":
+			return tag.Type
+		}
+		return "varchar(" + sizeOr(tag.Size, "255") + ")"
+	case "time.Time":
+		switch tag.Type {
+		case "date", "time":
+			return tag.Type
+		}
+		return "timestamp with time zone"
+	case "int8", "int16", "uint8":
+		return "smallint"
+	case "int", "int32", "uint16":
+		return "integer"
+	case "int64", "uint", "uint32", "uint64":
+		return "bigint"
+	case "float32", "float64":
+		return "double precision"
+	}
+	return ""
+}
+
+// columnDefinition renders a column type with its nullability and default value
+func (m postgresqlDriver) columnDefinition(typ string, null bool, dflt string) string {
+	def := typ + " NOT NULL"
+	if null {
+		def = typ + " NULL"
+	}
+	if dflt != "" {
+		def += " DEFAULT " + dflt
+	}
+	return def
+}
+
+func (m postgresqlDriver) createTableSQL(tb *modelTable) string {
+	var defs []string
+	for _, col := range tb.Columns {
+		var def string
+		if col.Name == tb.Pk {
+			typ := m.columnType(col)
+			if col.Tag.Auto {
+				if typ == "integer" || typ == "smallint" {
+					typ = "serial"
+				} else {
+					typ = "bigserial"
+				}
+			}
+			def = m.quote(col.Name) + " " + typ + " NOT NULL"
+		} else {
+			def = m.quote(col.Name) + " " + m.columnDefinition(m.columnType(col), col.Tag.Null, sqlDefault(col))
+		}
+		defs = append(defs, def)
+	}
+	if tb.Pk != "" {
+		defs = append(defs, "PRIMARY KEY ("+m.quote(tb.Pk)+")")
+	}
+	return "CREATE TABLE " + m.quote(tb.Name) + " (" + strings.Join(defs, ", ") + ")"
+}
+
+func (m postgresqlDriver) dropTableSQL(table string) string {
+	return "DROP TABLE " + m.quote(table)
+}
+
+func (m postgresqlDriver) addColumnSQL(table, column, def string) string {
+	return "ALTER TABLE " + m.quote(table) + " ADD COLUMN " + m.quote(column) + " " + def
+}
+
+func (m postgresqlDriver) dropColumnSQL(table, column string) string {
+	return "ALTER TABLE " + m.quote(table) + " DROP COLUMN " + m.quote(column)
+}
+
+func (m postgresqlDriver) modifyColumnSQL(table, column, typ string, null bool) []string {
+	nullability := "SET NOT NULL"
+	if null {
+		nullability = "DROP NOT NULL"
+	}
+	return []string{
+		"ALTER TABLE " + m.quote(table) + " ALTER COLUMN " + m.quote(column) + " TYPE " + typ,
+		"ALTER TABLE " + m.quote(table) + " ALTER COLUMN " + m.quote(column) + " " + nullability,
+	}
+}
+
+func (m postgresqlDriver) renameColumnSQL(table, from, to string) string {
+	return "ALTER TABLE " + m.quote(table) + " RENAME COLUMN " + m.quote(from) + " TO " + m.quote(to)
+}
+
+func (m postgresqlDriver) createIndexSQL(table string, idx *Index) string {
+	var cols []string
+	for _, c := range idx.Columns {
+		cols = append(cols, m.quote(c))
+	}
+	create := "CREATE INDEX "
+	if idx.Unique {
+		create = "CREATE UNIQUE INDEX "
+	}
+	return create + m.quote(idx.Name) + " ON " + m.quote(table) + " (" + strings.Join(cols, ", ") + ")"
+}
+
+func (m postgresqlDriver) dropIndexSQL(table string, idx *Index) string {
+	return "DROP INDEX " + m.quote(idx.Name)
+}
+
+// sameColumnType reports whether a rendered column type matches the type reported by Postgres
+func (m postgresqlDriver) sameColumnType(typ, dbType string) bool {
+	aliases := map[string]string{
+		"varchar":     "character varying",
+		"char":        "character",
+		"int":         "integer",
+		"int4":        "integer",
+		"int8":        "bigint",
+		"serial":      "integer",
+		"bigserial":   "bigint",
+		"decimal":     "numeric",
+		"bool":        "boolean",
+		"float8":      "double precision",
+		"timestamptz": "timestamp with time zone",
+		"timestamp":   "timestamp without time zone",
+	}
+	normalize := func(t string) string {
+		base, args := splitSQLType(strings.ToLower(strings.TrimSpace(t)))
+		if v, ok := aliases[base]; ok {
+			base = v
+		}
+		if args != "" && (base == "character varying" || base == "character" || base == "numeric") {
+			base += "(" + args + ")"
+		}
+		return base
+	}
+	return normalize(typ) == normalize(dbType)
+}
+
+// HasAlterFields reports whether any of the -add, -drop, -rename, -index or -unique options is set
+func HasAlterFields() bool {
+	return AddFields != "" || DropFields != "" || RenameFields != "" || IndexFields != "" || UniqueFields != ""
+}
+
+// generateAlterSQL builds the ALTER TABLE statements requested by the -add, -drop, -rename,
+// -index and -unique options, along with the statements reverting them in reverse order
+func generateAlterSQL(dialect schemaDialect, getSQLType func(string) (string, string), tableName string) (up, down []string) {
+	var revert [][]string
+	add := func(u string, d ...string) {
+		up = append(up, u)
+		revert = append(revert, d)
+	}
+
+	if RenameFields != "" {
+		for _, v := range strings.Split(RenameFields.String(), ",") {
+			kv := strings.SplitN(v, ":", 2)
+			if len(kv) != 2 {
+				beeLogger.Log.Fatal("Rename format is wrong. Should be: old:new,old:new " + v)
+			}
+			from, to := utils.SnakeString(kv[0]), utils.SnakeString(kv[1])
+			add(dialect.renameColumnSQL(tableName, from, to), dialect.renameColumnSQL(tableName, to, from))
+		}
+	}
+	if AddFields != "" {
+		for _, v := range strings.Split(AddFields.String(), ",") {
+			kv := strings.SplitN(v, ":", 2)
+			if len(kv) != 2 {
+				beeLogger.Log.Fatal("Fields format is wrong. Should be: key:type,key:type " + v)
+			}
+			typ, tag := getSQLType(kv[1])
+			if typ == "" || tag != "" {
+				beeLogger.Log.Fatal("Fields format is wrong. Should be: key:type,key:type " + v)
+			}
+			column := utils.SnakeString(kv[0])
+			add(dialect.addColumnSQL(tableName, column, nullColumn(typ)), dialect.dropColumnSQL(tableName, column))
+		}
+	}
+	if DropFields != "" {
+		for _, v := range strings.Split(DropFields.String(), ",") {
+			kv := strings.SplitN(v, ":", 2)
+			column := utils.SnakeString(kv[0])
+			if len(kv) != 2 {
+				beeLogger.Log.Warnf("Column '%s' has no type: the down migration will not restore it. Use -drop=\"%s:<type>\" to restore it.", column, kv[0])
+				add(dialect.dropColumnSQL(tableName, column))
+				continue
+			}
+			typ, tag := getSQLType(kv[1])
+			if typ == "" || tag != "" {
+				beeLogger.Log.Fatal("Fields format is wrong. Should be: key:type,key:type " + v)
+			}
+			add(dialect.dropColumnSQL(tableName, column), dialect.addColumnSQL(tableName, column, nullColumn(typ)))
+		}
+	}
+	for _, index := range []struct {
+		fields utils.DocValue
+		unique bool
+	}{{IndexFields, false}, {UniqueFields, true}} {
+		if index.fields == "" {
+			continue
+		}
+		var columns []string
+		for _, v := range strings.Split(index.fields.String(), ",") {
+			columns = append(columns, utils.SnakeString(v))
+		}
+		idx := &Index{Name: indexName(tableName, index.unique, columns), Columns: columns, Unique: index.unique}
+		add(dialect.createIndexSQL(tableName, idx), dialect.dropIndexSQL(tableName, idx))
+	}
+
+	for i := len(revert) - 1; i >= 0; i-- {
+		down = append(down, revert[i]...)
+	}
+	return
+}
+
+// nullColumn makes a column type of getSQLType nullable, so that the column can be
+// added to a table already holding rows
+func nullColumn(typ string) string {
+	if strings.Contains(typ, "AUTO_INCREMENT") {
+		return typ
+	}
+	return strings.Replace(typ, " NOT NULL", " NULL", 1)
+}
+
 func NewDBDriver() DBDriver {
 	switch SQLDriver {
 	case "mysql":
@@ -190,49 +584,38 @@ func NewDBDriver() DBDriver {
 // The generated file template consists of an up() method for updating schema and
 // a down() method for reverting the update.
 func GenerateMigration(mname, upsql, downsql, curpath string) {
-	w := colors.NewColorWriter(os.Stdout)
 	migrationFilePath := path.Join(curpath, DBPath, MPath)
-	if _, err := os.Stat(migrationFilePath); os.IsNotExist(err) {
-		// create migrations directory
-		if err := os.MkdirAll(migrationFilePath, 0777); err != nil {
-			beeLogger.Log.Fatalf("Could not create migration directory: %s", err)
-		}
-	}
 	// create file
 	today := time.Now().Format(MDateFormat)
 	fpath := path.Join(migrationFilePath, fmt.Sprintf("%s_%s.go", today, mname))
-	if f, err := os.OpenFile(fpath, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0666); err == nil {
-		defer utils.CloseFile(f)
-		ddlSpec := ""
-		spec := ""
-		up := ""
-		down := ""
-		if DDL != "" {
-			ddlSpec = "m.ddlSpec()"
-			switch strings.Title(DDL.String()) {
-			case "Create":
-				spec = strings.Replace(DDLSpecCreate, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
-			case "Alter":
-				spec = strings.Replace(DDLSpecAlter, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
-			}
-			spec = strings.Replace(spec, "{{tableName}}", mname, -1)
-		} else {
-			up = strings.Replace(MigrationUp, "{{UpSQL}}", upsql, -1)
-			up = strings.Replace(up, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
-			down = strings.Replace(MigrationDown, "{{DownSQL}}", downsql, -1)
-			down = strings.Replace(down, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
+	spec := ""
+	up := ""
+	down := ""
+	if DDL != "" {
+		// the migration binary collects the statements of m.Migrate, called by Up and Down
+		upType, downType := "create", "delete"
+		switch strings.Title(DDL.String()) {
+		case "Create":
+			spec = strings.Replace(DDLSpecCreate, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
+		case "Alter":
+			spec = strings.Replace(DDLSpecAlter, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
+			upType, downType = "alter", "reverse"
 		}
-
-		header := strings.Replace(MigrationHeader, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
-		header = strings.Replace(header, "{{ddlSpec}}", ddlSpec, -1)
-		header = strings.Replace(header, "{{CurrTime}}", today, -1)
-		f.WriteString(header + spec + up + down)
-		// Run 'gofmt' on the generated source code
-		utils.FormatSourceCode(fpath)
-		fmt.Fprintf(w, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", fpath, "\x1b[0m")
+		spec = strings.Replace(spec, "{{tableName}}", mname, -1)
+		up = strings.Replace(DDLMigrationUp, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
+		up = strings.Replace(up, "{{ModifyType}}", upType, -1)
+		down = strings.Replace(DDLMigrationDown, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
+		down = strings.Replace(down, "{{ModifyType}}", downType, -1)
 	} else {
-		beeLogger.Log.Fatalf("Could not create migration file: %s", err)
+		up = strings.Replace(MigrationUp, "{{UpSQL}}", upsql, -1)
+		up = strings.Replace(up, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
+		down = strings.Replace(MigrationDown, "{{DownSQL}}", downsql, -1)
+		down = strings.Replace(down, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
 	}
+
+	header := strings.Replace(MigrationHeader, "{{StructName}}", utils.CamelCase(mname)+"_"+today, -1)
+	header = strings.Replace(header, "{{CurrTime}}", today, -1)
+	utils.WriteGeneratedFile(fpath, header+spec+up+down, utils.FailIfExists)
 }
 
 const (
@@ -250,7 +633,6 @@ const (
 						func init() {
 							m := &{{StructName}}{}
 							m.Created = "{{CurrTime}}"
-							{{ddlSpec}}
 							migration.Register("{{StructName}}", m)
 						}
 					   `
@@ -274,6 +656,19 @@ const (
 
 				}
 				`
+	DDLMigrationUp = `
+				// Run the migrations
+				func (m *{{StructName}}) Up() {
+					m.ddlSpec()
+					m.Migrate("{{ModifyType}}")
+				}`
+	DDLMigrationDown = `
+				// Reverse the migrations
+				func (m *{{StructName}}) Down() {
+					m.ddlSpec()
+					m.Migrate("{{ModifyType}}")
+				}
+				`
 	MigrationUp = `
 				// Run the migrations
 				func (m *{{StructName}}) Up() {
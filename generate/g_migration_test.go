@@ -0,0 +1,119 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/beego/bee/v2/utils"
+)
+
+func TestGenerateAlterSQL(t *testing.T) {
+	defer func() {
+		AddFields, DropFields, RenameFields, IndexFields, UniqueFields = "", "", "", "", ""
+	}()
+
+	tests := []struct {
+		name                     string
+		add, drop, rename        utils.DocValue
+		index, unique            utils.DocValue
+		mysqlUp, mysqlDown       []string
+		postgresUp, postgresDown []string
+	}{
+		{
+			name: "add",
+			add:  "nickname:string:64,active:bool",
+			mysqlUp: []string{
+				"ALTER TABLE `user` ADD COLUMN `nickname` varchar(64) NULL",
+				"ALTER TABLE `user` ADD COLUMN `active` tinyint(1) NULL",
+			},
+			mysqlDown: []string{
+				"ALTER TABLE `user` DROP COLUMN `active`",
+				"ALTER TABLE `user` DROP COLUMN `nickname`",
+			},
+			postgresUp: []string{
+				"ALTER TABLE user ADD COLUMN nickname char(64) NULL",
+				"ALTER TABLE user ADD COLUMN active boolean NULL",
+			},
+			postgresDown: []string{
+				"ALTER TABLE user DROP COLUMN active",
+				"ALTER TABLE user DROP COLUMN nickname",
+			},
+		},
+		{
+			name:         "drop",
+			drop:         "age:int,legacy",
+			mysqlUp:      []string{"ALTER TABLE `user` DROP COLUMN `age`", "ALTER TABLE `user` DROP COLUMN `legacy`"},
+			mysqlDown:    []string{"ALTER TABLE `user` ADD COLUMN `age` int(11) DEFAULT NULL"},
+			postgresUp:   []string{"ALTER TABLE user DROP COLUMN age", "ALTER TABLE user DROP COLUMN legacy"},
+			postgresDown: []string{"ALTER TABLE user ADD COLUMN age integer DEFAULT NULL"},
+		},
+		{
+			name:         "rename",
+			rename:       "Name:FullName",
+			mysqlUp:      []string{"ALTER TABLE `user` RENAME COLUMN `name` TO `full_name`"},
+			mysqlDown:    []string{"ALTER TABLE `user` RENAME COLUMN `full_name` TO `name`"},
+			postgresUp:   []string{"ALTER TABLE user RENAME COLUMN name TO full_name"},
+			postgresDown: []string{"ALTER TABLE user RENAME COLUMN full_name TO name"},
+		},
+		{
+			name:   "indexes",
+			index:  "name,age",
+			unique: "email",
+			mysqlUp: []string{
+				"CREATE INDEX `user_name_age_idx` ON `user` (`name`, `age`)",
+				"CREATE UNIQUE INDEX `user_email_uniq` ON `user` (`email`)",
+			},
+			mysqlDown: []string{
+				"DROP INDEX `user_email_uniq` ON `user`",
+				"DROP INDEX `user_name_age_idx` ON `user`",
+			},
+			postgresUp: []string{
+				"CREATE INDEX user_name_age_idx ON user (name, age)",
+				"CREATE UNIQUE INDEX user_email_uniq ON user (email)",
+			},
+			postgresDown: []string{
+				"DROP INDEX user_email_uniq",
+				"DROP INDEX user_name_age_idx",
+			},
+		},
+	}
+	for _, tt := range tests {
+		AddFields, DropFields, RenameFields, IndexFields, UniqueFields = tt.add, tt.drop, tt.rename, tt.index, tt.unique
+		for _, d := range []struct {
+			name     string
+			dialect  schemaDialect
+			getType  func(string) (string, string)
+			up, down []string
+		}{
+			{"mysql", mysqlDriver{}, mysqlDriver{}.getSQLType, tt.mysqlUp, tt.mysqlDown},
+			{"postgres", postgresqlDriver{}, postgresqlDriver{}.getSQLType, tt.postgresUp, tt.postgresDown},
+		} {
+			t.Run(tt.name+"/"+d.name, func(t *testing.T) {
+				up, down := generateAlterSQL(d.dialect, d.getType, "user")
+				if !reflect.DeepEqual(up, d.up) {
+					t.Errorf("unexpected up statements:\n%q", up)
+				}
+				if !reflect.DeepEqual(down, d.down) {
+					t.Errorf("unexpected down statements:\n%q", down)
+				}
+				if upsql, downsql := d.dialect.(DBDriver).GenerateAlter("user"); upsql != renderSQLCalls(up) || downsql != renderSQLCalls(down) {
+					t.Errorf("unexpected migration:\n%s\n%s", upsql, downsql)
+				}
+			})
+		}
+	}
+}
@@ -16,19 +16,30 @@ package generate
 
 import (
 	"errors"
-	"fmt"
-	"os"
 	"path"
 	"strings"
 
 	beeLogger "github.com/beego/bee/v2/logger"
-	"github.com/beego/bee/v2/logger/colors"
 	"github.com/beego/bee/v2/utils"
 )
 
 func GenerateModel(mname, fields, currpath string) {
-	w := colors.NewColorWriter(os.Stdout)
+	generateModel(mname, fields, currpath, utils.FailIfExists)
+}
 
+// generateModel writes the model of the fields, with the given policy for an existing file
+func generateModel(mname, fields, currpath string, existing utils.ExistingPolicy) {
+	_, f := path.Split(mname)
+	modelName := strings.Title(f)
+	modelStruct, hastime, err := getStruct(modelName, fields)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not generate the model struct: %s", err)
+	}
+	writeModel(mname, modelStruct, hastime, currpath, existing)
+}
+
+// writeModel writes the model file with the struct declaration and the CRUD functions
+func writeModel(mname, modelStruct string, hastime bool, currpath string, existing utils.ExistingPolicy) {
 	p, f := path.Split(mname)
 	modelName := strings.Title(f)
 	packageName := "models"
@@ -37,39 +48,21 @@ func GenerateModel(mname, fields, currpath string) {
 		packageName = p[i+1 : len(p)-1]
 	}
 
-	modelStruct, hastime, err := getStruct(modelName, fields)
-	if err != nil {
-		beeLogger.Log.Fatalf("Could not generate the model struct: %s", err)
-	}
-
 	beeLogger.Log.Infof("Using '%s' as model name", modelName)
 	beeLogger.Log.Infof("Using '%s' as package name", packageName)
 
-	fp := path.Join(currpath, "models", p)
-	if _, err := os.Stat(fp); os.IsNotExist(err) {
-		// Create the model's directory
-		if err := os.MkdirAll(fp, 0777); err != nil {
-			beeLogger.Log.Fatalf("Could not create the model directory: %s", err)
-		}
-	}
-
-	fpath := path.Join(fp, strings.ToLower(modelName)+".go")
-	if f, err := os.OpenFile(fpath, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0666); err == nil {
-		defer utils.CloseFile(f)
-		content := strings.Replace(modelTpl, "{{packageName}}", packageName, -1)
-		content = strings.Replace(content, "{{modelName}}", modelName, -1)
-		content = strings.Replace(content, "{{modelStruct}}", modelStruct, -1)
-		if hastime {
-			content = strings.Replace(content, "{{timePkg}}", `"time"`, -1)
-		} else {
-			content = strings.Replace(content, "{{timePkg}}", "", -1)
-		}
-		f.WriteString(content)
-		// Run 'gofmt' on the generated source code
-		utils.FormatSourceCode(fpath)
-		fmt.Fprintf(w, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", fpath, "\x1b[0m")
+	fpath := path.Join(currpath, "models", p, strings.ToLower(modelName)+".go")
+	content := strings.Replace(loadTemplate("model.go.tpl"), "{{packageName}}", packageName, -1)
+	content = strings.Replace(content, "{{modelName}}", modelName, -1)
+	content = strings.Replace(content, "{{modelStruct}}", modelStruct, -1)
+	if hastime {
+		content = strings.Replace(content, "{{timePkg}}", `"time"`, -1)
 	} else {
-		beeLogger.Log.Fatalf("Could not create model file: %s", err)
+		content = strings.Replace(content, "{{timePkg}}", "", -1)
+	}
+	utils.WriteGeneratedFile(fpath, content, existing)
+	if Repository {
+		writeRepository(path.Join(currpath, "models", p), packageName, strings.ToLower(modelName), modelName, "int64")
 	}
 }
 
@@ -99,6 +92,7 @@ func getStruct(structname, fields string) (string, bool, error) {
 		if hastimeinner {
 			hastime = true
 		}
+		tag = addStructTag(tag, "valid", strings.Join(fieldValidRules(kv[1]), ";"))
 		structStr = structStr + utils.CamelString(kv[0]) + "       " + typ + "     " + tag + "\n"
 	}
 	structStr += "}\n"
@@ -146,6 +140,9 @@ import (
 	"strings"
 	{{timePkg}}
 	"github.com/beego/beego/v2/client/orm"
+
+	// bee:begin imports
+	// bee:end
 )
 
 {{modelStruct}}
@@ -276,4 +273,7 @@ func Delete{{modelName}}(id int64) (err error) {
 	}
 	return
 }
+
+// bee:begin custom
+// bee:end
 `
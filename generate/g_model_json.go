@@ -0,0 +1,718 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
+)
+
+// jsonObject is a decoded JSON object keeping the order of its keys
+type jsonObject struct {
+	Keys   []string
+	Values map[string]interface{}
+}
+
+// jsonShape is the type inferred from one or more JSON sample values
+type jsonShape struct {
+	Kind     string // null, bool, int, float, string, datetime, date, object, array or any
+	Nullable bool
+	Objects  int                   // number of objects merged
+	Keys     []string              // keys of the objects, in order of appearance
+	Fields   map[string]*jsonShape // shape of the values of each key
+	Present  map[string]int        // number of objects having each key
+	Elem     *jsonShape            // shape of the array elements
+}
+
+// inferredField is a field of a struct inferred from a JSON sample or schema
+type inferredField struct {
+	Name     string
+	Key      string
+	Type     string
+	Orm      string
+	Optional bool
+	Required bool // required by the schema of a specification
+}
+
+// inferredStruct is a struct inferred from a JSON sample or schema
+type inferredStruct struct {
+	Name   string
+	Fields []*inferredField
+}
+
+// structInferrer collects the structs inferred from a JSON document
+type structInferrer struct {
+	structs []*inferredStruct
+	names   map[string]bool
+	hasTime bool
+	schema  interface{}
+	refs    map[string]string
+	plain   bool // no model: the structs have neither orm tags nor a primary key
+	spec    bool // structs of bee generate fromspec: required properties are tagged for bee generate docs, string enums are types
+	enums   []*Enum
+}
+
+var nonIdentRegex = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// GenerateModelFromJSON generates a model from a sample JSON payload.
+// A top level array is read as samples of the same object.
+func GenerateModelFromJSON(mname, file, currpath string) {
+	doc := readJSONFile(file)
+	shape := sampleShape(doc)
+	if shape.Kind == "array" && shape.Elem != nil {
+		shape = shape.Elem
+	}
+	if shape.Kind != "object" {
+		beeLogger.Log.Fatalf("'%s' must contain an object or an array of objects", file)
+	}
+
+	inf := &structInferrer{names: make(map[string]bool)}
+	inf.shapeStruct(modelStructName(mname), shape, true)
+	writeModel(mname, inf.String(), inf.hasTime, currpath, utils.FailIfExists)
+}
+
+// GenerateModelFromSchema generates a model from a JSON Schema describing an object
+func GenerateModelFromSchema(mname, file, currpath string) {
+	doc := readJSONFile(file)
+	schema, ok := doc.(*jsonObject)
+	if !ok {
+		beeLogger.Log.Fatalf("'%s' is not a JSON Schema", file)
+	}
+	if items, ok := schema.Values["items"].(*jsonObject); ok && schemaTypes(schema)["array"] {
+		schema = items
+	}
+
+	inf := &structInferrer{names: make(map[string]bool), schema: doc, refs: make(map[string]string)}
+	schema, err := inf.resolveRef(schema)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read the schema: %s", err)
+	}
+	if err := inf.schemaStruct(modelStructName(mname), schema, true); err != nil {
+		beeLogger.Log.Fatalf("Could not read the schema: %s", err)
+	}
+	writeModel(mname, inf.String(), inf.hasTime, currpath, utils.FailIfExists)
+}
+
+func modelStructName(mname string) string {
+	_, f := path.Split(mname)
+	return strings.Title(f)
+}
+
+func readJSONFile(file string) interface{} {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read '%s': %s", file, err)
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	doc, err := decodeOrdered(dec)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not parse '%s': %s", file, err)
+	}
+	return doc
+}
+
+// decodeOrdered decodes the next JSON value, keeping the order of object keys
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch delim {
+	case '{':
+		obj := &jsonObject{Values: make(map[string]interface{})}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			k := key.(string)
+			if _, ok := obj.Values[k]; !ok {
+				obj.Keys = append(obj.Keys, k)
+			}
+			obj.Values[k] = value
+		}
+		_, err = dec.Token()
+		return obj, err
+	case '[':
+		var list []interface{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, err
+	}
+	return nil, fmt.Errorf("unexpected %v", delim)
+}
+
+// sampleShape infers the shape of a sample value
+func sampleShape(v interface{}) *jsonShape {
+	switch v := v.(type) {
+	case nil:
+		return &jsonShape{Kind: "null", Nullable: true}
+	case bool:
+		return &jsonShape{Kind: "bool"}
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return &jsonShape{Kind: "float"}
+		}
+		return &jsonShape{Kind: "int"}
+	case string:
+		return &jsonShape{Kind: timeKind(v)}
+	case []interface{}:
+		s := &jsonShape{Kind: "array"}
+		for _, e := range v {
+			s.Elem = mergeShapes(s.Elem, sampleShape(e))
+		}
+		return s
+	case *jsonObject:
+		s := &jsonShape{Kind: "object", Objects: 1, Keys: v.Keys, Fields: make(map[string]*jsonShape), Present: make(map[string]int)}
+		for _, k := range v.Keys {
+			s.Fields[k] = sampleShape(v.Values[k])
+			s.Present[k] = 1
+		}
+		return s
+	}
+	return &jsonShape{Kind: "any"}
+}
+
+// timeKind tells whether a string holds a date, a date and time, or anything else
+func timeKind(s string) string {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return "datetime"
+		}
+	}
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return "date"
+	}
+	return "string"
+}
+
+// mergeShapes returns the shape of values being either of a or b
+func mergeShapes(a, b *jsonShape) *jsonShape {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.Kind == "null":
+		b.Nullable = true
+		return b
+	case b.Kind == "null":
+		a.Nullable = true
+		return a
+	}
+
+	s := &jsonShape{Kind: a.Kind, Nullable: a.Nullable || b.Nullable}
+	switch {
+	case a.Kind == b.Kind:
+	case (a.Kind == "int" && b.Kind == "float") || (a.Kind == "float" && b.Kind == "int"):
+		s.Kind = "float"
+	case (a.Kind == "date" && b.Kind == "datetime") || (a.Kind == "datetime" && b.Kind == "date"):
+		s.Kind = "datetime"
+	case isStringKind(a.Kind) && isStringKind(b.Kind):
+		s.Kind = "string"
+	default:
+		s.Kind = "any"
+		return s
+	}
+
+	switch s.Kind {
+	case "array":
+		s.Elem = mergeShapes(a.Elem, b.Elem)
+	case "object":
+		s.Objects = a.Objects + b.Objects
+		s.Fields = make(map[string]*jsonShape)
+		s.Present = make(map[string]int)
+		for _, o := range []*jsonShape{a, b} {
+			for _, k := range o.Keys {
+				if _, ok := s.Fields[k]; !ok {
+					s.Keys = append(s.Keys, k)
+				}
+				s.Fields[k] = mergeShapes(s.Fields[k], o.Fields[k])
+				s.Present[k] += o.Present[k]
+			}
+		}
+	}
+	return s
+}
+
+func isStringKind(kind string) bool {
+	return kind == "string" || kind == "date" || kind == "datetime"
+}
+
+// shapeStruct adds the struct of an object shape, and of the objects nested in it
+func (inf *structInferrer) shapeStruct(name string, shape *jsonShape, model bool) string {
+	st := inf.newStruct(name)
+	if model {
+		st.Fields = append(st.Fields, idField(shape.Fields["id"]))
+	}
+	for _, key := range shape.Keys {
+		if model && key == "id" {
+			continue
+		}
+		fieldName := st.fieldName(key)
+		value := shape.Fields[key]
+		optional := value.Nullable || shape.Present[key] < shape.Objects
+		typ, orm := inf.shapeType(st.Name+fieldName, value, optional)
+		st.Fields = append(st.Fields, &inferredField{Name: fieldName, Key: key, Type: typ, Orm: orm, Optional: optional})
+	}
+	return st.Name
+}
+
+// shapeType returns the Go type and the orm tag of a value shape
+func (inf *structInferrer) shapeType(name string, shape *jsonShape, optional bool) (typ, orm string) {
+	switch shape.Kind {
+	case "bool":
+		return "bool", ""
+	case "int":
+		return "int64", ""
+	case "float":
+		return "float64", ""
+	case "string":
+		return "string", ""
+	case "datetime":
+		inf.hasTime = true
+		return "time.Time", "type(datetime)"
+	case "date":
+		inf.hasTime = true
+		return "time.Time", "type(date)"
+	case "null":
+		beeLogger.Log.Warnf("The type of '%s' is unknown as it is always null, using string", name)
+		return "string", ""
+	case "object":
+		typ = inf.shapeStruct(inf.uniqueName(name), shape, false)
+		if optional {
+			typ = "*" + typ
+		}
+		return typ, "-"
+	case "array":
+		if shape.Elem == nil {
+			return "[]interface{}", "-"
+		}
+		elem, _ := inf.shapeType(singularize(name), shape.Elem, false)
+		return "[]" + elem, "-"
+	}
+	return "interface{}", "-"
+}
+
+// schemaStruct adds the struct of an object schema, and of the objects nested in it
+func (inf *structInferrer) schemaStruct(name string, schema *jsonObject, model bool) error {
+	st := inf.newStruct(name)
+	if model {
+		var id *jsonShape
+		if props, ok := schema.Values["properties"].(*jsonObject); ok {
+			if idSchema, ok := props.Values["id"].(*jsonObject); ok && !schemaTypes(idSchema)["integer"] {
+				id = &jsonShape{Kind: "string"}
+			}
+		}
+		st.Fields = append(st.Fields, idField(id))
+	}
+
+	props, required, err := inf.schemaProperties(schema)
+	if err != nil {
+		return err
+	}
+	for _, key := range props.Keys {
+		if model && key == "id" {
+			continue
+		}
+		prop, ok := props.Values[key].(*jsonObject)
+		if !ok {
+			return fmt.Errorf("property '%s' is not a schema", key)
+		}
+		fieldName := st.fieldName(key)
+		typ, orm, nullable, err := inf.schemaType(st.Name+fieldName, prop)
+		if err != nil {
+			return fmt.Errorf("%s: %s", key, err)
+		}
+		optional := nullable || !required[key]
+		if optional && inf.names[typ] && inf.enum(typ) == nil {
+			typ = "*" + typ
+		}
+		st.Fields = append(st.Fields, &inferredField{Name: fieldName, Key: key, Type: typ, Orm: orm, Optional: optional, Required: inf.spec && required[key]})
+	}
+	return nil
+}
+
+// schemaProperties returns the properties of an object schema and the required
+// ones, merging the subschemas of allOf
+func (inf *structInferrer) schemaProperties(schema *jsonObject) (*jsonObject, map[string]bool, error) {
+	props := &jsonObject{Values: make(map[string]interface{})}
+	required := make(map[string]bool)
+	if p, ok := schema.Values["properties"].(*jsonObject); ok {
+		props.Keys = append(props.Keys, p.Keys...)
+		for k, v := range p.Values {
+			props.Values[k] = v
+		}
+	}
+	if r, ok := schema.Values["required"].([]interface{}); ok {
+		for _, k := range r {
+			if k, ok := k.(string); ok {
+				required[k] = true
+			}
+		}
+	}
+
+	allOf, _ := schema.Values["allOf"].([]interface{})
+	for _, s := range allOf {
+		sub, ok := s.(*jsonObject)
+		if !ok {
+			continue
+		}
+		sub, err := inf.resolveRef(sub)
+		if err != nil {
+			return nil, nil, err
+		}
+		p, r, err := inf.schemaProperties(sub)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, k := range p.Keys {
+			if _, ok := props.Values[k]; !ok {
+				props.Keys = append(props.Keys, k)
+				props.Values[k] = p.Values[k]
+			}
+		}
+		for k := range r {
+			required[k] = true
+		}
+	}
+	return props, required, nil
+}
+
+// schemaType returns the Go type and orm tag of a property schema, and whether it is nullable
+func (inf *structInferrer) schemaType(name string, schema *jsonObject) (typ, orm string, nullable bool, err error) {
+	if ref, ok := schema.Values["$ref"].(string); ok {
+		if structName, ok := inf.refs[ref]; ok {
+			return structName, "-", false, nil
+		}
+		resolved, err := inf.resolveRef(schema)
+		if err != nil {
+			return "", "", false, err
+		}
+		if !schemaTypes(resolved)["object"] && resolved.Values["properties"] == nil {
+			return inf.schemaType(name, resolved)
+		}
+		// definitions are prefixed with the model so that models sharing them do not clash
+		structName := utils.CamelString(nonIdentRegex.ReplaceAllString(ref[strings.LastIndex(ref, "/")+1:], "_"))
+		if !inf.plain && !strings.HasPrefix(structName, inf.structs[0].Name) {
+			structName = inf.structs[0].Name + structName
+		}
+		structName = inf.uniqueName(structName)
+		inf.refs[ref] = structName
+		if err := inf.schemaStruct(structName, resolved, false); err != nil {
+			return "", "", false, err
+		}
+		return structName, "-", false, nil
+	}
+
+	for _, key := range []string{"oneOf", "anyOf"} {
+		alternatives, ok := schema.Values[key].([]interface{})
+		if !ok {
+			continue
+		}
+		var others []*jsonObject
+		for _, a := range alternatives {
+			if a, ok := a.(*jsonObject); ok {
+				if types := schemaTypes(a); len(types) == 1 && types["null"] {
+					nullable = true
+					continue
+				}
+				others = append(others, a)
+			}
+		}
+		if len(others) != 1 {
+			return "interface{}", "-", nullable, nil
+		}
+		typ, orm, n, err := inf.schemaType(name, others[0])
+		return typ, orm, nullable || n, err
+	}
+
+	types := schemaTypes(schema)
+	nullable = types["null"] || schema.Values["nullable"] == true
+	delete(types, "null")
+	if len(types) == 0 {
+		switch {
+		case schema.Values["properties"] != nil:
+			types["object"] = true
+		case schema.Values["items"] != nil:
+			types["array"] = true
+		case schema.Values["additionalProperties"] != nil:
+			types["object"] = true
+		case schema.Values["enum"] != nil:
+			types["string"] = true
+		}
+	}
+	if len(types) != 1 {
+		return "interface{}", "-", nullable, nil
+	}
+
+	switch {
+	case types["boolean"]:
+		return "bool", "", nullable, nil
+	case types["integer"]:
+		if schema.Values["format"] == "int32" {
+			return "int32", "", nullable, nil
+		}
+		return "int64", "", nullable, nil
+	case types["number"]:
+		if schema.Values["format"] == "float" {
+			return "float32", "", nullable, nil
+		}
+		return "float64", "", nullable, nil
+	case types["string"]:
+		switch schema.Values["format"] {
+		// bee generate docs documents time.Time as datetime
+		case "date-time", "datetime":
+			inf.hasTime = true
+			return "time.Time", "type(datetime)", nullable, nil
+		case "date":
+			inf.hasTime = true
+			return "time.Time", "type(date)", nullable, nil
+		}
+		if values, ok := enumStrings(schema); ok && inf.spec {
+			e := &Enum{Name: inf.uniqueName(name), Values: values, Source: "the specification"}
+			inf.names[e.Name] = true
+			inf.enums = append(inf.enums, e)
+			return e.Name, "", nullable, nil
+		}
+		if n, ok := schema.Values["maxLength"].(json.Number); ok {
+			return "string", "size(" + n.String() + ")", nullable, nil
+		}
+		return "string", "", nullable, nil
+	case types["object"]:
+		if schema.Values["properties"] == nil && schema.Values["allOf"] == nil {
+			values, ok := schema.Values["additionalProperties"].(*jsonObject)
+			if !ok {
+				return "map[string]interface{}", "-", nullable, nil
+			}
+			elem, _, _, err := inf.schemaType(singularize(name), values)
+			if err != nil {
+				return "", "", false, err
+			}
+			return "map[string]" + elem, "-", nullable, nil
+		}
+		name = inf.uniqueName(name)
+		if err := inf.schemaStruct(name, schema, false); err != nil {
+			return "", "", false, err
+		}
+		return name, "-", nullable, nil
+	case types["array"]:
+		items, ok := schema.Values["items"].(*jsonObject)
+		if !ok {
+			return "[]interface{}", "-", nullable, nil
+		}
+		elem, _, _, err := inf.schemaType(singularize(name), items)
+		if err != nil {
+			return "", "", false, err
+		}
+		return "[]" + elem, "-", nullable, nil
+	}
+	return "interface{}", "-", nullable, nil
+}
+
+// enumStrings returns the values of the enum of a schema, if they are all strings
+func enumStrings(schema *jsonObject) ([]string, bool) {
+	list, _ := schema.Values["enum"].([]interface{})
+	var values []string
+	for _, v := range list {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, s)
+	}
+	return values, len(values) > 0
+}
+
+// enum returns the enum type of the given name, nil if there is none
+func (inf *structInferrer) enum(name string) *Enum {
+	for _, e := range inf.enums {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// schemaTypes returns the types of a schema, given as a string or a list
+func schemaTypes(schema *jsonObject) map[string]bool {
+	types := make(map[string]bool)
+	switch t := schema.Values["type"].(type) {
+	case string:
+		types[t] = true
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types[s] = true
+			}
+		}
+	}
+	return types
+}
+
+// resolveRef follows the local $ref of a schema, i.e. #/definitions/Address
+func (inf *structInferrer) resolveRef(schema *jsonObject) (*jsonObject, error) {
+	for i := 0; i < 32; i++ {
+		ref, ok := schema.Values["$ref"].(string)
+		if !ok {
+			return schema, nil
+		}
+		if !strings.HasPrefix(ref, "#") {
+			return nil, fmt.Errorf("only local references are supported, got '%s'", ref)
+		}
+		var node interface{} = inf.schema
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+			if part == "" {
+				continue
+			}
+			part = strings.Replace(strings.Replace(part, "~1", "/", -1), "~0", "~", -1)
+			obj, ok := node.(*jsonObject)
+			if !ok {
+				return nil, fmt.Errorf("could not resolve '%s'", ref)
+			}
+			if node, ok = obj.Values[part]; !ok {
+				return nil, fmt.Errorf("could not resolve '%s'", ref)
+			}
+		}
+		if schema, ok = node.(*jsonObject); !ok {
+			return nil, fmt.Errorf("'%s' is not a schema", ref)
+		}
+	}
+	return nil, errors.New("too many nested references")
+}
+
+// idField returns the primary key field the CRUD functions of the model rely on
+func idField(sample *jsonShape) *inferredField {
+	if sample != nil && sample.Kind != "int" && sample.Kind != "null" {
+		beeLogger.Log.Warnf("The model primary key must be an integer, the id will be an int64")
+	}
+	return &inferredField{Name: "Id", Key: "id", Type: "int64", Orm: "auto"}
+}
+
+// newStruct adds a struct, its name must not be taken already
+func (inf *structInferrer) newStruct(name string) *inferredStruct {
+	st := &inferredStruct{Name: name}
+	inf.names[st.Name] = true
+	inf.structs = append(inf.structs, st)
+	return st
+}
+
+// uniqueName returns name, or name followed by a number if it is already taken
+func (inf *structInferrer) uniqueName(name string) string {
+	if !inf.names[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		if n := name + strconv.Itoa(i); !inf.names[n] {
+			return n
+		}
+	}
+}
+
+// fieldName turns a JSON key into an exported field name unique in the struct
+func (st *inferredStruct) fieldName(key string) string {
+	name := utils.CamelString(strings.Trim(nonIdentRegex.ReplaceAllString(key, "_"), "_"))
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "Field" + name
+	}
+	unique := name
+	for i := 2; ; i++ {
+		taken := false
+		for _, f := range st.Fields {
+			if f.Name == unique {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return unique
+		}
+		unique = name + strconv.Itoa(i)
+	}
+}
+
+// String renders the declarations of the inferred structs, the model first
+func (inf *structInferrer) String() string {
+	var b strings.Builder
+	for i, st := range inf.structs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "type %s struct {\n", st.Name)
+		model := i == 0 && !inf.plain
+		for _, f := range st.Fields {
+			var tags []string
+			orm := f.Orm
+			if model && f.Optional && orm == "" {
+				orm = "null"
+			} else if model && f.Optional && orm != "-" {
+				orm += ";null"
+			}
+			if model && orm != "" {
+				tags = append(tags, `orm:"`+orm+`"`)
+			}
+			json := f.Key
+			if f.Optional {
+				json += ",omitempty"
+			}
+			tags = append(tags, `json:"`+json+`"`)
+			if f.Required {
+				// read by bee generate docs
+				tags = append(tags, `required:"true"`)
+			}
+			fmt.Fprintf(&b, "\t%s %s `%s`\n", f.Name, f.Type, strings.Join(tags, " "))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// singularize returns the singular of an English plural, i.e. the name of the elements of a list
+func singularize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "ses"), strings.HasSuffix(s, "xes"), strings.HasSuffix(s, "ches"), strings.HasSuffix(s, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss"):
+		return s[:len(s)-1]
+	}
+	return s + "Item"
+}
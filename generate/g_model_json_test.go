@@ -0,0 +1,93 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func decodeTestJSON(t *testing.T, s string) interface{} {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	doc, err := decodeOrdered(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestInferStructFromSample(t *testing.T) {
+	doc := decodeTestJSON(t, `[
+		{"id": 1, "name": "a", "score": 1, "at": "2020-01-02T03:04:05Z", "nick": null, "tags": ["x"], "home": {"city": "c"}},
+		{"id": 2, "name": "b", "score": 1.5, "at": "2020-01-03T03:04:05Z", "nick": "n", "tags": []}
+	]`)
+	inf := &structInferrer{names: make(map[string]bool)}
+	inf.shapeStruct("User", sampleShape(doc).Elem, true)
+
+	expected := "type User struct {\n" +
+		"\tId int64 `orm:\"auto\" json:\"id\"`\n" +
+		"\tName string `json:\"name\"`\n" +
+		"\tScore float64 `json:\"score\"`\n" +
+		"\tAt time.Time `orm:\"type(datetime)\" json:\"at\"`\n" +
+		"\tNick string `orm:\"null\" json:\"nick,omitempty\"`\n" +
+		"\tTags []string `orm:\"-\" json:\"tags\"`\n" +
+		"\tHome *UserHome `orm:\"-\" json:\"home,omitempty\"`\n" +
+		"}\n\n" +
+		"type UserHome struct {\n" +
+		"\tCity string `json:\"city\"`\n" +
+		"}\n"
+	if got := inf.String(); got != expected {
+		t.Errorf("unexpected struct:\n%s\nexpected:\n%s", got, expected)
+	}
+	if !inf.hasTime {
+		t.Error("expected the struct to need the time package")
+	}
+}
+
+func TestInferStructFromSchema(t *testing.T) {
+	doc := decodeTestJSON(t, `{
+		"type": "object",
+		"required": ["name", "owner"],
+		"properties": {
+			"name": {"type": "string", "maxLength": 64},
+			"size": {"type": ["integer", "null"]},
+			"owner": {"$ref": "#/definitions/Person"},
+			"members": {"type": "array", "items": {"$ref": "#/definitions/Person"}}
+		},
+		"definitions": {
+			"Person": {"type": "object", "properties": {"born": {"type": "string", "format": "date"}}}
+		}
+	}`)
+	inf := &structInferrer{names: make(map[string]bool), schema: doc, refs: make(map[string]string)}
+	if err := inf.schemaStruct("Team", doc.(*jsonObject), true); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "type Team struct {\n" +
+		"\tId int64 `orm:\"auto\" json:\"id\"`\n" +
+		"\tName string `orm:\"size(64)\" json:\"name\"`\n" +
+		"\tSize int64 `orm:\"null\" json:\"size,omitempty\"`\n" +
+		"\tOwner TeamPerson `orm:\"-\" json:\"owner\"`\n" +
+		"\tMembers []TeamPerson `orm:\"-\" json:\"members,omitempty\"`\n" +
+		"}\n\n" +
+		"type TeamPerson struct {\n" +
+		"\tBorn time.Time `json:\"born,omitempty\"`\n" +
+		"}\n"
+	if got := inf.String(); got != expected {
+		t.Errorf("unexpected struct:\n%s\nexpected:\n%s", got, expected)
+	}
+}
@@ -0,0 +1,519 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"path"
+	"strings"
+
+	"github.com/beego/bee/v2/utils"
+)
+
+// writeRepository writes the repository interface of a model, its database
+// implementation, its in-memory fake, and the helpers of the fakes of the package
+func writeRepository(dir, packageName, fileName, modelName, idType string) {
+	replacer := strings.NewReplacer(
+		"{{packageName}}", packageName,
+		"{{modelName}}", modelName,
+		"{{idType}}", idType,
+	)
+	utils.WriteGeneratedFile(path.Join(dir, fileName+"_repository.go"),
+		replacer.Replace(loadTemplate("repository/repository.go.tpl")), utils.AskIfExists)
+	utils.WriteGeneratedFile(path.Join(dir, fileName+"_repository_fake.go"),
+		replacer.Replace(loadTemplate("repository/fake.go.tpl")), utils.AskIfExists)
+	utils.WriteGeneratedFile(path.Join(dir, "fake_repository.go"),
+		replacer.Replace(loadTemplate("repository/fake_helpers.go.tpl")), utils.KeepExisting)
+}
+
+// renderRepositoryController returns a controller of the model using its repository
+func renderRepositoryController(packageName, controllerName, pkgPath, idType, enumChecks string, unique []uniqueField) string {
+	return strings.NewReplacer(
+		"{{packageName}}", packageName,
+		"{{controllerName}}", controllerName,
+		"{{pkgPath}}", pkgPath,
+		"{{idType}}", idType,
+		"{{enumChecks}}", enumChecks,
+		"{{uniqueChecks}}", renderUniqueChecks(controllerName, "c.repository().GetAll", unique),
+	).Replace(loadTemplate("repository/controller.go.tpl"))
+}
+
+var repositoryTpl = `package {{packageName}}
+
+// {{modelName}}Repository stores the {{modelName}} models
+type {{modelName}}Repository interface {
+	// Add inserts a new {{modelName}} and returns its Id
+	Add(m *{{modelName}}) (int64, error)
+	// GetById returns the {{modelName}} with the given Id
+	GetById(id {{idType}}) (*{{modelName}}, error)
+	// GetAll returns the {{modelName}} models matching the query, sorted, and limited to the given fields
+	GetAll(query map[string]string, fields []string, sortby []string, order []string, offset int64, limit int64) ([]interface{}, error)
+	// UpdateById updates the {{modelName}} with the Id of m
+	UpdateById(m *{{modelName}}) error
+	// Delete deletes the {{modelName}} with the given Id
+	Delete(id {{idType}}) error
+}
+
+// New{{modelName}}Repository returns the {{modelName}}Repository of the database
+func New{{modelName}}Repository() {{modelName}}Repository {
+	return orm{{modelName}}Repository{}
+}
+
+// orm{{modelName}}Repository is the {{modelName}}Repository of the database
+type orm{{modelName}}Repository struct{}
+
+func (orm{{modelName}}Repository) Add(m *{{modelName}}) (int64, error) {
+	return Add{{modelName}}(m)
+}
+
+func (orm{{modelName}}Repository) GetById(id {{idType}}) (*{{modelName}}, error) {
+	return Get{{modelName}}ById(id)
+}
+
+func (orm{{modelName}}Repository) GetAll(query map[string]string, fields []string, sortby []string, order []string, offset int64, limit int64) ([]interface{}, error) {
+	return GetAll{{modelName}}(query, fields, sortby, order, offset, limit)
+}
+
+func (orm{{modelName}}Repository) UpdateById(m *{{modelName}}) error {
+	return Update{{modelName}}ById(m)
+}
+
+func (orm{{modelName}}Repository) Delete(id {{idType}}) error {
+	return Delete{{modelName}}(id)
+}
+`
+
+var fakeRepositoryTpl = `package {{packageName}}
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// Fake{{modelName}}Repository is an in-memory {{modelName}}Repository, for tests
+type Fake{{modelName}}Repository struct {
+	mu     sync.Mutex
+	items  map[{{idType}}]{{modelName}}
+	lastId {{idType}}
+}
+
+// NewFake{{modelName}}Repository returns an empty Fake{{modelName}}Repository
+func NewFake{{modelName}}Repository() *Fake{{modelName}}Repository {
+	return &Fake{{modelName}}Repository{items: make(map[{{idType}}]{{modelName}})}
+}
+
+func (r *Fake{{modelName}}Repository) Add(m *{{modelName}}) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m.Id == 0 {
+		r.lastId++
+		m.Id = r.lastId
+	} else if _, ok := r.items[m.Id]; ok {
+		return 0, fakeDuplicateError(m.Id)
+	} else if m.Id > r.lastId {
+		r.lastId = m.Id
+	}
+	r.items[m.Id] = *m
+	return int64(m.Id), nil
+}
+
+func (r *Fake{{modelName}}Repository) GetById(id {{idType}}) (*{{modelName}}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.items[id]
+	if !ok {
+		return nil, orm.ErrNoRows
+	}
+	return &m, nil
+}
+
+func (r *Fake{{modelName}}Repository) GetAll(query map[string]string, fields []string, sortby []string, order []string, offset int64, limit int64) ([]interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var l []interface{}
+	for _, m := range r.items {
+		ok, err := fakeMatches(m, query)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			l = append(l, m)
+		}
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].({{modelName}}).Id < l[j].({{modelName}}).Id })
+	return fakeResults(l, fields, sortby, order, offset, limit)
+}
+
+func (r *Fake{{modelName}}Repository) UpdateById(m *{{modelName}}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.items[m.Id]; !ok {
+		return orm.ErrNoRows
+	}
+	r.items[m.Id] = *m
+	return nil
+}
+
+func (r *Fake{{modelName}}Repository) Delete(id {{idType}}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.items[id]; !ok {
+		return orm.ErrNoRows
+	}
+	delete(r.items, id)
+	return nil
+}
+`
+
+var fakeRepositoryHelpersTpl = `package {{packageName}}
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The helpers of the in-memory repositories, emulating the queries of GetAll
+
+func fakeDuplicateError(id interface{}) error {
+	return fmt.Errorf("duplicate Id %v", id)
+}
+
+// fakeField returns the field of m named like a query key, a struct field or its column
+func fakeField(m reflect.Value, name string) (reflect.Value, bool) {
+	name = strings.Replace(strings.ToLower(name), "_", "", -1)
+	t := m.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.ToLower(t.Field(i).Name) == name {
+			return m.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// fakeMatches tells whether m has the values of the query, compared as strings
+func fakeMatches(m interface{}, query map[string]string) (bool, error) {
+	v := reflect.ValueOf(m)
+	for k, want := range query {
+		name := strings.TrimSuffix(k, "__exact")
+		if strings.Contains(name, "__") || strings.Contains(name, ".") {
+			return false, fmt.Errorf("the fake repository does not support the query %q", k)
+		}
+		f, ok := fakeField(v, name)
+		if !ok {
+			return false, fmt.Errorf("unknown field %q", k)
+		}
+		if fmt.Sprint(f.Interface()) != want {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// fakeLess compares two values of a field
+func fakeLess(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	case reflect.String:
+		return a.String() < b.String()
+	}
+	if t, ok := a.Interface().(time.Time); ok {
+		return t.Before(b.Interface().(time.Time))
+	}
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
+
+// fakeResults sorts, pages and trims the models matching a query, like GetAll
+func fakeResults(l []interface{}, fields []string, sortby []string, order []string, offset int64, limit int64) ([]interface{}, error) {
+	if len(sortby) == 0 && len(order) != 0 {
+		return nil, errors.New("Error: unused 'order' fields")
+	}
+	if len(sortby) != 0 && len(order) != 1 && len(order) != len(sortby) {
+		return nil, errors.New("Error: 'sortby', 'order' sizes mismatch or 'order' size is not 1")
+	}
+	for i := len(sortby) - 1; i >= 0; i-- {
+		o := order[0]
+		if len(order) > 1 {
+			o = order[i]
+		}
+		if o != "asc" && o != "desc" {
+			return nil, errors.New("Error: Invalid order. Must be either [asc|desc]")
+		}
+		desc := o == "desc"
+		if len(l) > 0 {
+			if _, ok := fakeField(reflect.ValueOf(l[0]), sortby[i]); !ok {
+				return nil, fmt.Errorf("unknown field %q", sortby[i])
+			}
+		}
+		name := sortby[i]
+		sort.SliceStable(l, func(a, b int) bool {
+			fa, _ := fakeField(reflect.ValueOf(l[a]), name)
+			fb, _ := fakeField(reflect.ValueOf(l[b]), name)
+			if desc {
+				return fakeLess(fb, fa)
+			}
+			return fakeLess(fa, fb)
+		})
+	}
+
+	if offset > int64(len(l)) {
+		offset = int64(len(l))
+	}
+	l = l[offset:]
+	if limit > 0 && limit < int64(len(l)) {
+		l = l[:limit]
+	}
+	if len(fields) == 0 {
+		return l, nil
+	}
+	var ml []interface{}
+	for _, m := range l {
+		v := reflect.ValueOf(m)
+		row := make(map[string]interface{})
+		for _, name := range fields {
+			f, ok := fakeField(v, name)
+			if !ok {
+				return nil, fmt.Errorf("unknown field %q", name)
+			}
+			row[name] = f.Interface()
+		}
+		ml = append(ml, row)
+	}
+	return ml, nil
+}
+`
+
+var repositoryControllerTpl = `package {{packageName}}
+
+import (
+	"{{pkgPath}}/models"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/v2/core/validation"
+	beego "github.com/beego/beego/v2/server/web"
+
+	// bee:begin imports
+	// bee:end
+)
+
+// {{controllerName}}Controller operations for {{controllerName}}
+type {{controllerName}}Controller struct {
+	beego.Controller
+	// Repository stores the {{controllerName}} models, models.New{{controllerName}}Repository() if nil
+	Repository models.{{controllerName}}Repository
+}
+
+// URLMapping ...
+func (c *{{controllerName}}Controller) URLMapping() {
+	c.Mapping("Post", c.Post)
+	c.Mapping("GetOne", c.GetOne)
+	c.Mapping("GetAll", c.GetAll)
+	c.Mapping("Put", c.Put)
+	c.Mapping("Delete", c.Delete)
+
+	// bee:begin mappings
+	// bee:end
+}
+
+// repository returns the repository of the controller
+func (c *{{controllerName}}Controller) repository() models.{{controllerName}}Repository {
+	if c.Repository == nil {
+		c.Repository = models.New{{controllerName}}Repository()
+	}
+	return c.Repository
+}
+
+// Post ...
+// @Title Post
+// @Description create {{controllerName}}
+// @Param	body		body 	models.{{controllerName}}	true		"body for {{controllerName}} content"
+// @Success 201 {int} models.{{controllerName}}
+// @Failure 400 the fields breaking validation rules
+// @Failure 403 body is empty
+// @router / [post]
+func (c *{{controllerName}}Controller) Post() {
+	var v models.{{controllerName}}
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &v); err == nil {
+		if errs := c.validate(&v); errs != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = errs
+		} else if _, err := c.repository().Add(&v); err == nil {
+			c.Ctx.Output.SetStatus(201)
+			c.Data["json"] = v
+		} else {
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// GetOne ...
+// @Title Get One
+// @Description get {{controllerName}} by id
+// @Param	id		path 	string	true		"The key for staticblock"
+// @Success 200 {object} models.{{controllerName}}
+// @Failure 403 :id is empty
+// @router /:id [get]
+func (c *{{controllerName}}Controller) GetOne() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.ParseInt(idStr, 0, 64)
+	v, err := c.repository().GetById({{idType}}(id))
+	if err != nil {
+		c.Data["json"] = err.Error()
+	} else {
+		c.Data["json"] = v
+	}
+	c.ServeJSON()
+}
+
+// GetAll ...
+// @Title Get All
+// @Description get {{controllerName}}
+// @Param	query	query	string	false	"Filter. e.g. col1:v1,col2:v2 ..."
+// @Param	fields	query	string	false	"Fields returned. e.g. col1,col2 ..."
+// @Param	sortby	query	string	false	"Sorted-by fields. e.g. col1,col2 ..."
+// @Param	order	query	string	false	"Order corresponding to each sortby field, if single value, apply to all sortby fields. e.g. desc,asc ..."
+// @Param	limit	query	string	false	"Limit the size of result set. Must be an integer"
+// @Param	offset	query	string	false	"Start position of result set. Must be an integer"
+// @Success 200 {object} models.{{controllerName}}
+// @Failure 403
+// @router / [get]
+func (c *{{controllerName}}Controller) GetAll() {
+	var fields []string
+	var sortby []string
+	var order []string
+	var query = make(map[string]string)
+	var limit int64 = 10
+	var offset int64
+
+	// fields: col1,col2,entity.col3
+	if v := c.GetString("fields"); v != "" {
+		fields = strings.Split(v, ",")
+	}
+	// limit: 10 (default is 10)
+	if v, err := c.GetInt64("limit"); err == nil {
+		limit = v
+	}
+	// offset: 0 (default is 0)
+	if v, err := c.GetInt64("offset"); err == nil {
+		offset = v
+	}
+	// sortby: col1,col2
+	if v := c.GetString("sortby"); v != "" {
+		sortby = strings.Split(v, ",")
+	}
+	// order: desc,asc
+	if v := c.GetString("order"); v != "" {
+		order = strings.Split(v, ",")
+	}
+	// query: k:v,k:v
+	if v := c.GetString("query"); v != "" {
+		for _, cond := range strings.Split(v, ",") {
+			kv := strings.SplitN(cond, ":", 2)
+			if len(kv) != 2 {
+				c.Data["json"] = errors.New("Error: invalid query key/value pair")
+				c.ServeJSON()
+				return
+			}
+			k, v := kv[0], kv[1]
+			query[k] = v
+		}
+	}
+
+	l, err := c.repository().GetAll(query, fields, sortby, order, offset, limit)
+	if err != nil {
+		c.Data["json"] = err.Error()
+	} else {
+		c.Data["json"] = l
+	}
+	c.ServeJSON()
+}
+
+// Put ...
+// @Title Put
+// @Description update the {{controllerName}}
+// @Param	id		path 	string	true		"The id you want to update"
+// @Param	body		body 	models.{{controllerName}}	true		"body for {{controllerName}} content"
+// @Success 200 {object} models.{{controllerName}}
+// @Failure 400 the fields breaking validation rules
+// @Failure 403 :id is not int
+// @router /:id [put]
+func (c *{{controllerName}}Controller) Put() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.ParseInt(idStr, 0, 64)
+	v := models.{{controllerName}}{Id: {{idType}}(id)}
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &v); err == nil {
+		if errs := c.validate(&v); errs != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = errs
+		} else if err := c.repository().UpdateById(&v); err == nil {
+			c.Data["json"] = "OK"
+		} else {
+			c.Data["json"] = err.Error()
+		}
+	} else {
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// Delete ...
+// @Title Delete
+// @Description delete the {{controllerName}}
+// @Param	id		path 	string	true		"The id you want to delete"
+// @Success 200 {string} delete success!
+// @Failure 403 id is empty
+// @router /:id [delete]
+func (c *{{controllerName}}Controller) Delete() {
+	idStr := c.Ctx.Input.Param(":id")
+	id, _ := strconv.ParseInt(idStr, 0, 64)
+	if err := c.repository().Delete({{idType}}(id)); err == nil {
+		c.Data["json"] = "OK"
+	} else {
+		c.Data["json"] = err.Error()
+	}
+	c.ServeJSON()
+}
+
+// validate checks v against the valid tags of its fields, its enum fields against
+// their values, and its unique fields against the other {{controllerName}} models.
+// It returns the errors found, if any.
+func (c *{{controllerName}}Controller) validate(v *models.{{controllerName}}) *ValidationErrors {
+	valid := validation.Validation{}
+	if _, err := valid.Valid(v); err != nil {
+		valid.SetError("", err.Error())
+	}
+{{enumChecks}}{{uniqueChecks}}	return newValidationErrors(valid.Errors)
+}
+
+// bee:begin custom
+// bee:end
+`
@@ -0,0 +1,257 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beego/bee/v2/internal/pkg/sqlite"
+)
+
+var testRepositoryModel = "type Post struct {\n" +
+	"\tId        int    `orm:\"column(id);auto\"`\n" +
+	"\tTitle     string `orm:\"column(title);size(64)\"`\n" +
+	"\tViews     int    `orm:\"column(views)\"`\n" +
+	"\tPublished bool   `orm:\"column(published)\"`\n" +
+	"}"
+
+// testRepositoryTest compares the fake repository with the one of the database
+var testRepositoryTest = `package models
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/beego/beego/v2/client/orm"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var (
+	_ PostRepository = NewPostRepository()
+	_ PostRepository = NewFakePostRepository()
+)
+
+func TestGetAll(t *testing.T) {
+	orm.RegisterDriver("sqlite3", orm.DRSqlite)
+	if err := orm.RegisterDataBase("default", "sqlite3", filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	if err := orm.RunSyncdb("default", false, false); err != nil {
+		t.Fatal(err)
+	}
+	repositories := map[string]PostRepository{"orm": NewPostRepository(), "fake": NewFakePostRepository()}
+	for _, r := range repositories {
+		for i, title := range []string{"b", "a", "c", "a", "d"} {
+			if _, err := r.Add(&Post{Title: title, Views: i % 3, Published: i%2 == 0}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name   string
+		query  map[string]string
+		fields []string
+		sortby []string
+		order  []string
+		offset int64
+		limit  int64
+	}{
+		{name: "all", limit: 10},
+		{name: "query", query: map[string]string{"title": "a"}, limit: 10},
+		{name: "query exact", query: map[string]string{"title__exact": "a", "views": "0"}, limit: 10},
+		{name: "no match", query: map[string]string{"title": "z"}, limit: 10},
+		{name: "sort", sortby: []string{"title", "id"}, order: []string{"asc", "desc"}, limit: 10},
+		{name: "sort one order", sortby: []string{"views", "title"}, order: []string{"desc"}, limit: 10},
+		{name: "sort without order", sortby: []string{"title"}, limit: 10},
+		{name: "order without sort", order: []string{"asc"}, limit: 10},
+		{name: "invalid order", sortby: []string{"title"}, order: []string{"up"}, limit: 10},
+		{name: "mismatched orders", sortby: []string{"title"}, order: []string{"asc", "desc"}, limit: 10},
+		{name: "page", sortby: []string{"id"}, order: []string{"asc"}, offset: 1, limit: 2},
+		{name: "last page", sortby: []string{"id"}, order: []string{"asc"}, offset: 4, limit: 2},
+		{name: "past the end", offset: 10, limit: 2},
+		{name: "fields", fields: []string{"Id", "Title"}, sortby: []string{"id"}, order: []string{"desc"}, limit: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(map[string]string)
+			for name, r := range repositories {
+				l, err := r.GetAll(tt.query, tt.fields, tt.sortby, tt.order, tt.offset, tt.limit)
+				results[name] = fmt.Sprintf("%v %v", l, err != nil)
+			}
+			if !reflect.DeepEqual(results["orm"], results["fake"]) {
+				t.Errorf("the fake returns %s, the database %s", results["fake"], results["orm"])
+			}
+		})
+	}
+}
+`
+
+func TestFakeRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and tests a package")
+	}
+	if !sqlite.Supported {
+		t.Skip("SQLite needs cgo")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	// build inside the module, for the imports of the models to resolve
+	dir, err := ioutil.TempDir(".", "repository")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	model := strings.NewReplacer(
+		"{{modelStruct}}", testRepositoryModel,
+		"{{modelName}}", "Post",
+		"{{tableName}}", "post",
+		"{{timePkg}}", "",
+	).Replace(loadTemplate("appcode/model.go.tpl"))
+	files := map[string]string{"models/post.go": model, "models/repository_test.go": testRepositoryTest}
+	// write the repository out of the module, for its generated version not to be cached in it
+	out, err := ioutil.TempDir("", "repository")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+	writeRepository(out, "models", "post", "Post", "int")
+	for _, name := range []string{"post_repository.go", "post_repository_fake.go", "fake_repository.go"} {
+		content, err := ioutil.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files["models/"+name] = string(content)
+	}
+	writeTestFiles(t, dir, files)
+
+	cmd := exec.Command(goBin, "test", "./...")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("the fake repository does not behave like the database: %s\n%s", err, out)
+	}
+}
+
+// testUniqueChecksTest validates posts against the fake repository
+var testUniqueChecksTest = `package controllers
+
+import (
+	"testing"
+
+	"{{pkgPath}}/models"
+)
+
+func TestValidateUnique(t *testing.T) {
+	c := &PostController{Repository: models.NewFakePostRepository()}
+	for _, p := range []*models.Post{{Title: "a"}, {Email: "x@example.com"}} {
+		if _, err := c.Repository.Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		post     models.Post
+		expected string
+	}{
+		// the posts without an email do not clash, unlike the ones without a title
+		{models.Post{Title: "b"}, ""},
+		{models.Post{Title: "a"}, "Title"},
+		{models.Post{Email: "x@example.com", Title: "b"}, "Email"},
+		{models.Post{Email: "y@example.com"}, "Title"},
+	}
+	for _, tt := range tests {
+		var got string
+		if errs := c.validate(&tt.post); errs != nil {
+			for _, e := range errs.Errors {
+				got += e.Field
+			}
+		}
+		if got != tt.expected {
+			t.Errorf("expected the errors of %+v to be %q, got %q", tt.post, tt.expected, got)
+		}
+	}
+}
+`
+
+func TestUniqueChecks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and tests a package")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	// build inside the module, for the imports of the models to resolve
+	dir, err := ioutil.TempDir(".", "unique")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pkgPath := "github.com/beego/bee/v2/generate/" + filepath.Base(dir)
+
+	model := strings.NewReplacer(
+		"{{modelStruct}}", "type Post struct {\n"+
+			"\tId    int    `orm:\"column(id);auto\"`\n"+
+			"\tEmail string `orm:\"column(email);size(64);null;unique\"`\n"+
+			"\tTitle string `orm:\"column(title);size(64);unique\"`\n"+
+			"}",
+		"{{modelName}}", "Post",
+		"{{tableName}}", "post",
+		"{{timePkg}}", "",
+	).Replace(loadTemplate("appcode/model.go.tpl"))
+	files := map[string]string{
+		"models/post.go":            model,
+		"controllers/validation.go": strings.Replace(validationTpl, "{{packageName}}", "controllers", -1),
+		"controllers/post_test.go":  strings.Replace(testUniqueChecksTest, "{{pkgPath}}", pkgPath, -1),
+	}
+	out, err := ioutil.TempDir("", "unique")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+	writeRepository(out, "models", "post", "Post", "int")
+	for _, name := range []string{"post_repository.go", "post_repository_fake.go", "fake_repository.go"} {
+		content, err := ioutil.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files["models/"+name] = string(content)
+	}
+	writeTestFiles(t, dir, files)
+
+	// the unique fields as read from the model
+	unique := modelUniqueFields(filepath.Join(dir, "models"), "Post")
+	if len(unique) != 2 || !unique[0].Nullable || unique[1].Nullable {
+		t.Fatalf("unexpected unique fields %+v", unique)
+	}
+	writeTestFiles(t, dir, map[string]string{
+		"controllers/post.go": renderRepositoryController("controllers", "Post", pkgPath, "int", "", unique),
+	})
+
+	cmd := exec.Command(goBin, "test", "./...")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("the unique checks of the controller fail: %s\n%s", err, out)
+	}
+}
@@ -13,19 +13,19 @@ func GenerateScaffold(sname, fields, currpath, driver, conn string) {
 
 	// Generate the model
 	if utils.AskForConfirmation() {
-		GenerateModel(sname, fields, currpath)
+		generateModel(sname, fields, currpath, utils.MergeExisting)
 	}
 
 	// Generate the controller
 	beeLogger.Log.Infof("Do you want to create a '%s' controller? [Yes|No] ", sname)
 	if utils.AskForConfirmation() {
-		GenerateController(sname, currpath)
+		generateController(sname, currpath, utils.MergeExisting)
 	}
 
 	// Generate the views
 	beeLogger.Log.Infof("Do you want to create views for this '%s' resource? [Yes|No] ", sname)
 	if utils.AskForConfirmation() {
-		GenerateView(sname, currpath)
+		generateView(sname, fields, currpath, utils.MergeExisting)
 	}
 
 	// Generate a migration
@@ -46,5 +46,6 @@ func GenerateScaffold(sname, fields, currpath, driver, conn string) {
 	if utils.AskForConfirmation() {
 		migrate.MigrateUpdate(currpath, driver, conn, "")
 	}
-	beeLogger.Log.Successf("All done! Don't forget to add  beego.Router(\"/%s\" ,&controllers.%sController{}) to routers/route.go\n", sname, strings.Title(sname))
+	beeLogger.Log.Successf("All done! Don't forget to add beego.AddNamespace(beego.NewNamespace(\"/api/%s\", beego.NSInclude(&controllers.%sController{})))"+
+		" and, for the views, beego.Include(&controllers.%sViewController{}) to routers/router.go\n", sname, strings.Title(sname), utils.CamelCase(sname))
 }
@@ -0,0 +1,101 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/beego/bee/v2/cmd/commands/migrate"
+	"github.com/beego/bee/v2/internal/pkg/sqlite"
+	beeLogger "github.com/beego/bee/v2/logger"
+)
+
+// schemaIgnoredTables are the bookkeeping tables of bee left out of schema dumps
+var schemaIgnoredTables = map[string]bool{"migrations": true, "seeds": true}
+
+func init() {
+	migrate.SchemaDumper = DumpSchema
+}
+
+// DumpSchema introspects the database and returns its schema as SQL statements.
+// Tables, foreign keys and indexes are sorted by name so that dumps diff cleanly.
+func DumpSchema(driver, connStr string) string {
+	sqlite.Check(driver)
+	trans, ok := dbDriver[driver]
+	if !ok {
+		beeLogger.Log.Fatalf("Dumping the schema is not supported for '%s'", driver)
+	}
+	db, err := sql.Open(driver, connStr)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not connect to '%s' database using '%s': %s", driver, connStr, err)
+	}
+	defer db.Close()
+
+	var tables []*Table
+	for _, name := range trans.GetTableNames(db) {
+		if schemaIgnoredTables[name] {
+			continue
+		}
+		// tables without a single column primary key are dumped too
+		tb := &Table{Name: name, Fk: make(map[string]*ForeignKey)}
+		trans.GetConstraints(db, tb, make(map[string]bool))
+		trans.GetColumns(db, tb, make(map[string]bool))
+		getIndexes(trans, db, tb)
+		tables = append(tables, tb)
+	}
+	return renderSchema(tables)
+}
+
+// renderSchema writes the CREATE TABLE and CREATE INDEX statements of tables
+func renderSchema(tables []*Table) string {
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	var b strings.Builder
+	b.WriteString("-- Database schema dumped by bee migrate. DO NOT EDIT.\n")
+	for _, tb := range tables {
+		var lines []string
+		for _, col := range tb.Columns {
+			null := "NOT NULL"
+			if col.Nullable {
+				null = "NULL"
+			}
+			lines = append(lines, fmt.Sprintf("%s %s %s", col.Tag.Column, col.SQLType, null))
+		}
+		if tb.Pk != "" {
+			lines = append(lines, fmt.Sprintf("PRIMARY KEY (%s)", tb.Pk))
+		}
+		var fks []string
+		for column, fk := range tb.Fk {
+			fks = append(fks, fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", column, fk.RefTable, fk.RefColumn))
+		}
+		sort.Strings(fks)
+		lines = append(lines, fks...)
+		fmt.Fprintf(&b, "\nCREATE TABLE %s (\n\t%s\n);\n", tb.Name, strings.Join(lines, ",\n\t"))
+
+		indexes := append([]*Index(nil), tb.Indexes...)
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+		for _, idx := range indexes {
+			unique := ""
+			if idx.Unique {
+				unique = "UNIQUE "
+			}
+			fmt.Fprintf(&b, "CREATE %sINDEX %s ON %s (%s);\n", unique, idx.Name, tb.Name, strings.Join(idx.Columns, ", "))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"math/rand"
+	"testing"
+)
+
+const expectedSchema = `-- Database schema dumped by bee migrate. DO NOT EDIT.
+
+CREATE TABLE post (
+	id int(11) NOT NULL,
+	author_id int(11) NOT NULL,
+	editor_id int(11) NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (author_id) REFERENCES user (id),
+	FOREIGN KEY (editor_id) REFERENCES user (id)
+);
+CREATE INDEX post_author_idx ON post (author_id);
+CREATE UNIQUE INDEX post_editor_uniq ON post (editor_id);
+
+CREATE TABLE user (
+	id int(11) NOT NULL,
+	name varchar(64) NOT NULL,
+	PRIMARY KEY (id)
+);
+`
+
+func TestRenderSchemaIsDeterministic(t *testing.T) {
+	tables := func() []*Table {
+		user := &Table{
+			Name: "user",
+			Pk:   "id",
+			Fk:   map[string]*ForeignKey{},
+			Columns: []*Column{
+				{Tag: &OrmTag{Column: "id"}, SQLType: "int(11)"},
+				{Tag: &OrmTag{Column: "name"}, SQLType: "varchar(64)"},
+			},
+		}
+		post := &Table{
+			Name: "post",
+			Pk:   "id",
+			Fk: map[string]*ForeignKey{
+				"author_id": {RefTable: "user", RefColumn: "id"},
+				"editor_id": {RefTable: "user", RefColumn: "id"},
+			},
+			Columns: []*Column{
+				{Tag: &OrmTag{Column: "id"}, SQLType: "int(11)"},
+				{Tag: &OrmTag{Column: "author_id"}, SQLType: "int(11)"},
+				{Tag: &OrmTag{Column: "editor_id"}, SQLType: "int(11)", Nullable: true},
+			},
+			Indexes: []*Index{
+				{Name: "post_editor_uniq", Columns: []string{"editor_id"}, Unique: true},
+				{Name: "post_author_idx", Columns: []string{"author_id"}},
+			},
+		}
+		return []*Table{user, post}
+	}
+
+	for i := 0; i < 20; i++ {
+		tbs := tables()
+		// the database returns tables and indexes in any order
+		rand.Shuffle(len(tbs), func(i, j int) { tbs[i], tbs[j] = tbs[j], tbs[i] })
+		for _, tb := range tbs {
+			rand.Shuffle(len(tb.Indexes), func(i, j int) { tb.Indexes[i], tb.Indexes[j] = tb.Indexes[j], tb.Indexes[i] })
+		}
+		if schema := renderSchema(tbs); schema != expectedSchema {
+			t.Fatalf("unexpected schema:\n%s", schema)
+		}
+	}
+}
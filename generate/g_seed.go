@@ -0,0 +1,76 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
+)
+
+const SPath = "seeds"
+
+// GenerateSeed generates a seed file in database/seeds. The format is either
+// go, sql or yaml.
+func GenerateSeed(sname, format, curpath string) {
+	var tpl, ext string
+	switch format {
+	case "", "go":
+		tpl, ext = SeedGoTPL, ".go"
+	case "sql":
+		tpl, ext = SeedSQLTPL, ".sql"
+	case "yaml", "yml":
+		tpl, ext = SeedYAMLTPL, ".yml"
+	default:
+		beeLogger.Log.Fatal("Invalid seed format. Must be either \"go\", \"sql\" or \"yaml\"")
+	}
+
+	name := fmt.Sprintf("%s_%s", time.Now().Format(MDateFormat), utils.SnakeString(sname))
+	fpath := path.Join(curpath, DBPath, SPath, name+ext)
+	utils.WriteGeneratedFile(fpath, strings.Replace(tpl, "{{SeedName}}", name, -1), utils.FailIfExists)
+}
+
+const (
+	SeedGoTPL = `package main
+
+import (
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// DO NOT MODIFY
+func init() {
+	Register("{{SeedName}}", seed{{SeedName}})
+}
+
+// Seed the database, e.g. with o.Raw("INSERT INTO ...").Exec()
+func seed{{SeedName}}(o orm.Ormer) error {
+	return nil
+}
+`
+	SeedSQLTPL = `-- Seed {{SeedName}}
+-- Statements are separated by semicolons and run in order, e.g.
+-- INSERT INTO users (name, email) VALUES ('admin', 'admin@example.com');
+`
+	SeedYAMLTPL = `# Seed {{SeedName}}
+# Each key is a table holding the list of rows to insert, e.g.
+# users:
+#   - name: admin
+#     email: admin@example.com
+`
+)
@@ -0,0 +1,282 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/beego/bee/v2/config"
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
+)
+
+// builtinTemplate is a template of a code generator. A file of the same name in
+// the templates directory of the project replaces its content.
+type builtinTemplate struct {
+	Name         string
+	Content      string
+	Placeholders []string
+}
+
+// builtinTemplates are the templates that can be overridden, by generator
+var builtinTemplates = []*builtinTemplate{
+	{"controller.go.tpl", controllerTpl, []string{
+		"{{packageName}} package of the controller",
+		"{{controllerName}} name of the controller, without the Controller suffix",
+	}},
+	{"controller_model.go.tpl", controllerModelTpl, []string{
+		"{{packageName}} package of the controller",
+		"{{controllerName}} name of the controller and of the model it serves",
+		"{{pkgPath}} import path of the application",
+		"{{uniqueChecks}} checks of the unique fields of the model, in its validate method",
+	}},
+	{"repository/repository.go.tpl", repositoryTpl, []string{
+		"{{packageName}} package of the model",
+		"{{modelName}} name of the model struct",
+		"{{idType}} type of the Id field of the model",
+	}},
+	{"repository/fake.go.tpl", fakeRepositoryTpl, []string{
+		"{{packageName}} package of the model",
+		"{{modelName}} name of the model struct",
+		"{{idType}} type of the Id field of the model",
+	}},
+	{"repository/fake_helpers.go.tpl", fakeRepositoryHelpersTpl, []string{
+		"{{packageName}} package of the models",
+	}},
+	{"repository/controller.go.tpl", repositoryControllerTpl, []string{
+		"{{packageName}} package of the controller",
+		"{{controllerName}} name of the controller and of the model it serves",
+		"{{pkgPath}} import path of the application",
+		"{{idType}} type of the Id field of the model",
+		"{{enumChecks}} checks of the enum fields of the model, in its validate method",
+		"{{uniqueChecks}} checks of the unique fields of the model, in its validate method",
+	}},
+	{"validation/errors.go.tpl", validationTpl, []string{
+		"{{packageName}} package of the controllers",
+	}},
+	{"model.go.tpl", modelTpl, []string{
+		"{{packageName}} package of the model",
+		"{{modelName}} name of the model struct",
+		"{{modelStruct}} declaration of the model struct",
+		"{{timePkg}} \"time\" when the model has a time field, empty otherwise",
+	}},
+	{"appcode/model.go.tpl", ModelTPL, []string{
+		"{{modelName}} name of the model struct",
+		"{{modelStruct}} declaration of the model struct",
+		"{{tableName}} name of the table, as schema\\\".\\\"table with -schema",
+		"{{timePkg}} \"time\" when the model has a time field, empty otherwise",
+		"{{importTimePkg}} import declaration of \"time\" when the model has a time field, empty otherwise",
+	}},
+	{"appcode/struct_model.go.tpl", StructModelTPL, []string{
+		"{{modelStruct}} declaration of the model struct, for tables without a primary key",
+		"{{importTimePkg}} import declaration of \"time\" when the model has a time field, empty otherwise",
+	}},
+	{"appcode/controller.go.tpl", CtrlTPL, []string{
+		"{{ctrlName}} name of the controller and of the model it serves",
+		"{{pkgPath}} import path of the application",
+		"{{enumChecks}} checks of the enum fields of the model, in its validate method",
+		"{{uniqueChecks}} checks of the unique fields of the model, in its validate method",
+	}},
+	{"appcode/enum.go.tpl", enumTpl, []string{
+		"{{enumName}} name of the enum type",
+		"{{enumSource}} column or type the values come from",
+		"{{enumConsts}} declaration of a constant per value",
+		"{{enumValues}} constants of the values, separated by commas",
+	}},
+	{"appcode/router.go.tpl", RouterTPL, []string{
+		"{{nameSpaces}} namespaces of the controllers, rendered with appcode/namespace.go.tpl",
+		"{{pkgPath}} import path of the application",
+	}},
+	{"appcode/namespace.go.tpl", NamespaceTPL, []string{
+		"{{nameSpace}} path of the namespace, the name of the table",
+		"{{ctrlName}} name of the controller",
+	}},
+	{"routers/comments_router.go.tpl", globalRouterTemplate, []string{
+		"{{.routersDir}} package of the routers file",
+		"{{.globalimport}} imports of the @Import annotations",
+		"{{.globalinfo}} registration of the routes of the @router annotations",
+	}},
+	{"views/index.tpl", indexViewTpl, []string{
+		"{{title}} name of the resource",
+		"{{route}} path of the resource",
+		"{{headers}} table header cells, one per field",
+		"{{cells}} table cells of an item, one per field",
+	}},
+	{"views/show.tpl", showViewTpl, []string{
+		"{{title}} name of the resource",
+		"{{route}} path of the resource",
+		"{{rows}} label and value of each field",
+	}},
+	{"views/create.tpl", createViewTpl, []string{
+		"{{title}} name of the resource",
+		"{{route}} path of the resource",
+		"{{inputs}} label and input of each field",
+	}},
+	{"views/edit.tpl", editViewTpl, []string{
+		"{{title}} name of the resource",
+		"{{route}} path of the resource",
+		"{{inputs}} label and input of each field, filled from .item",
+	}},
+	{"views/controller.go.tpl", viewControllerTpl, []string{
+		"{{packageName}} package of the controller",
+		"{{controllerName}} name of the controller, and of the model it renders",
+		"{{modelImport}} import path of the package of the model",
+		"{{idType}} type of the Id field of the model",
+		"{{route}} path of the resource",
+		"{{viewPath}} directory of the views, in the views directory",
+		"{{resetBools}} statements resetting the bool fields, before the form is parsed",
+	}},
+	{"fromspec/model.go.tpl", StructModelTPL, []string{
+		"{{modelStruct}} declaration of the structs of a definition, or of the inline schemas of a controller",
+		"{{importTimePkg}} import declaration of \"time\" when a struct has a time field, empty otherwise",
+	}},
+	{"fromspec/controller.go.tpl", specControllerTpl, []string{
+		"{{imports}} imports of the models and time packages, when the methods use them",
+		"{{controllerName}} name of the controller, without the Controller suffix",
+		"{{description}} description of the tag of the operations",
+		"{{methods}} annotated methods, one per operation",
+	}},
+	{"fromspec/router.go.tpl", specRouterTpl, []string{
+		"{{info}} comments describing the API, such as @Title and @APIVersion",
+		"{{pkgPath}} import path of the application",
+		"{{basePath}} base path of the API",
+		"{{nameSpaces}} namespaces of the controllers",
+	}},
+	{"client/go/client.go.tpl", goClientTpl, []string{
+		"{{packageName}} package of the client, the name of the output directory",
+		"{{title}} title of the API",
+		"{{baseURL}} URL of the API, from the @Schemes and @Host annotations and the namespace of the router",
+		"{{services}} fields of the services of the client, one per controller",
+		"{{serviceInits}} initialization of the services",
+	}},
+	{"client/ts/client.ts.tpl", tsClientTpl, []string{
+		"{{title}} title of the API",
+		"{{baseURL}} URL of the API, from the @Schemes and @Host annotations and the namespace of the router",
+		"{{imports}} imports and exports of the services, one module per controller",
+		"{{services}} members of the services of the client, one per controller",
+		"{{serviceInits}} initialization of the services",
+	}},
+	{"tests/setup_test.go.tpl", testSetupTpl, []string{
+		"{{pkgPath}} import path of the application",
+	}},
+	{"tests/controller_test.go.tpl", routeTestTpl, []string{
+		"{{controllerName}} name of the controller",
+		"{{cases}} test cases, one per route and HTTP method",
+	}},
+}
+
+// templatesDir returns the directory of the project templates. A relative
+// directory is relative to the working directory, which the Beefile is read from.
+func templatesDir() string {
+	dir := config.Conf.Templates
+	if dir == "" {
+		dir = "templates"
+	}
+	if !filepath.IsAbs(dir) {
+		if wd, err := os.Getwd(); err == nil {
+			dir = filepath.Join(wd, dir)
+		}
+	}
+	return dir
+}
+
+// loadTemplate returns the content of the named template, read from the templates
+// directory of the project if it overrides it
+func loadTemplate(name string) string {
+	var tpl *builtinTemplate
+	for _, t := range builtinTemplates {
+		if t.Name == name {
+			tpl = t
+			break
+		}
+	}
+	if tpl == nil {
+		beeLogger.Log.Fatalf("Unknown template '%s'", name)
+	}
+
+	fpath := filepath.Join(templatesDir(), filepath.FromSlash(name))
+	content, err := ioutil.ReadFile(fpath)
+	if os.IsNotExist(err) {
+		return tpl.Content
+	}
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not read template '%s': %s", fpath, err)
+	}
+	beeLogger.Log.Debugf("Using template '%s'", utils.FILE(), utils.LINE(), fpath)
+	return string(content)
+}
+
+// EjectTemplates writes the built-in templates to the templates directory so they
+// can be customized, along with a README describing their placeholders.
+// Existing files are kept. All the templates are written when no name is given.
+func EjectTemplates(names []string) {
+	selected := builtinTemplates
+	if len(names) > 0 {
+		selected = nil
+		for _, name := range names {
+			found := false
+			for _, t := range builtinTemplates {
+				if t.Name == name {
+					selected = append(selected, t)
+					found = true
+				}
+			}
+			if !found {
+				beeLogger.Log.Fatalf("Unknown template '%s'. Run 'bee generate templates list' to see the templates", name)
+			}
+		}
+	}
+
+	dir := templatesDir()
+	for _, t := range selected {
+		utils.WriteGeneratedFile(filepath.Join(dir, filepath.FromSlash(t.Name)), t.Content, utils.KeepExisting)
+	}
+	utils.WriteGeneratedFile(filepath.Join(dir, "README.md"), templatesReadme(), utils.KeepExisting)
+}
+
+// ListTemplates prints the templates and whether the project overrides them
+func ListTemplates() {
+	for _, t := range builtinTemplates {
+		status := "built-in"
+		if _, err := os.Stat(filepath.Join(templatesDir(), filepath.FromSlash(t.Name))); err == nil {
+			status = "overridden"
+		}
+		fmt.Printf("%-32s %s\n", t.Name, status)
+	}
+}
+
+// templatesReadme documents the placeholders of each template
+func templatesReadme() string {
+	var b strings.Builder
+	b.WriteString("# bee generate templates\n\n")
+	b.WriteString("The files of this directory replace the built-in templates of `bee generate` with the same name.\n")
+	b.WriteString("Delete a file to go back to the built-in template.\n\n")
+	b.WriteString("The placeholders below are replaced as plain text, anything else is copied as is.\n")
+	b.WriteString("Migrations and seeds are not templated since `bee migrate` reads them back.\n")
+	b.WriteString("The lines between a `bee:begin <name>` and a `bee:end` comment are protected regions: their\n")
+	b.WriteString("content is kept when the file is generated again.\n")
+	for _, t := range builtinTemplates {
+		fmt.Fprintf(&b, "\n## %s\n\n", t.Name)
+		for _, p := range t.Placeholders {
+			i := strings.Index(p, " ")
+			fmt.Fprintf(&b, "- `%s` %s\n", p[:i], p[i+1:])
+		}
+	}
+	return b.String()
+}
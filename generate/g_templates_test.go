@@ -0,0 +1,122 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beego/bee/v2/config"
+)
+
+// inTemplatesProject runs f in an empty directory, with the templates
+// directory of the configuration set to templates
+func inTemplatesProject(t *testing.T, templates string, f func(dir string)) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	defer func(templates string) { config.Conf.Templates = templates }(config.Conf.Templates)
+	config.Conf.Templates = templates
+
+	// the temporary directory may be behind a symbolic link
+	dir, err = os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f(dir)
+}
+
+func TestLoadTemplate(t *testing.T) {
+	for _, templates := range []string{"", "tpl"} {
+		inTemplatesProject(t, templates, func(dir string) {
+			if templates == "" {
+				templates = "templates"
+			}
+			if got := templatesDir(); got != filepath.Join(dir, templates) {
+				t.Errorf("expected the templates in %s, got %s", filepath.Join(dir, templates), got)
+			}
+			if got := loadTemplate("views/index.tpl"); got != indexViewTpl {
+				t.Errorf("expected the built-in template, got:\n%s", got)
+			}
+
+			override := "<h1>{{title}}</h1>\n"
+			fpath := filepath.Join(dir, templates, "views", "index.tpl")
+			if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(fpath, []byte(override), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got := loadTemplate("views/index.tpl"); got != override {
+				t.Errorf("expected the template of the project, got:\n%s", got)
+			}
+			if got := loadTemplate("views/show.tpl"); got != showViewTpl {
+				t.Errorf("expected the built-in template for a template not overridden, got:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestEjectTemplates(t *testing.T) {
+	inTemplatesProject(t, "tpl", func(dir string) {
+		EjectTemplates([]string{"model.go.tpl", "views/edit.tpl"})
+
+		for name, content := range map[string]string{"model.go.tpl": modelTpl, "views/edit.tpl": editViewTpl} {
+			got, err := ioutil.ReadFile(filepath.Join(dir, "tpl", filepath.FromSlash(name)))
+			if err != nil {
+				t.Fatalf("expected %s to be ejected: %s", name, err)
+			}
+			if string(got) != content {
+				t.Errorf("unexpected content of %s:\n%s", name, got)
+			}
+		}
+		readme, err := ioutil.ReadFile(filepath.Join(dir, "tpl", "README.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(readme), "## views/edit.tpl") || !strings.Contains(string(readme), "{{modelStruct}}") {
+			t.Errorf("unexpected README:\n%s", readme)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "tpl", "controller.go.tpl")); !os.IsNotExist(err) {
+			t.Errorf("expected only the named templates to be ejected: %v", err)
+		}
+
+		// an ejected template is the one loaded, and is kept when ejecting again
+		fpath := filepath.Join(dir, "tpl", "model.go.tpl")
+		if err := ioutil.WriteFile(fpath, []byte("custom\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		EjectTemplates(nil)
+		if got := loadTemplate("model.go.tpl"); got != "custom\n" {
+			t.Errorf("expected the ejected template to be loaded, got:\n%s", got)
+		}
+		if got := loadTemplate("controller.go.tpl"); got != controllerTpl {
+			t.Errorf("expected the ejected built-in template, got:\n%s", got)
+		}
+	})
+}
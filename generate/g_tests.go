@@ -0,0 +1,422 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
+)
+
+// includedController is a controller registered in the router file
+// under the prefix of its namespaces
+type includedController struct {
+	Name    string
+	PkgPath string
+	Prefix  string
+}
+
+// routeTest is a test case of a controller route
+type routeTest struct {
+	Name   string
+	Method string
+	Path   string
+	Body   bool
+}
+
+// GenerateTests writes a table-driven test file in the tests directory for each
+// controller of the router file, with a case per route of its @router annotations
+func GenerateTests(routerFile, currpath string) {
+	if routerFile == "" {
+		routerFile = path.Join("routers", "router.go")
+	}
+	if !filepath.IsAbs(routerFile) {
+		routerFile = path.Join(currpath, routerFile)
+	}
+	beeLogger.Log.Infof("Using '%s' as router file", routerFile)
+
+	controllers := parseRouterFile(routerFile)
+	if len(controllers) == 0 {
+		beeLogger.Log.Fatalf("Could not find any controller included in '%s'", routerFile)
+	}
+	// the routes of the @router annotations are registered by the file bee generate routers writes
+	if generated, _ := filepath.Glob(filepath.Join(filepath.Dir(routerFile), "commentsRouter*.go")); len(generated) == 0 {
+		beeLogger.Log.Warnf("Could not find the routes generated from the @router annotations next to '%s': "+
+			"run 'bee generate routers' too, the cases get 404 Not Found until then", routerFile)
+	}
+
+	pkgPath := getPackagePath(currpath)
+	testsPath := path.Join(currpath, "tests")
+	setup := strings.Replace(loadTemplate("tests/setup_test.go.tpl"), "{{pkgPath}}", pkgPath, -1)
+	utils.WriteGeneratedFile(path.Join(testsPath, "setup_test.go"), setup, utils.KeepExisting)
+
+	// a controller included under several namespaces gets a single test file
+	var keys []string
+	included := make(map[string]*includedController)
+	testCases := make(map[string][]*routeTest)
+	names := make(map[string]map[string]bool)
+	for _, c := range controllers {
+		if c.PkgPath != pkgPath && !strings.HasPrefix(c.PkgPath, pkgPath+"/") {
+			beeLogger.Log.Warnf("Skipping '%s' from package '%s' outside of the application", c.Name, c.PkgPath)
+			continue
+		}
+		dir := path.Join(currpath, strings.TrimPrefix(c.PkgPath, pkgPath))
+		tests := controllerRouteTests(dir, c)
+		if len(tests) == 0 {
+			beeLogger.Log.Warnf("Controller '%s' has no @router annotation", c.Name)
+			continue
+		}
+		key := c.PkgPath + "." + c.Name
+		if _, ok := included[key]; !ok {
+			keys = append(keys, key)
+			included[key] = c
+		}
+		testCases[key] = append(testCases[key], tests...)
+		if names[c.Name] == nil {
+			names[c.Name] = make(map[string]bool)
+		}
+		names[c.Name][c.PkgPath] = true
+	}
+
+	for _, key := range keys {
+		c := included[key]
+		name := c.Name
+		if len(names[c.Name]) > 1 {
+			// controllers of the same name in several packages are told apart by their package
+			name = utils.CamelCase(strings.Replace(strings.Trim(strings.TrimPrefix(c.PkgPath, pkgPath), "/"), "/", "_", -1)) + name
+		}
+		tests := testCases[key]
+		sort.SliceStable(tests, func(i, j int) bool { return tests[i].Path < tests[j].Path })
+		fpath := path.Join(testsPath, utils.SnakeString(strings.TrimSuffix(name, "Controller"))+"_test.go")
+		utils.WriteGeneratedFile(fpath, renderRouteTests(name, tests), utils.KeepExisting)
+	}
+	beeLogger.Log.Info("Every case expects 200 OK: set the status of the routes that fail without a database, " +
+		"or register one in tests/setup_test.go")
+}
+
+// parseRouterFile returns the controllers included in the router file through
+// Include or NSInclude, with the prefix of the namespaces they are nested in
+func parseRouterFile(routerFile string) []*includedController {
+	file, err := parser.ParseFile(token.NewFileSet(), routerFile, nil, 0)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not parse router file: %s", err)
+	}
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importPath
+	}
+
+	var controllers []*includedController
+	var walk func(expr ast.Expr, prefix string)
+	walk = func(expr ast.Expr, prefix string) {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return
+		}
+		switch callName(call) {
+		case "NewNamespace", "NSNamespace":
+			if len(call.Args) == 0 {
+				return
+			}
+			if s, ok := stringLiteral(call.Args[0]); ok {
+				prefix += s
+			}
+			for _, arg := range call.Args[1:] {
+				walk(arg, prefix)
+			}
+		case "Include", "NSInclude":
+			for _, arg := range call.Args {
+				pkg, name, ok := controllerLiteral(arg)
+				if !ok {
+					continue
+				}
+				controllers = append(controllers, &includedController{Name: name, PkgPath: imports[pkg], Prefix: prefix})
+			}
+		default:
+			for _, arg := range call.Args {
+				walk(arg, prefix)
+			}
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		stmt, ok := n.(*ast.ExprStmt)
+		if ok {
+			walk(stmt.X, "")
+			return false
+		}
+		assign, ok := n.(*ast.AssignStmt)
+		if ok {
+			for _, rhs := range assign.Rhs {
+				walk(rhs, "")
+			}
+			return false
+		}
+		return true
+	})
+	return controllers
+}
+
+// callName returns the name of the called function, without its package
+func callName(call *ast.CallExpr) string {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	}
+	return ""
+}
+
+// controllerLiteral returns the package and the type of &pkg.Controller{}
+func controllerLiteral(expr ast.Expr) (pkg, name string, ok bool) {
+	unary, ok := expr.(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND {
+		return "", "", false
+	}
+	lit, ok := unary.X.(*ast.CompositeLit)
+	if !ok {
+		return "", "", false
+	}
+	sel, ok := lit.Type.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", false
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", "", false
+	}
+	return ident.Name, sel.Sel.Name, true
+}
+
+// controllerRouteTests returns a test case for each route and HTTP method of
+// the @router annotations of the controller methods found in dir
+func controllerRouteTests(dir string, c *includedController) []*routeTest {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		beeLogger.Log.Fatalf("Could not parse controllers: %s", err)
+	}
+
+	var tests []*routeTest
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil || fn.Doc == nil {
+					continue
+				}
+				star, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+				if !ok || fmt.Sprint(star.X) != c.Name {
+					continue
+				}
+				comments, err := parseComment(fn.Doc.List)
+				if err != nil {
+					beeLogger.Log.Fatalf("Could not parse the annotations of '%s.%s': %s", c.Name, fn.Name.Name, err)
+				}
+				for _, pc := range comments {
+					for _, method := range pc.methods {
+						method = strings.ToUpper(strings.TrimSpace(method))
+						tests = append(tests, &routeTest{
+							Name:   fn.Name.Name,
+							Method: method,
+							Path:   routeTestPath(c.Prefix, pc),
+							Body:   method == "POST" || method == "PUT" || method == "PATCH",
+						})
+					}
+				}
+			}
+		}
+	}
+	return tests
+}
+
+// routeTestPath joins the namespace prefix and the route, replacing path
+// parameters with a sample value of their type and adding required query parameters
+func routeTestPath(prefix string, pc *parsedComment) string {
+	params := make(map[string]parsedParam)
+	for _, p := range pc.params {
+		params[p.name] = p
+	}
+
+	segments := strings.Split(prefix+pc.routerPath, "/")
+	for i, segment := range segments {
+		switch segment {
+		case "*":
+			segments[i] = "test"
+			continue
+		case "*.*":
+			segments[i] = "test.json"
+			continue
+		}
+		segments[i] = routeParamRegex.ReplaceAllStringFunc(segment, func(param string) string {
+			m := routeParamRegex.FindStringSubmatch(param)
+			p, ok := params[m[1]]
+			if !ok {
+				p = params[":"+m[1]]
+			}
+			if p.datatype == "" {
+				p.datatype = m[2]
+			}
+			return routeParamValue(p, m[3])
+		})
+	}
+	p := strings.Join(segments, "/")
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+
+	var query []string
+	for _, param := range params {
+		if param.location == "query" && param.required {
+			query = append(query, param.name+"="+sampleParamValue(param))
+		}
+	}
+	if len(query) > 0 {
+		sort.Strings(query)
+		p += "?" + strings.Join(query, "&")
+	}
+	return p
+}
+
+// routeParamRegex matches a path parameter of a route, with its optional type
+// and regular expression, as in :id, :id:int or :id([0-9]+)
+var routeParamRegex = regexp.MustCompile(`:(\w+)(?::(int|string))?(?:\(([^/]*)\))?`)
+
+// routeParamValue returns a sample value of a path parameter, matching the
+// regular expression the route restricts it with
+func routeParamValue(p parsedParam, expr string) string {
+	value := sampleParamValue(p)
+	if expr == "" {
+		return value
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return value
+	}
+	for _, v := range []string{value, "1", "test", "a", "A", "test.json"} {
+		if re.MatchString(v) {
+			return v
+		}
+	}
+	beeLogger.Log.Warnf("Could not find a value of path parameter ':%s' matching '%s'", p.name, expr)
+	return value
+}
+
+// sampleParamValue returns a placeholder value for a parameter of the given type
+func sampleParamValue(p parsedParam) string {
+	if p.defValue != "" {
+		return p.defValue
+	}
+	switch p.datatype {
+	case "int", "int64", "integer", "number", "float", "float64":
+		return "1"
+	case "bool", "boolean":
+		return "true"
+	}
+	return "test"
+}
+
+// renderRouteTests writes the test function of a controller
+func renderRouteTests(controllerName string, tests []*routeTest) string {
+	var cases strings.Builder
+	for _, t := range tests {
+		body := `""`
+		if t.Body {
+			body = "`{}`, // TODO: request body"
+		} else {
+			body += ","
+		}
+		fmt.Fprintf(&cases, "\t\t{\n\t\t\tname:   %q,\n\t\t\tmethod: %q,\n\t\t\tpath:   %q,\n\t\t\tbody:   %s\n\t\t\tstatus: http.StatusOK, // TODO: expected status code\n\t\t},\n",
+			t.Name+" "+t.Method+" "+t.Path, t.Method, t.Path, body)
+	}
+	content := strings.Replace(loadTemplate("tests/controller_test.go.tpl"), "{{controllerName}}", controllerName, -1)
+	return strings.Replace(content, "{{cases}}", cases.String(), -1)
+}
+
+var testSetupTpl = `package test
+
+import (
+	"path/filepath"
+	"runtime"
+
+	_ "{{pkgPath}}/routers"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+func init() {
+	_, file, _, _ := runtime.Caller(0)
+	apppath, _ := filepath.Abs(filepath.Dir(filepath.Join(file, ".."+string(filepath.Separator))))
+	beego.TestBeegoInit(apppath)
+}
+`
+
+var routeTestTpl = `package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+// Test{{controllerName}} runs a request against each route of {{controllerName}}
+func Test{{controllerName}}(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+{{cases}}	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.body != "" {
+				r.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			beego.BeeApp.Handlers.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("%s %s: got status %d, want %d\n%s", tt.method, tt.path, w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+`
@@ -0,0 +1,177 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+)
+
+func TestRouteTestPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		route    string
+		params   []parsedParam
+		expected string
+	}{
+		{"root", "", "/", nil, "/"},
+		{"prefix", "/v1/users", "/", nil, "/v1/users"},
+		{"annotated param", "", "/:id", []parsedParam{{name: "id", datatype: "int", location: "path"}}, "/1"},
+		{"annotated param with colon", "", "/:id", []parsedParam{{name: ":id", datatype: "int", location: "path"}}, "/1"},
+		{"untyped param", "", "/:name", nil, "/test"},
+		{"typed param", "", "/:id:int/:name:string", nil, "/1/test"},
+		{"regex param", "", "/:id([0-9]+)", nil, "/1"},
+		{"regex param of another type", "", "/:id([0-9]+)", []parsedParam{{name: "id", datatype: "string", location: "path"}}, "/1"},
+		{"regex letters", "", "/:code([a-z]+)", nil, "/test"},
+		{"param in a segment", "", "/cms_:id([0-9]+).html", nil, "/cms_1.html"},
+		{"default value", "", "/:id", []parsedParam{{name: "id", datatype: "int", location: "path", defValue: "42"}}, "/42"},
+		{"wildcards", "", "/files/*/download/*.*", nil, "/files/test/download/test.json"},
+		{"required query", "", "/search", []parsedParam{
+			{name: "q", datatype: "string", location: "query", required: true},
+			{name: "limit", datatype: "int", location: "query", required: true},
+			{name: "offset", datatype: "int", location: "query"},
+		}, "/search?limit=1&q=test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := &parsedComment{routerPath: tt.route, params: make(map[string]parsedParam)}
+			for _, p := range tt.params {
+				pc.params[p.name] = p
+			}
+			if got := routeTestPath(tt.prefix, pc); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+var testRouterFile = `package routers
+
+import (
+	"app/controllers"
+	v2 "app/controllers/v2"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+func init() {
+	ns := beego.NewNamespace("/v1",
+		beego.NSNamespace("/users", beego.NSInclude(&controllers.UserController{})),
+		beego.NSNamespace("/members", beego.NSInclude(&controllers.UserController{})),
+	)
+	beego.AddNamespace(ns)
+	beego.AddNamespace(beego.NewNamespace("/v2/users", beego.NSInclude(&v2.UserController{})))
+	beego.Include(&controllers.PostController{})
+}
+`
+
+var testControllerFiles = map[string]string{
+	"controllers/user.go": `package controllers
+
+type UserController struct{}
+
+// @router /:id([0-9]+) [get]
+func (c *UserController) Get() {}
+`,
+	"controllers/post.go": `package controllers
+
+type PostController struct{}
+
+// @Param body body models.Post true "post"
+// @router /posts [post]
+func (c *PostController) Post() {}
+`,
+	"controllers/v2/user.go": `package v2
+
+type UserController struct{}
+
+// @router /:name:string [get,delete]
+func (c *UserController) Get() {}
+`,
+}
+
+func TestGenerateTests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{"go.mod": "module app\n", "routers/router.go": testRouterFile}
+	for name, content := range testControllerFiles {
+		files[name] = content
+	}
+	for name, content := range files {
+		fpath := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fpath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	beeLogger.Log.SetOutput(&out)
+	GenerateTests("", dir)
+	beeLogger.Log.SetOutput(os.Stdout)
+	if !strings.Contains(out.String(), "run 'bee generate routers' too") {
+		t.Errorf("expected a hint to generate the routers of the annotations, got:\n%s", out.String())
+	}
+
+	expected := map[string][]string{
+		"setup_test.go": {`_ "app/routers"`},
+		// the controllers named UserController are told apart by their package,
+		// and the namespaces including the same controller share a file
+		"controllers_user_test.go":    {"func TestControllersUserController(", `"/v1/users/1"`, `"/v1/members/1"`},
+		"controllers_v2_user_test.go": {"func TestControllersV2UserController(", `"GET"`, `"DELETE"`, `"/v2/users/test"`},
+		"post_test.go":                {"func TestPostController(", `"POST"`, `"/posts"`, "`{}`"},
+	}
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "tests"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(expected) {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("expected %d test files, got %v", len(expected), names)
+	}
+	for name, contains := range expected {
+		content, err := ioutil.ReadFile(filepath.Join(dir, "tests", name))
+		if err != nil {
+			t.Errorf("expected the test file %s: %s", name, err)
+			continue
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), name, content, 0); err != nil {
+			t.Errorf("%s does not parse: %s", name, err)
+		}
+		for _, s := range contains {
+			if !strings.Contains(string(content), s) {
+				t.Errorf("expected %s to contain %s:\n%s", name, s, content)
+			}
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/beego/bee/v2/utils"
+)
+
+// uniqueField is a field of a model whose value can be used by a single row
+type uniqueField struct {
+	Field    string
+	Column   string
+	Type     string // Go type of the field, its basic type for an enum
+	Nullable bool   // the rows without a value do not clash
+}
+
+// validRules returns the rules of beego's validation package checking the values of
+// a column: Required when it is NOT NULL without a default, MaxSize for its size.
+// Numbers and booleans are not required, their zero value being a value. Relations
+// are left out, the validators only knowing the basic types.
+func (col *Column) validRules() []string {
+	tag := col.Tag
+	if tag == nil || tag.Auto || tag.Pk || tag.AutoNow || tag.AutoNowAdd {
+		return nil
+	}
+	if col.Enum != nil {
+		// the values, and the empty value beego's Required does not see in an enum
+		// type, are checked by the validate method of the controllers
+		if !col.Nullable && !col.HasDefault {
+			return []string{"Required"}
+		}
+		return nil
+	}
+	var rules []string
+	switch col.Type {
+	case "string":
+		if !col.Nullable && !col.HasDefault {
+			rules = append(rules, "Required")
+		}
+		if _, err := strconv.Atoi(tag.Size); err == nil {
+			rules = append(rules, "MaxSize("+tag.Size+")")
+		}
+	case "time.Time":
+		if !col.Nullable && !col.HasDefault {
+			rules = append(rules, "Required")
+		}
+	}
+	return rules
+}
+
+// uniqueFields returns the fields of the columns of tb with a unique index of their own
+func (tb *Table) uniqueFields() (fields []uniqueField) {
+	for _, col := range tb.Columns {
+		tag := col.Tag
+		if tag == nil || tag.Auto || tag.Pk || tag.RelFk || tag.RelOne || tag.ReverseOne || tag.ReverseMany || tag.RelM2M {
+			continue
+		}
+		if tag.Unique || tb.isUnique(tag.Column) {
+			typ := col.Type
+			if col.Enum != nil {
+				typ = "string"
+			}
+			fields = append(fields, uniqueField{Field: col.Name, Column: tag.Column, Type: typ, Nullable: col.Nullable})
+		}
+	}
+	return
+}
+
+// modelUniqueFields returns the fields of a model tagged unique, read from the models of modelsPath
+func modelUniqueFields(modelsPath, model string) (fields []uniqueField) {
+	for _, tb := range parseModels(modelsPath) {
+		if tb.Model != model {
+			continue
+		}
+		for _, col := range tb.Columns {
+			if col.Tag.Unique && !col.Tag.Pk && !col.Tag.Auto {
+				fields = append(fields, uniqueField{Field: col.Field, Column: col.Name, Type: col.GoType, Nullable: col.Tag.Null})
+			}
+		}
+	}
+	return
+}
+
+// fieldValidRules returns the rules of beego's validation package for a field of the
+// -fields option: the strings, texts and datetimes are created NOT NULL by the migrations
+func fieldValidRules(ktype string) []string {
+	kv := strings.SplitN(ktype, ":", 2)
+	switch kv[0] {
+	case "string":
+		size := "128"
+		if len(kv) == 2 {
+			size = kv[1]
+		}
+		return []string{"Required", "MaxSize(" + size + ")"}
+	case "text", "datetime":
+		return []string{"Required"}
+	}
+	return nil
+}
+
+// addStructTag adds a key to the struct tag of a field, empty or quoted with backquotes
+func addStructTag(tag, key, value string) string {
+	if value == "" {
+		return tag
+	}
+	if tag == "" {
+		return fmt.Sprintf("`%s:\"%s\"`", key, value)
+	}
+	return fmt.Sprintf("%s %s:\"%s\"`", strings.TrimSuffix(tag, "`"), key, value)
+}
+
+// renderUniqueChecks returns the code of the validate method of a controller checking
+// with getAll, a GetAll function of the model, that no other model has the values of
+// fields. The nullable fields are checked only when they have a value.
+func renderUniqueChecks(model, getAll string, fields []uniqueField) string {
+	var b strings.Builder
+	for _, f := range fields {
+		check := fmt.Sprintf(`if l, err := %s(uniqueQuery(%q, v.%s), nil, nil, nil, 0, 2); err == nil {
+	for _, m := range l {
+		if m.(models.%s).Id != v.Id {
+			valid.SetError(%q, %q).Name = "Unique"
+			break
+		}
+	}
+}
+`, getAll, f.Column, f.Field, model, f.Field, f.Field+" is already used")
+		indent := "\t"
+		cond := f.valueCond()
+		if cond != "" {
+			fmt.Fprintf(&b, "\tif %s {\n", cond)
+			indent = "\t\t"
+		}
+		for _, line := range strings.SplitAfter(strings.TrimSuffix(check, "\n"), "\n") {
+			b.WriteString(indent + line)
+		}
+		b.WriteString("\n")
+		if cond != "" {
+			b.WriteString("\t}\n")
+		}
+	}
+	return b.String()
+}
+
+// valueCond returns the condition of a nullable field having a value, other than
+// the zero value of its type, and an empty string for the other fields
+func (f uniqueField) valueCond() string {
+	if !f.Nullable {
+		return ""
+	}
+	v := "v." + f.Field
+	switch {
+	case f.Type == "time.Time":
+		return "!" + v + ".IsZero()"
+	case f.Type == "bool":
+		return v
+	case strings.Contains(f.Type, "int"), strings.HasPrefix(f.Type, "float"):
+		return v + " != 0"
+	}
+	return v + ` != ""`
+}
+
+// renderEnumChecks returns the code of the validate method of a controller checking
+// that the enum fields of tb hold one of their values, or are empty when not required
+func (tb *Table) renderEnumChecks() string {
+	var b strings.Builder
+	for _, col := range tb.Columns {
+		if col.Enum == nil {
+			continue
+		}
+		cond := fmt.Sprintf("!v.%s.Valid()", col.Name)
+		if len(col.validRules()) == 0 {
+			cond = fmt.Sprintf("v.%s != \"\" && %s", col.Name, cond)
+		}
+		fmt.Fprintf(&b, `	if %s {
+		valid.SetError(%q, %q).Name = "Valid"
+	}
+`, cond, col.Name, col.Name+" must be one of the values of "+col.Enum.Name)
+	}
+	return b.String()
+}
+
+// writeValidationHelpers writes the helpers of the validation of the controllers of a package
+func writeValidationHelpers(dir, packageName string) {
+	content := strings.Replace(loadTemplate("validation/errors.go.tpl"), "{{packageName}}", packageName, -1)
+	utils.WriteGeneratedFile(path.Join(dir, "validation.go"), content, utils.KeepExisting)
+}
+
+var validationTpl = `package {{packageName}}
+
+import (
+	"fmt"
+
+	"github.com/beego/beego/v2/core/validation"
+)
+
+// ValidationError is a field of a request breaking a validation rule
+type ValidationError struct {
+	Field   string ` + "`json:\"field\"`" + `
+	Rule    string ` + "`json:\"rule,omitempty\"`" + `
+	Message string ` + "`json:\"message\"`" + `
+}
+
+// ValidationErrors is the body of the 400 responses to invalid requests
+type ValidationErrors struct {
+	Errors []ValidationError ` + "`json:\"errors\"`" + `
+}
+
+// newValidationErrors returns the errors of a validation, nil if there are none
+func newValidationErrors(errs []*validation.Error) *ValidationErrors {
+	if len(errs) == 0 {
+		return nil
+	}
+	ve := &ValidationErrors{}
+	for _, err := range errs {
+		ve.Errors = append(ve.Errors, ValidationError{Field: err.Field, Rule: err.Name, Message: err.Message})
+	}
+	return ve
+}
+
+// uniqueQuery returns the query of GetAll matching the models with the value of a column
+func uniqueQuery(column string, value interface{}) map[string]string {
+	return map[string]string{column: fmt.Sprint(value)}
+}
+`
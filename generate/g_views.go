@@ -15,61 +15,504 @@
 package generate
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"path"
+	"strings"
 
 	beeLogger "github.com/beego/bee/v2/logger"
-	"github.com/beego/bee/v2/logger/colors"
 	"github.com/beego/bee/v2/utils"
 )
 
-// recipe
-// admin/recipe
-func GenerateView(viewpath, currpath string) {
-	w := colors.NewColorWriter(os.Stdout)
+// viewField is a field of the resource shown in the views
+type viewField struct {
+	Name  string
+	Label string
+	Type  string
+	Text  bool
+}
+
+// viewModel is the model struct the views of a resource are generated from
+type viewModel struct {
+	Name   string
+	IDType string
+	Fields []*viewField
+}
+
+// GenerateView writes the CRUD templates of a resource. The fields come from
+// the -fields option or, when it is empty, from the struct of the matching model.
+// When the model exists, a controller rendering the templates is written along,
+// with the routes the templates link to:
+//
+//	GET  /recipe             Index, index.tpl: .items, .hasPrev, .prevOffset, .hasNext, .nextOffset, .limit
+//	GET  /recipe/new         New, create.tpl
+//	POST /recipe             Create
+//	GET  /recipe/:id         Show, show.tpl: .item
+//	GET  /recipe/:id/edit    Edit, edit.tpl: .item
+//	POST /recipe/:id         Update
+//	POST /recipe/:id/delete  Remove
+//
+// All the templates get .xsrfdata, and the forms .error when saving fails.
+// viewpath is the resource, i.e. recipe or admin/recipe.
+func GenerateView(viewpath, fields, currpath string) {
+	generateView(viewpath, fields, currpath, utils.FailIfExists)
+}
 
+// generateView writes the views and their controller, with the given policy for
+// the existing files
+func generateView(viewpath, fields, currpath string, existing utils.ExistingPolicy) {
 	beeLogger.Log.Info("Generating view...")
 
+	model, modelErr := readViewModel(viewpath, currpath)
+	var viewFields []*viewField
+	var err error
+	if fields != "" {
+		viewFields, err = parseViewFields(fields)
+	} else if err = modelErr; err == nil {
+		viewFields = model.Fields
+	}
+	if err != nil {
+		beeLogger.Log.Hint("Give the fields of the resource, i.e. -fields=\"title:string,body:text\"")
+		beeLogger.Log.Fatalf("Could not read the fields of '%s': %s", viewpath, err)
+	}
+
 	absViewPath := path.Join(currpath, "views", viewpath)
-	err := os.MkdirAll(absViewPath, os.ModePerm)
+
+	_, name := path.Split(viewpath)
+	route := "/" + strings.Trim(viewpath, "/")
+	replacer := strings.NewReplacer("{{title}}", fieldLabel(utils.CamelString(name)), "{{route}}", route)
+	views := []struct {
+		name    string
+		content string
+	}{
+		{"index.tpl", indexView(viewFields)},
+		{"show.tpl", showView(viewFields)},
+		{"create.tpl", formView(viewFields, false)},
+		{"edit.tpl", formView(viewFields, true)},
+	}
+	for _, v := range views {
+		utils.WriteGeneratedFile(path.Join(absViewPath, v.name), replacer.Replace(v.content), existing)
+	}
+
+	if modelErr != nil {
+		beeLogger.Log.Warnf("Skipping the controller of the views, the model could not be read: %s", modelErr)
+		beeLogger.Log.Hintf("The views link to GET %s/new, GET %s/:id/edit, POST %s/:id and POST %s/:id/delete", route, route, route, route)
+		return
+	}
+	p, _ := path.Split(viewpath)
+	packageName := "controllers"
+	if p != "" {
+		packageName = path.Base(p)
+	}
+	content := renderViewController(packageName, path.Join(getPackagePath(currpath), "models", p), route, strings.Trim(viewpath, "/"), model, viewFields)
+	fpath := path.Join(currpath, "controllers", p, utils.SnakeString(model.Name)+"_view.go")
+	utils.WriteGeneratedFile(fpath, content, existing)
+	beeLogger.Log.Infof("Add beego.Include(&%s.%sViewController{}) to the router file to serve the views", packageName, model.Name)
+}
+
+// renderViewController writes the controller rendering the views of the model.
+// The checkboxes of the form are not sent when unchecked, so the bool fields
+// are reset before the form is parsed.
+func renderViewController(packageName, modelImport, route, viewPath string, model *viewModel, fields []*viewField) string {
+	types := make(map[string]string)
+	for _, f := range model.Fields {
+		types[f.Name] = f.Type
+	}
+	var resets strings.Builder
+	for _, f := range fields {
+		if f.Type == "bool" && types[f.Name] == "bool" {
+			fmt.Fprintf(&resets, "\tv.%s = false\n", f.Name)
+		}
+	}
+	return strings.NewReplacer(
+		"{{packageName}}", packageName,
+		"{{controllerName}}", model.Name,
+		"{{modelImport}}", modelImport,
+		"{{idType}}", model.IDType,
+		"{{route}}", route,
+		"{{viewPath}}", viewPath,
+		"{{resetBools}}", resets.String(),
+	).Replace(loadTemplate("views/controller.go.tpl"))
+}
+
+// parseViewFields reads the fields of the -fields option, i.e. title:string,body:text
+func parseViewFields(fields string) ([]*viewField, error) {
+	var viewFields []*viewField
+	for _, v := range strings.Split(fields, ",") {
+		kv := strings.SplitN(v, ":", 2)
+		if len(kv) != 2 {
+			return nil, errors.New("the fields format is wrong. Should be key:type,key:type " + v)
+		}
+		typ, _, _ := getType(kv[1])
+		if typ == "" {
+			return nil, errors.New("the fields format is wrong. Should be key:type,key:type " + v)
+		}
+		name := utils.CamelString(kv[0])
+		if name == "Id" {
+			continue
+		}
+		viewFields = append(viewFields, &viewField{
+			Name:  name,
+			Label: fieldLabel(name),
+			Type:  typ,
+			Text:  strings.HasPrefix(kv[1], "text"),
+		})
+	}
+	return viewFields, nil
+}
+
+// readViewModel reads the model struct named after the view, in the file
+// named after the model
+func readViewModel(viewpath, currpath string) (*viewModel, error) {
+	p, f := path.Split(viewpath)
+	modelName := utils.CamelCase(f)
+	fpath := path.Join(currpath, "models", p, utils.SnakeString(modelName)+".go")
+	file, err := parser.ParseFile(token.NewFileSet(), fpath, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	obj := file.Scope.Lookup(modelName)
+	if obj == nil {
+		return nil, fmt.Errorf("model '%s' not found in '%s'", modelName, fpath)
+	}
+	spec, ok := obj.Decl.(*ast.TypeSpec)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a struct", modelName)
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a struct", modelName)
+	}
+
+	model := &viewModel{Name: modelName}
+	for _, field := range st.Fields.List {
+		var tag string
+		if field.Tag != nil {
+			tag = field.Tag.Value
+		}
+		typ := fmt.Sprint(field.Type)
+		if sel, ok := field.Type.(*ast.SelectorExpr); ok {
+			typ = fmt.Sprint(sel.X) + "." + sel.Sel.Name
+		}
+		for _, name := range field.Names {
+			if name.Name == "Id" {
+				model.IDType = typ
+			}
+			// relations and the primary key are not edited in the forms
+			if !name.IsExported() || name.Name == "Id" || strings.Contains(tag, "rel(") || strings.Contains(tag, "reverse(") {
+				continue
+			}
+			model.Fields = append(model.Fields, &viewField{
+				Name:  name.Name,
+				Label: fieldLabel(name.Name),
+				Type:  typ,
+				Text:  strings.Contains(tag, "type(longtext)") || strings.Contains(tag, "type(text)"),
+			})
+		}
+	}
+	if model.IDType == "" {
+		return nil, fmt.Errorf("model '%s' has no Id field", modelName)
+	}
+	if len(model.Fields) == 0 {
+		return nil, fmt.Errorf("model '%s' has no field", modelName)
+	}
+	return model, nil
+}
+
+// fieldLabel turns a field name into a label, i.e. CreatedAt into Created At
+func fieldLabel(name string) string {
+	return strings.Title(strings.Replace(utils.SnakeString(name), "_", " ", -1))
+}
+
+// fieldValue renders the value of the field of item
+func fieldValue(item string, f *viewField) string {
+	if f.Type == "time.Time" {
+		return fmt.Sprintf(`{{dateformat %s.%s "2006-01-02 15:04:05"}}`, item, f.Name)
+	}
+	return fmt.Sprintf("{{%s.%s}}", item, f.Name)
+}
+
+// fieldInput renders the form input of the field, filled from .item on edit forms
+func fieldInput(f *viewField, edit bool) string {
+	value := func(v string) string {
+		if !edit {
+			return ""
+		}
+		return v
+	}
+	id := utils.SnakeString(f.Name)
+	switch {
+	case f.Text:
+		return fmt.Sprintf(`<textarea id="%s" name="%s" rows="5">%s</textarea>`, id, f.Name, value("{{.item."+f.Name+"}}"))
+	case f.Type == "bool":
+		return fmt.Sprintf(`<input type="checkbox" id="%s" name="%s" value="true"%s>`, id, f.Name, value("{{if .item."+f.Name+"}} checked{{end}}"))
+	case f.Type == "time.Time":
+		return fmt.Sprintf(`<input type="datetime-local" step="1" id="%s" name="%s"%s>`, id, f.Name,
+			value(` value="{{dateformat .item.`+f.Name+` "2006-01-02T15:04:05"}}"`))
+	case strings.HasPrefix(f.Type, "float"):
+		return fmt.Sprintf(`<input type="number" step="any" id="%s" name="%s"%s>`, id, f.Name, value(` value="{{.item.`+f.Name+`}}"`))
+	case strings.HasPrefix(f.Type, "int") || strings.HasPrefix(f.Type, "uint"):
+		return fmt.Sprintf(`<input type="number" step="1" id="%s" name="%s"%s>`, id, f.Name, value(` value="{{.item.`+f.Name+`}}"`))
+	}
+	return fmt.Sprintf(`<input type="text" id="%s" name="%s"%s>`, id, f.Name, value(` value="{{.item.`+f.Name+`}}"`))
+}
+
+func indexView(fields []*viewField) string {
+	var headers, cells strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&headers, "\n      <th>%s</th>", f.Label)
+		fmt.Fprintf(&cells, "\n      <td>%s</td>", fieldValue("", f))
+	}
+	return strings.NewReplacer("{{headers}}", headers.String(), "{{cells}}", cells.String()).Replace(loadTemplate("views/index.tpl"))
+}
+
+func showView(fields []*viewField) string {
+	var rows strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&rows, "\n  <dt>%s</dt>\n  <dd>%s</dd>", f.Label, fieldValue(".item", f))
+	}
+	return strings.Replace(loadTemplate("views/show.tpl"), "{{rows}}", rows.String(), -1)
+}
+
+func formView(fields []*viewField, edit bool) string {
+	var inputs strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&inputs, "\n  <p>\n    <label for=\"%s\">%s</label>\n    %s\n  </p>", utils.SnakeString(f.Name), f.Label, fieldInput(f, edit))
+	}
+	if edit {
+		return strings.Replace(loadTemplate("views/edit.tpl"), "{{inputs}}", inputs.String(), -1)
+	}
+	return strings.Replace(loadTemplate("views/create.tpl"), "{{inputs}}", inputs.String(), -1)
+}
+
+var indexViewTpl = `<h1>{{title}}</h1>
+
+<p><a href="{{route}}/new">New {{title}}</a></p>
+
+<table>
+  <thead>
+    <tr>{{headers}}
+      <th></th>
+    </tr>
+  </thead>
+  <tbody>
+    {{range .items}}
+    <tr>{{cells}}
+      <td>
+        <a href="{{route}}/{{.Id}}">Show</a>
+        <a href="{{route}}/{{.Id}}/edit">Edit</a>
+        <form action="{{route}}/{{.Id}}/delete" method="post">
+          {{$.xsrfdata}}
+          <button type="submit">Delete</button>
+        </form>
+      </td>
+    </tr>
+    {{end}}
+  </tbody>
+</table>
+
+<p>
+  {{if .hasPrev}}<a href="{{route}}?offset={{.prevOffset}}&limit={{.limit}}">Previous</a>{{end}}
+  {{if .hasNext}}<a href="{{route}}?offset={{.nextOffset}}&limit={{.limit}}">Next</a>{{end}}
+</p>
+`
+
+var showViewTpl = `<h1>{{title}} {{.item.Id}}</h1>
+
+<dl>{{rows}}
+</dl>
+
+<p>
+  <a href="{{route}}/{{.item.Id}}/edit">Edit</a>
+  <a href="{{route}}">Back</a>
+</p>
+
+<form action="{{route}}/{{.item.Id}}/delete" method="post">
+  {{.xsrfdata}}
+  <button type="submit">Delete</button>
+</form>
+`
+
+var createViewTpl = `<h1>New {{title}}</h1>
+
+{{if .error}}<p class="error">{{.error}}</p>{{end}}
+
+<form action="{{route}}" method="post">
+  {{.xsrfdata}}{{inputs}}
+  <button type="submit">Create</button>
+</form>
+
+<p><a href="{{route}}">Back</a></p>
+`
+
+var editViewTpl = `<h1>Edit {{title}} {{.item.Id}}</h1>
+
+{{if .error}}<p class="error">{{.error}}</p>{{end}}
+
+<form action="{{route}}/{{.item.Id}}" method="post">
+  {{.xsrfdata}}{{inputs}}
+  <button type="submit">Update</button>
+</form>
+
+<p><a href="{{route}}/{{.item.Id}}">Back</a></p>
+`
+
+var viewControllerTpl = `package {{packageName}}
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	models "{{modelImport}}"
+
+	beego "github.com/beego/beego/v2/server/web"
+
+	// bee:begin imports
+	// bee:end
+)
+
+// {{controllerName}}ViewController renders the views of {{controllerName}}
+type {{controllerName}}ViewController struct {
+	beego.Controller
+}
+
+// URLMapping ...
+func (c *{{controllerName}}ViewController) URLMapping() {
+	c.Mapping("Index", c.Index)
+	c.Mapping("New", c.New)
+	c.Mapping("Create", c.Create)
+	c.Mapping("Show", c.Show)
+	c.Mapping("Edit", c.Edit)
+	c.Mapping("Update", c.Update)
+	c.Mapping("Remove", c.Remove)
+
+	// bee:begin mappings
+	// bee:end
+}
+
+// Prepare gives the forms their XSRF field
+func (c *{{controllerName}}ViewController) Prepare() {
+	xsrf := ""
+	if c.EnableXSRF {
+		xsrf = c.XSRFFormHTML()
+	}
+	c.Data["xsrfdata"] = template.HTML(xsrf)
+}
+
+// Index lists the {{controllerName}} page by page
+// @router {{route}} [get]
+func (c *{{controllerName}}ViewController) Index() {
+	limit, _ := c.GetInt64("limit", 10)
+	offset, _ := c.GetInt64("offset", 0)
+	if limit <= 0 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	// an item more than the page tells whether there is a next page
+	l, err := models.GetAll{{controllerName}}(nil, nil, []string{"Id"}, []string{"asc"}, offset, limit+1)
 	if err != nil {
-		beeLogger.Log.Fatalf("Could not create '%s' view: %s", viewpath, err)
+		c.CustomAbort(http.StatusInternalServerError, err.Error())
 	}
+	c.Data["hasNext"] = int64(len(l)) > limit
+	if int64(len(l)) > limit {
+		l = l[:limit]
+	}
+	prevOffset := offset - limit
+	if prevOffset < 0 {
+		prevOffset = 0
+	}
+	c.Data["items"] = l
+	c.Data["limit"] = limit
+	c.Data["hasPrev"] = offset > 0
+	c.Data["prevOffset"] = prevOffset
+	c.Data["nextOffset"] = offset + limit
+	c.TplName = "{{viewPath}}/index.tpl"
+}
 
-	cfile := path.Join(absViewPath, "index.tpl")
-	if f, err := os.OpenFile(cfile, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0666); err == nil {
-		defer utils.CloseFile(f)
-		f.WriteString(cfile)
-		fmt.Fprintf(w, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", cfile, "\x1b[0m")
-	} else {
-		beeLogger.Log.Fatalf("Could not create view file: %s", err)
+// New renders the form creating a {{controllerName}}
+// @router {{route}}/new [get]
+func (c *{{controllerName}}ViewController) New() {
+	c.TplName = "{{viewPath}}/create.tpl"
+}
+
+// Create saves the {{controllerName}} of the form
+// @router {{route}} [post]
+func (c *{{controllerName}}ViewController) Create() {
+	var v models.{{controllerName}}
+	err := c.ParseForm(&v)
+	if err == nil {
+		_, err = models.Add{{controllerName}}(&v)
+	}
+	if err != nil {
+		c.Data["error"] = err.Error()
+		c.TplName = "{{viewPath}}/create.tpl"
+		return
 	}
+	c.Redirect(fmt.Sprintf("{{route}}/%v", v.Id), http.StatusSeeOther)
+}
+
+// Show renders a {{controllerName}}
+// @router {{route}}/:id([0-9]+) [get]
+func (c *{{controllerName}}ViewController) Show() {
+	c.Data["item"] = c.item()
+	c.TplName = "{{viewPath}}/show.tpl"
+}
 
-	cfile = path.Join(absViewPath, "show.tpl")
-	if f, err := os.OpenFile(cfile, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0666); err == nil {
-		defer utils.CloseFile(f)
-		f.WriteString(cfile)
-		fmt.Fprintf(w, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", cfile, "\x1b[0m")
-	} else {
-		beeLogger.Log.Fatalf("Could not create view file: %s", err)
+// Edit renders the form updating a {{controllerName}}
+// @router {{route}}/:id([0-9]+)/edit [get]
+func (c *{{controllerName}}ViewController) Edit() {
+	c.Data["item"] = c.item()
+	c.TplName = "{{viewPath}}/edit.tpl"
+}
+
+// Update saves the changes of the form to a {{controllerName}}
+// @router {{route}}/:id([0-9]+) [post]
+func (c *{{controllerName}}ViewController) Update() {
+	v := c.item()
+	id := v.Id
+{{resetBools}}	err := c.ParseForm(v)
+	v.Id = id
+	if err == nil {
+		err = models.Update{{controllerName}}ById(v)
+	}
+	if err != nil {
+		c.Data["error"] = err.Error()
+		c.Data["item"] = v
+		c.TplName = "{{viewPath}}/edit.tpl"
+		return
 	}
+	c.Redirect(fmt.Sprintf("{{route}}/%v", id), http.StatusSeeOther)
+}
 
-	cfile = path.Join(absViewPath, "create.tpl")
-	if f, err := os.OpenFile(cfile, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0666); err == nil {
-		defer utils.CloseFile(f)
-		f.WriteString(cfile)
-		fmt.Fprintf(w, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", cfile, "\x1b[0m")
-	} else {
-		beeLogger.Log.Fatalf("Could not create view file: %s", err)
+// Remove deletes a {{controllerName}}
+// @router {{route}}/:id([0-9]+)/delete [post]
+func (c *{{controllerName}}ViewController) Remove() {
+	if err := models.Delete{{controllerName}}(c.item().Id); err != nil {
+		c.CustomAbort(http.StatusInternalServerError, err.Error())
 	}
+	c.Redirect("{{route}}", http.StatusSeeOther)
+}
 
-	cfile = path.Join(absViewPath, "edit.tpl")
-	if f, err := os.OpenFile(cfile, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0666); err == nil {
-		defer utils.CloseFile(f)
-		f.WriteString(cfile)
-		fmt.Fprintf(w, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", cfile, "\x1b[0m")
-	} else {
-		beeLogger.Log.Fatalf("Could not create view file: %s", err)
+// item returns the {{controllerName}} of the :id parameter, responding 404 when there is none
+func (c *{{controllerName}}ViewController) item() *models.{{controllerName}} {
+	id, err := strconv.ParseInt(c.Ctx.Input.Param(":id"), 10, 64)
+	if err != nil {
+		c.Abort("404")
 	}
+	v, err := models.Get{{controllerName}}ById({{idType}}(id))
+	if err != nil {
+		c.Abort("404")
+	}
+	return v
 }
+
+// bee:begin custom
+// bee:end
+`
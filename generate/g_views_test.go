@@ -0,0 +1,222 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package generate
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testViewModel = `package models
+
+import "time"
+
+type BlogPost struct {
+	Id        int64     ` + "`orm:\"auto\"`" + `
+	Title     string    ` + "`orm:\"size(128)\"`" + `
+	Body      string    ` + "`orm:\"type(text)\"`" + `
+	Published bool
+	CreatedAt time.Time
+	Author    *Author   ` + "`orm:\"rel(fk)\"`" + `
+}
+
+type Author struct {
+	Id int64
+}
+
+func AddBlogPost(m *BlogPost) (int64, error) { return 0, nil }
+
+func GetBlogPostById(id int64) (*BlogPost, error) { return &BlogPost{Id: id}, nil }
+
+func GetAllBlogPost(query map[string]string, fields []string, sortby []string, order []string,
+	offset int64, limit int64) ([]interface{}, error) {
+	return nil, nil
+}
+
+func UpdateBlogPostById(m *BlogPost) error { return nil }
+
+func DeleteBlogPost(id int64) error { return nil }
+`
+
+// blogPost is the item the views are rendered with
+type blogPost struct {
+	Id        int64
+	Title     string
+	Body      string
+	Published bool
+	CreatedAt time.Time
+}
+
+// writeTestFiles writes files, by path relative to dir
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		fpath := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fpath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadViewModel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "views")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{"models/blog_post.go": testViewModel})
+
+	for _, viewpath := range []string{"blog_post", "BlogPost"} {
+		model, err := readViewModel(viewpath, dir)
+		if err != nil {
+			t.Fatalf("%s: %s", viewpath, err)
+		}
+		var fields []string
+		for _, f := range model.Fields {
+			fields = append(fields, f.Name+":"+f.Type)
+		}
+		if model.Name != "BlogPost" || model.IDType != "int64" ||
+			strings.Join(fields, ",") != "Title:string,Body:string,Published:bool,CreatedAt:time.Time" {
+			t.Errorf("%s: unexpected model %s %s %s", viewpath, model.Name, model.IDType, fields)
+		}
+	}
+	if _, err := readViewModel("comment", dir); err == nil {
+		t.Error("expected an error for a missing model")
+	}
+}
+
+// routerAnnotationRegex matches the @router annotations of the controller
+var routerAnnotationRegex = regexp.MustCompile(`@router (\S+) \[(\w+)\]`)
+
+// viewLinkRegex matches the links and the forms of the views
+var viewLinkRegex = regexp.MustCompile(`(href|action)="([^"?]+)[^"]*"`)
+
+func TestGenerateView(t *testing.T) {
+	dir, err := ioutil.TempDir("", "views")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{"go.mod": "module app\n", "models/blog_post.go": testViewModel})
+
+	GenerateView("blog_post", "", dir)
+
+	controller, err := ioutil.ReadFile(filepath.Join(dir, "controllers", "blog_post_view.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`models "app/models"`, "type BlogPostViewController struct", "v.Published = false", `"blog_post/edit.tpl"`} {
+		if !bytes.Contains(controller, []byte(s)) {
+			t.Errorf("expected the controller to contain %s:\n%s", s, controller)
+		}
+	}
+	routes := make(map[string]*regexp.Regexp)
+	for _, m := range routerAnnotationRegex.FindAllStringSubmatch(string(controller), -1) {
+		routes[strings.ToUpper(m[2])+" "+m[1]] = regexp.MustCompile("^" + strings.Replace(m[1], ":id([0-9]+)", "[0-9]+", -1) + "$")
+	}
+	if len(routes) != 7 {
+		t.Fatalf("expected 7 routes, got %v", routes)
+	}
+
+	// every link and form of the views goes to a route of the controller
+	item := blogPost{Id: 1, Title: "Hello", CreatedAt: time.Now()}
+	data := map[string]interface{}{
+		"items":      []interface{}{item},
+		"item":       &item,
+		"hasPrev":    true,
+		"prevOffset": 0,
+		"hasNext":    true,
+		"nextOffset": 20,
+		"limit":      10,
+		"xsrfdata":   template.HTML(`<input type="hidden" name="_xsrf" value="token" />`),
+		"error":      "",
+	}
+	funcs := template.FuncMap{"dateformat": func(t time.Time, layout string) string { return t.Format(layout) }}
+	for _, name := range []string{"index.tpl", "show.tpl", "create.tpl", "edit.tpl"} {
+		content, err := ioutil.ReadFile(filepath.Join(dir, "views", "blog_post", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl, err := template.New(name).Funcs(funcs).Parse(string(content))
+		if err != nil {
+			t.Fatalf("%s does not parse: %s", name, err)
+		}
+		var out bytes.Buffer
+		if err := tpl.Execute(&out, data); err != nil {
+			t.Fatalf("%s does not render: %s", name, err)
+		}
+		if strings.Contains(out.String(), "_method") {
+			t.Errorf("%s overrides the method of a form:\n%s", name, out.String())
+		}
+		for _, m := range viewLinkRegex.FindAllStringSubmatch(out.String(), -1) {
+			method := "GET"
+			if m[1] == "action" {
+				method = "POST"
+			}
+			found := false
+			for route, re := range routes {
+				if strings.HasPrefix(route, method+" ") && re.MatchString(m[2]) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("%s: no route for %s %s in %v", name, method, m[2], routes)
+			}
+		}
+	}
+}
+
+func TestViewControllerCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a package")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	// build inside the module, for the imports of the controller to resolve
+	dir, err := ioutil.TempDir(".", "views")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{"models/blog_post.go": testViewModel})
+
+	model, err := readViewModel("blog_post", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := renderViewController("controllers", "github.com/beego/bee/v2/generate/"+filepath.Base(dir)+"/models", "/blog_post", "blog_post", model, model.Fields)
+	if strings.Contains(content, "{{") {
+		t.Errorf("unexpected placeholder left in the controller:\n%s", content)
+	}
+	writeTestFiles(t, dir, map[string]string{"controllers/blog_post_view.go": content})
+
+	cmd := exec.Command(goBin, "build", "./...")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("could not build the controller: %s\n%s\n%s", err, out, content)
+	}
+}
@@ -18,8 +18,6 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
-	"os"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
@@ -34,6 +32,7 @@ import (
 	"github.com/beego/beego/v2/server/web/context/param"
 
 	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/utils"
 )
 
 var globalRouterTemplate = `package {{.routersDir}}
@@ -518,18 +517,6 @@ func genRouterCode(routersPath string) error {
 	}
 
 	if globalinfo != "" {
-
-		routersPathDir := filepath.Dir(routersPath)
-		err := os.MkdirAll(routersPathDir, os.ModePerm)
-		if err != nil {
-			return err
-		}
-		f, err := os.Create(routersPath)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-
 		routersDir := RouterPkg.String()
 		if len(routersDir) == 0{
 			routersDir = "routers"
@@ -537,13 +524,10 @@ func genRouterCode(routersPath string) error {
 
 		beeLogger.Log.Infof("using %s as routers file's package", routersDir)
 
-		content := strings.Replace(globalRouterTemplate, "{{.globalinfo}}", globalinfo, -1)
+		content := strings.Replace(loadTemplate("routers/comments_router.go.tpl"), "{{.globalinfo}}", globalinfo, -1)
 		content = strings.Replace(content, "{{.routersDir}}", routersDir, -1)
 		content = strings.Replace(content, "{{.globalimport}}", globalimport, -1)
-		_, err = f.WriteString(content)
-		if err != nil {
-			return err
-		}
+		utils.WriteGeneratedFile(routersPath, content, utils.OverwriteExisting)
 	}
 	return nil
 }
\ No newline at end of file
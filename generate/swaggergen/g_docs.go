@@ -61,6 +61,8 @@ var controllerComments map[string]string
 var importlist map[string]string
 var controllerList map[string]map[string]*swagger.Item //controllername Paths items
 var modelsList map[string]map[string]swagger.Schema
+var omitEmpty map[string]map[string]bool     //definition: json names of the omitempty fields
+var declaredTags map[*swagger.Operation]bool //operations with @Tags
 var rootapi swagger.Swagger
 var astPkgs []*ast.Package
 var pkgLoadedCache map[string]struct{}
@@ -106,6 +108,8 @@ func init() {
 	importlist = make(map[string]string)
 	controllerList = make(map[string]map[string]*swagger.Item)
 	modelsList = make(map[string]map[string]swagger.Schema)
+	omitEmpty = make(map[string]map[string]bool)
+	declaredTags = make(map[*swagger.Operation]bool)
 	astPkgs = make([]*ast.Package, 0)
 	pkgLoadedCache = make(map[string]struct{})
 }
@@ -173,8 +177,9 @@ func parsePackageFromDir(path string) error {
 	return nil
 }
 
-// GenerateDocs generates documentations for a given path.
-func GenerateDocs(curpath string) {
+// ParseDocs analyses the router file and the controllers of a given path, and
+// returns the documentation of the API.
+func ParseDocs(curpath string) *swagger.Swagger {
 	pkgspath := curpath
 	workspace := os.Getenv("BeeWorkspace")
 	if workspace != "" {
@@ -316,10 +321,11 @@ func GenerateDocs(curpath string) {
 											switch pp := sp.(type) {
 											case *ast.CallExpr:
 												if pp.Fun.(*ast.SelectorExpr).Sel.String() == "NSInclude" {
-													controllerName = analyseNSInclude(s, pp)
+													var tag string
+													controllerName, tag = analyseNSInclude(s, pp)
 													if v, ok := controllerComments[controllerName]; ok {
 														rootapi.Tags = append(rootapi.Tags, swagger.Tag{
-															Name:        strings.Trim(s, "/"),
+															Name:        tag,
 															Description: v,
 														})
 													}
@@ -327,10 +333,11 @@ func GenerateDocs(curpath string) {
 											}
 										}
 									} else if selname == "NSInclude" {
-										controllerName = analyseNSInclude("", pp)
+										var tag string
+										controllerName, tag = analyseNSInclude("", pp)
 										if v, ok := controllerComments[controllerName]; ok {
 											rootapi.Tags = append(rootapi.Tags, swagger.Tag{
-												Name:        controllerName, // if the NSInclude has no prefix, we use the controllername as the tag
+												Name:        tag, // if the NSInclude has no prefix, we use the controllername as the tag
 												Description: v,
 											})
 										}
@@ -344,27 +351,19 @@ func GenerateDocs(curpath string) {
 			}
 		}
 	}
-	os.Mkdir(path.Join(curpath, "swagger"), 0755)
-	fd, err := os.Create(path.Join(curpath, "swagger", "swagger.json"))
-	if err != nil {
-		panic(err)
-	}
-	fdyml, err := os.Create(path.Join(curpath, "swagger", "swagger.yml"))
-	if err != nil {
-		panic(err)
-	}
-	defer fdyml.Close()
-	defer fd.Close()
+	return &rootapi
+}
+
+// GenerateDocs generates documentations for a given path.
+func GenerateDocs(curpath string) {
+	ParseDocs(curpath)
 	dt, err := json.MarshalIndent(rootapi, "", "    ")
 	dtyml, erryml := yaml.Marshal(rootapi)
 	if err != nil || erryml != nil {
 		panic(err)
 	}
-	_, err = fd.Write(dt)
-	_, erryml = fdyml.Write(dtyml)
-	if err != nil || erryml != nil {
-		panic(err)
-	}
+	bu.WriteGeneratedFile(path.Join(curpath, "swagger", "swagger.json"), string(dt), bu.OverwriteExisting)
+	bu.WriteGeneratedFile(path.Join(curpath, "swagger", "swagger.yml"), string(dtyml), bu.OverwriteExisting)
 }
 
 // analyseNewNamespace returns version and the others params
@@ -382,8 +381,12 @@ func analyseNewNamespace(ce *ast.CallExpr) (first string, others []ast.Expr) {
 	return
 }
 
-func analyseNSInclude(baseurl string, ce *ast.CallExpr) string {
-	cname := ""
+// analyseNSInclude adds the routes of the included controller to the paths and
+// returns the controller name and the tag of its operations, which is the
+// namespace prefix, the controller name without one, or the first @Tags of
+// an operation.
+func analyseNSInclude(baseurl string, ce *ast.CallExpr) (cname, tag string) {
+	var declared string
 	for _, p := range ce.Args {
 		var x *ast.SelectorExpr
 		var p1 interface{} = p
@@ -405,31 +408,25 @@ func analyseNSInclude(baseurl string, ce *ast.CallExpr) string {
 		}
 		if apis, ok := controllerList[cname]; ok {
 			for rt, item := range apis {
-				tag := cname
+				tag = cname
 				if baseurl != "" {
-					rt = baseurl + rt
+					// the / route of a namespace is the path of the namespace
+					if rt == "/" {
+						rt = baseurl
+					} else {
+						rt = baseurl + rt
+					}
 					tag = strings.Trim(baseurl, "/")
 				}
-				if item.Get != nil {
-					item.Get.Tags = []string{tag}
-				}
-				if item.Post != nil {
-					item.Post.Tags = []string{tag}
-				}
-				if item.Put != nil {
-					item.Put.Tags = []string{tag}
-				}
-				if item.Patch != nil {
-					item.Patch.Tags = []string{tag}
-				}
-				if item.Head != nil {
-					item.Head.Tags = []string{tag}
-				}
-				if item.Delete != nil {
-					item.Delete.Tags = []string{tag}
-				}
-				if item.Options != nil {
-					item.Options.Tags = []string{tag}
+				for _, op := range []*swagger.Operation{item.Get, item.Post, item.Put, item.Patch, item.Head, item.Delete, item.Options} {
+					if op == nil {
+						continue
+					}
+					if declaredTags[op] {
+						declared = op.Tags[0]
+						continue
+					}
+					op.Tags = []string{tag}
 				}
 				if len(rootapi.Paths) == 0 {
 					rootapi.Paths = make(map[string]*swagger.Item)
@@ -439,7 +436,10 @@ func analyseNSInclude(baseurl string, ce *ast.CallExpr) string {
 			}
 		}
 	}
-	return cname
+	if declared != "" {
+		tag = declared
+	}
+	return cname, tag
 }
 
 func analyseControllerPkg(localName, pkgpath string) {
@@ -540,6 +540,53 @@ func peekNextSplitString(ss string) (s string, spacePos int) {
 	return
 }
 
+// parseResponse parses the code, the optional {object} or {array} schema and
+// the description of a @Success or @Failure comment.
+func parseResponse(ss, pkgpath, controllerName, funcName string) (string, swagger.Response) {
+	rs := swagger.Response{}
+	respCode, pos := peekNextSplitString(ss)
+	ss = strings.TrimSpace(ss[pos:])
+	respType, pos := peekNextSplitString(ss)
+	if respType == "{object}" || respType == "{array}" {
+		isArray := respType == "{array}"
+		ss = strings.TrimSpace(ss[pos:])
+		schemaName, pos := peekNextSplitString(ss)
+		if schemaName == "" {
+			beeLogger.Log.Fatalf("[%s.%s] Schema must follow {object} or {array}", controllerName, funcName)
+		}
+		if strings.HasPrefix(schemaName, "[]") {
+			schemaName = schemaName[2:]
+			isArray = true
+		}
+		schema := swagger.Schema{}
+		if sType, ok := basicTypes[schemaName]; ok {
+			typeFormat := strings.Split(sType, ":")
+			schema.Type = typeFormat[0]
+			schema.Format = typeFormat[1]
+		} else {
+			m, mod, realTypes := getModel(schemaName)
+			schema.Ref = "#/definitions/" + m
+			if _, ok := modelsList[pkgpath+controllerName]; !ok {
+				modelsList[pkgpath+controllerName] = make(map[string]swagger.Schema)
+			}
+			modelsList[pkgpath+controllerName][schemaName] = mod
+			appendModels(pkgpath, controllerName, realTypes)
+		}
+		if isArray {
+			rs.Schema = &swagger.Schema{
+				Type:  astTypeArray,
+				Items: &schema,
+			}
+		} else {
+			rs.Schema = &schema
+		}
+		rs.Description = strings.TrimSpace(ss[pos:])
+	} else {
+		rs.Description = strings.TrimSpace(ss)
+	}
+	return respCode, rs
+}
+
 // parse the func comments
 func parserComments(f *ast.FuncDecl, controllerName, pkgpath string) error {
 	var routerPath string
@@ -574,48 +621,7 @@ func parserComments(f *ast.FuncDecl, controllerName, pkgpath string) error {
 			} else if strings.HasPrefix(t, "@Summary") {
 				opts.Summary = strings.TrimSpace(t[len("@Summary"):])
 			} else if strings.HasPrefix(t, "@Success") {
-				ss := strings.TrimSpace(t[len("@Success"):])
-				rs := swagger.Response{}
-				respCode, pos := peekNextSplitString(ss)
-				ss = strings.TrimSpace(ss[pos:])
-				respType, pos := peekNextSplitString(ss)
-				if respType == "{object}" || respType == "{array}" {
-					isArray := respType == "{array}"
-					ss = strings.TrimSpace(ss[pos:])
-					schemaName, pos := peekNextSplitString(ss)
-					if schemaName == "" {
-						beeLogger.Log.Fatalf("[%s.%s] Schema must follow {object} or {array}", controllerName, funcName)
-					}
-					if strings.HasPrefix(schemaName, "[]") {
-						schemaName = schemaName[2:]
-						isArray = true
-					}
-					schema := swagger.Schema{}
-					if sType, ok := basicTypes[schemaName]; ok {
-						typeFormat := strings.Split(sType, ":")
-						schema.Type = typeFormat[0]
-						schema.Format = typeFormat[1]
-					} else {
-						m, mod, realTypes := getModel(schemaName)
-						schema.Ref = "#/definitions/" + m
-						if _, ok := modelsList[pkgpath+controllerName]; !ok {
-							modelsList[pkgpath+controllerName] = make(map[string]swagger.Schema)
-						}
-						modelsList[pkgpath+controllerName][schemaName] = mod
-						appendModels(pkgpath, controllerName, realTypes)
-					}
-					if isArray {
-						rs.Schema = &swagger.Schema{
-							Type:  astTypeArray,
-							Items: &schema,
-						}
-					} else {
-						rs.Schema = &schema
-					}
-					rs.Description = strings.TrimSpace(ss[pos:])
-				} else {
-					rs.Description = strings.TrimSpace(ss)
-				}
+				respCode, rs := parseResponse(strings.TrimSpace(t[len("@Success"):]), pkgpath, controllerName, funcName)
 				opts.Responses[respCode] = rs
 			} else if strings.HasPrefix(t, "@Param") {
 				para := swagger.Parameter{}
@@ -695,23 +701,14 @@ func parserComments(f *ast.FuncDecl, controllerName, pkgpath string) error {
 				}
 				opts.Parameters = append(opts.Parameters, para)
 			} else if strings.HasPrefix(t, "@Failure") {
-				rs := swagger.Response{}
-				st := strings.TrimSpace(t[len("@Failure"):])
-				var cd []rune
-				var start bool
-				for i, s := range st {
-					if unicode.IsSpace(s) {
-						if start {
-							rs.Description = strings.TrimSpace(st[i+1:])
-							break
-						} else {
-							continue
-						}
+				respCode, rs := parseResponse(strings.TrimSpace(t[len("@Failure"):]), pkgpath, controllerName, funcName)
+				opts.Responses[respCode] = rs
+			} else if strings.HasPrefix(t, "@Tags") {
+				for _, tag := range strings.Split(strings.TrimSpace(t[len("@Tags"):]), ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						opts.Tags = append(opts.Tags, tag)
 					}
-					start = true
-					cd = append(cd, s)
 				}
-				opts.Responses[string(cd)] = rs
 			} else if strings.HasPrefix(t, "@Deprecated") {
 				opts.Deprecated, _ = strconv.ParseBool(strings.TrimSpace(t[len("@Deprecated"):]))
 			} else if strings.HasPrefix(t, "@Accept") {
@@ -768,6 +765,9 @@ func parserComments(f *ast.FuncDecl, controllerName, pkgpath string) error {
 			controllerList[pkgpath+controllerName] = make(map[string]*swagger.Item)
 			item = &swagger.Item{}
 		}
+		if len(opts.Tags) > 0 {
+			declaredTags[&opts] = true
+		}
 		for _, hm := range strings.Split(HTTPMethod, ",") {
 			switch hm {
 			case "GET":
@@ -1000,14 +1000,14 @@ func parseObject(imports []*ast.ImportSpec, d *ast.Object, k string, m *swagger.
 			}
 		}
 	case *ast.Ident:
-		parseIdent(t, k, m, astPkgs)
+		parseIdent(t, k, m, astPkgs, packageName)
 	case *ast.StructType:
 		parseStruct(imports, t, k, m, realTypes, astPkgs, packageName)
 	}
 }
 
 // parse as enum, in the package, find out all consts with the same type
-func parseIdent(st *ast.Ident, k string, m *swagger.Schema, astPkgs []*ast.Package) {
+func parseIdent(st *ast.Ident, k string, m *swagger.Schema, astPkgs []*ast.Package, packageName string) {
 	m.Title = k
 	basicType := fmt.Sprint(st)
 	if object, isStdLibObject := stdlibObject[basicType]; isStdLibObject {
@@ -1021,6 +1021,9 @@ func parseIdent(st *ast.Ident, k string, m *swagger.Schema, astPkgs []*ast.Packa
 	enums := make(map[int]string)
 	enumValues := make(map[int]interface{})
 	for _, pkg := range astPkgs {
+		if pkg.Name != packageName {
+			continue
+		}
 		for _, fl := range pkg.Files {
 			for _, obj := range fl.Scope.Objects {
 				if obj.Kind == ast.Con {
@@ -1128,12 +1131,16 @@ func parseStruct(imports []*ast.ImportSpec, st *ast.StructType, k string, m *swa
 				if sType == astTypeObject {
 					isObject = true
 					mp.Ref = "#/definitions/" + realType
+					if _, ok := field.Type.(*ast.MapType); ok {
+						mp = swagger.Propertie{Type: astTypeObject, AdditionalProperties: &swagger.Propertie{Ref: mp.Ref}}
+					}
 				} else if isBasicType(realType) {
 					typeFormat := strings.Split(sType, ":")
 					mp.Type = typeFormat[0]
 					mp.Format = typeFormat[1]
 				} else if realType == astTypeMap {
 					typeFormat := strings.Split(sType, ":")
+					mp.Type = astTypeObject
 					mp.AdditionalProperties = &swagger.Propertie{
 						Type:   typeFormat[0],
 						Format: typeFormat[1],
@@ -1201,6 +1208,11 @@ func parseStruct(imports []*ast.ImportSpec, st *ast.StructType, k string, m *swa
 					if required := stag.Get("required"); strings.EqualFold(required, "true") {
 						m.Required = append(m.Required, name)
 					}
+					for i := 1; i < len(tagValues); i++ {
+						if tagValues[i] == "omitempty" {
+							setOmitEmpty(packageName+"."+k, name)
+						}
+					}
 					if desc := stag.Get("description"); desc != "" {
 						mp.Description = desc
 					}
@@ -1251,6 +1263,9 @@ func parseStruct(imports []*ast.ImportSpec, st *ast.StructType, k string, m *swa
 					}
 					for name, p := range nm.Properties {
 						m.Properties[name] = p
+						if OmitEmpty(realType, name) {
+							setOmitEmpty(packageName+"."+k, name)
+						}
 					}
 					m.Required = append(m.Required, nm.Required...)
 					continue
@@ -1287,7 +1302,14 @@ func typeAnalyser(f *ast.Field) (isSlice bool, realType, swaggerType string) {
 		}
 		return false, basicType, astTypeObject
 	case *ast.MapType:
+		if _, ok := t.Value.(*ast.InterfaceType); ok {
+			// values of any type have an empty schema
+			return false, astTypeMap, ":"
+		}
 		val := fmt.Sprintf("%v", t.Value)
+		if star, ok := t.Value.(*ast.StarExpr); ok {
+			val = fmt.Sprint(star.X)
+		}
 		if isBasicType(val) {
 			return false, astTypeMap, basicTypes[val]
 		}
@@ -1305,6 +1327,19 @@ func typeAnalyser(f *ast.Field) (isSlice bool, realType, swaggerType string) {
 	return false, basicType, astTypeObject
 }
 
+func setOmitEmpty(definition, property string) {
+	if omitEmpty[definition] == nil {
+		omitEmpty[definition] = make(map[string]bool)
+	}
+	omitEmpty[definition][property] = true
+}
+
+// OmitEmpty reports whether a property of a model parsed by ParseDocs has the
+// omitempty json option, i.e. whether it may be missing from the JSON of the model
+func OmitEmpty(definition, property string) bool {
+	return omitEmpty[definition][property]
+}
+
 func isBasicType(Type string) bool {
 	if _, ok := basicTypes[Type]; ok {
 		return true
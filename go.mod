@@ -11,7 +11,9 @@ require (
 	github.com/go-sql-driver/mysql v1.7.0
 	github.com/gorilla/websocket v1.4.2
 	github.com/lib/pq v1.10.5
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/pelletier/go-toml v1.9.2
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/shopspring/decimal v1.3.1
 	github.com/smartwalle/pongo2render v1.0.1
 	github.com/spf13/viper v1.7.0
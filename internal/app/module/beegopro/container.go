@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/beego/bee/v2/config"
 	"github.com/beego/bee/v2/internal/pkg/git"
 	"github.com/beego/bee/v2/internal/pkg/system"
 	beeLogger "github.com/beego/bee/v2/logger"
@@ -18,6 +19,7 @@ import (
 )
 
 var GitRemotePath utils.DocValue
+var DatabaseEnv utils.DocValue
 
 const MDateFormat = "20060102_150405"
 
@@ -53,7 +55,7 @@ var DefaultBeegoPro = &Container{
 	TmplOption:       TmplOption{},
 	CurPath:          system.CurrentDir,
 	EnableModules:    make(map[string]interface{}), // get the user configuration, get the enable module result
-	FunctionOnce:     make(map[string]sync.Once),   // get the tmpl configuration, get the function once result
+	FunctionOnce:     make(map[string]*sync.Once),  // get the tmpl configuration, get the function once result
 }
 
 func (c *Container) Run() {
@@ -86,6 +88,20 @@ func (c *Container) initUserOption() {
 		viper.Debug()
 	}
 
+	// the Beefile database environment replaces the dsn when asked for, or when there is none
+	if DatabaseEnv != "" || c.UserOption.Dsn == "" {
+		if err := config.SelectDatabase(DatabaseEnv.String()); err != nil {
+			if DatabaseEnv != "" {
+				beeLogger.Log.Fatal(err.Error())
+			}
+			beeLogger.Log.Warn(err.Error())
+		}
+		if config.Conf.Database.Conn != "" {
+			c.UserOption.Driver = config.Conf.Database.Driver
+			c.UserOption.Dsn = config.Conf.Database.Conn
+		}
+	}
+
 	if c.UserOption.EnableGomod {
 		if !utils.IsExist(c.GoModFile) {
 			beeLogger.Log.Fatalf("go mod not exist, please create go mod file")
@@ -135,7 +151,7 @@ func (c *Container) initTemplateOption() {
 
 	for _, value := range c.TmplOption.Descriptor {
 		if value.Once {
-			c.FunctionOnce[value.SrcName] = sync.Once{}
+			c.FunctionOnce[value.SrcName] = &sync.Once{}
 		}
 	}
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/beego/bee/v2/internal/pkg/system"
 	beeLogger "github.com/beego/bee/v2/logger"
+	bu "github.com/beego/bee/v2/utils"
 )
 
 // render
@@ -143,6 +144,14 @@ func (r *RenderFile) Exec(name string) {
 	}
 
 	if FileContentChange(orgContent, output, GetSeg(ext)) {
+		if bu.DryRun {
+			policy := bu.KeepExisting
+			if len(orgContent) == 0 || isNeedOverwrite(r.FlushFile) {
+				policy = bu.OverwriteExisting
+			}
+			bu.WriteGeneratedFile(r.FlushFile, string(output), policy)
+			return
+		}
 		err = r.write(r.FlushFile, output)
 		if err != nil {
 			beeLogger.Log.Fatalf("Could not create file: %s", err)
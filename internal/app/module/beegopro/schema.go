@@ -23,7 +23,7 @@ type Container struct {
 	TmplOption       TmplOption             // tmpl option
 	CurPath          string                 // user current path
 	EnableModules    map[string]interface{} // beego pro provider a collection of module
-	FunctionOnce     map[string]sync.Once   // exec function once
+	FunctionOnce     map[string]*sync.Once  // exec function once
 	Timestamp        Timestamp
 	GenerateTime     string
 	GenerateTimeUnix int64
@@ -13,11 +13,12 @@ import (
 
 	"github.com/beego/bee/v2/internal/pkg/utils"
 	beeLogger "github.com/beego/bee/v2/logger"
+	bu "github.com/beego/bee/v2/utils"
 )
 
 // write to file
 func (c *RenderFile) write(filename string, buf []byte) (err error) {
-	if utils.IsExist(filename) && !isNeedOverwrite(filename) {
+	if utils.IsExist(filename) && (bu.SkipExisting || !bu.Force && !isNeedOverwrite(filename)) {
 		return
 	}
 
@@ -38,6 +39,9 @@ func (c *RenderFile) write(filename string, buf []byte) (err error) {
 	name := path.Base(filename)
 
 	if utils.IsExist(filename) {
+		if org, err := ioutil.ReadFile(filename); err == nil {
+			buf = []byte(bu.KeepCustomRegions(filename, string(org), string(buf)))
+		}
 		bakName := fmt.Sprintf("%s/%s.%s.bak", filePathBak, filepath.Base(name), time.Now().Format("2006.01.02.15.04.05"))
 		beeLogger.Log.Infof("bak file '%s'", bakName)
 		if err := os.Rename(filename, bakName); err != nil {
@@ -0,0 +1,11 @@
+//go:build cgo
+// +build cgo
+
+package sqlite
+
+import (
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Supported reports whether the SQLite driver works in this build
+const Supported = true
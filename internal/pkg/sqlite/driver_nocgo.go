@@ -0,0 +1,7 @@
+//go:build !cgo
+// +build !cgo
+
+package sqlite
+
+// Supported reports whether the SQLite driver works in this build
+const Supported = false
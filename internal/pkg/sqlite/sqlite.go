@@ -0,0 +1,21 @@
+// Package sqlite registers the SQLite database driver, which needs cgo
+package sqlite
+
+import beeLogger "github.com/beego/bee/v2/logger"
+
+// DriverName is the name the SQLite driver registers with database/sql
+const DriverName = "sqlite3"
+
+// IsSQLite reports whether driver names SQLite
+func IsSQLite(driver string) bool {
+	return driver == "sqlite" || driver == DriverName
+}
+
+// Check fails when driver is SQLite and bee was built without cgo, the SQLite
+// driver being a stub then
+func Check(driver string) {
+	if IsSQLite(driver) && !Supported {
+		beeLogger.Log.Hint("Build bee with CGO_ENABLED=1 and a C compiler installed to use SQLite")
+		beeLogger.Log.Fatal("SQLite is not supported by this build of bee: it was built without cgo")
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"text/template"
@@ -51,8 +53,9 @@ var logLevel = levelInfo
 
 // BeeLogger logs logging records to the specified io.Writer
 type BeeLogger struct {
-	mu     sync.Mutex
-	output io.Writer
+	mu      sync.Mutex
+	output  io.Writer
+	secrets []string
 }
 
 // LogRecord represents a log record and contains the timestamp when the record
@@ -108,6 +111,39 @@ func (l *BeeLogger) SetOutput(w io.Writer) {
 	l.output = colors.NewColorWriter(w)
 }
 
+// minSecretLength is the length below which a secret is only masked where it
+// stands as the password of a connection string, for short passwords like
+// root not to mask unrelated words
+const minSecretLength = 6
+
+// AddSecret registers a value, such as a password, that is masked in log messages
+func (l *BeeLogger) AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.secrets = append(l.secrets, secret)
+	// mask the longest values first, in case one contains another
+	sort.Slice(l.secrets, func(i, j int) bool { return len(l.secrets[i]) > len(l.secrets[j]) })
+}
+
+// mask replaces the registered secrets in message. The lock must be held.
+func (l *BeeLogger) mask(message string) string {
+	for _, secret := range l.secrets {
+		if len(secret) >= minSecretLength {
+			message = strings.Replace(message, secret, "******", -1)
+			continue
+		}
+		message = strings.NewReplacer(
+			":"+secret+"@", ":******@",
+			"password="+secret, "password=******",
+			"password='"+secret+"'", "password='******'",
+		).Replace(message)
+	}
+	return message
+}
+
 // Now returns the current local time in the specified layout
 func Now(layout string) string {
 	return time.Now().Format(layout)
@@ -178,7 +214,7 @@ func (l *BeeLogger) mustLog(level int, message string, args ...interface{}) {
 	record := LogRecord{
 		ID:      fmt.Sprintf("%04d", atomic.AddUint64(&sequenceNo, 1)),
 		Level:   l.getColorLevel(level),
-		Message: fmt.Sprintf(message, args...),
+		Message: l.mask(fmt.Sprintf(message, args...)),
 	}
 
 	err := logRecordTemplate.Execute(l.output, record)
@@ -197,11 +233,15 @@ func (l *BeeLogger) mustLogDebug(message string, file string, line int, args ...
 	// Change the output to Stderr
 	l.SetOutput(os.Stderr)
 
+	l.mu.Lock()
+	message = l.mask(fmt.Sprintf(message, args...))
+	l.mu.Unlock()
+
 	// Create the log record
 	record := LogRecord{
 		ID:       fmt.Sprintf("%04d", atomic.AddUint64(&sequenceNo, 1)),
 		Level:    l.getColorLevel(levelDebug),
-		Message:  fmt.Sprintf(message, args...),
+		Message:  message,
 		LineNo:   line,
 		Filename: filepath.Base(file),
 	}
@@ -27,6 +27,7 @@ import (
 func main() {
 	utils.NoticeUpdateBee()
 	flag.Usage = cmd.Usage
+	utils.AddWriteFlags(flag.CommandLine)
 	flag.Parse()
 	log.SetFlags(0)
 
@@ -0,0 +1,216 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package utils
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+	"github.com/beego/bee/v2/logger/colors"
+)
+
+// Policies of the generators for the files they write, set by the -dry-run, -force
+// and -skip-existing flags
+var (
+	// DryRun prints a diff of the files instead of writing them
+	DryRun bool
+	// Force overwrites the existing files
+	Force bool
+	// SkipExisting keeps the existing files unchanged
+	SkipExisting bool
+)
+
+// ExistingPolicy is what a generator does with a file that already exists, unless
+// -force or -skip-existing is given
+type ExistingPolicy int
+
+const (
+	// FailIfExists stops the generator
+	FailIfExists ExistingPolicy = iota
+	// AskIfExists asks whether to overwrite the file
+	AskIfExists
+	// OverwriteExisting replaces the file
+	OverwriteExisting
+	// KeepExisting leaves the file unchanged
+	KeepExisting
+	// MergeExisting merges the changes made to the file since it was last generated
+	// with the new version, and asks whether to overwrite a file it did not generate
+	MergeExisting
+)
+
+// AddWriteFlags adds the -dry-run, -force and -skip-existing flags to the flags of a command
+func AddWriteFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&DryRun, "dry-run", DryRun, "Print a diff of the files that would be written, without writing them.")
+	fs.BoolVar(&Force, "force", Force, "Overwrite the existing files.")
+	fs.BoolVar(&SkipExisting, "skip-existing", SkipExisting, "Keep the existing files unchanged.")
+}
+
+// WriteGeneratedFile writes a file of a generator, creating its directory. Go sources
+// are formatted. The protected regions of an existing file are kept and, with
+// MergeExisting, the generated version is cached to merge the next one with.
+// With -dry-run, the diff of the file is printed instead. It reports whether the
+// file was, or would be, written.
+func WriteGeneratedFile(fpath, content string, existing ExistingPolicy) bool {
+	if strings.HasSuffix(fpath, ".go") {
+		if src, err := format.Source([]byte(content)); err == nil {
+			content = string(src)
+		}
+	}
+
+	generated := content
+	merge := existing == MergeExisting
+	action := "create"
+	conflicts := false
+	old, err := ioutil.ReadFile(fpath)
+	if err == nil {
+		content = KeepCustomRegions(fpath, string(old), content)
+		if base, ok := readGeneratedBase(fpath); merge && ok && !Force {
+			// the file was generated before: merge its changes with the new version
+			content, conflicts = MergeGenerated(base, string(old), content)
+			existing = OverwriteExisting
+			action = "merge"
+		}
+		if string(old) == content {
+			printFileAction("identical", fpath)
+			if merge && !DryRun {
+				saveGeneratedBase(fpath, generated)
+			}
+			return false
+		}
+		switch {
+		case Force:
+			existing = OverwriteExisting
+		case SkipExisting:
+			existing = KeepExisting
+		}
+		switch existing {
+		case KeepExisting:
+			printFileAction("skip", fpath)
+			return false
+		case FailIfExists:
+			if !DryRun {
+				beeLogger.Log.Fatalf("'%s' already exists. Use -force to overwrite it or -skip-existing to keep it", fpath)
+			}
+			action = "conflict"
+		case AskIfExists, MergeExisting:
+			if !DryRun {
+				beeLogger.Log.Warnf("'%s' already exists. Do you want to overwrite it? [Yes|No] ", fpath)
+				if !AskForConfirmation() {
+					beeLogger.Log.Warnf("Skipped create file '%s'", fpath)
+					return false
+				}
+			}
+			action = "overwrite"
+		default:
+			if action == "create" {
+				action = "overwrite"
+			}
+		}
+	}
+	if conflicts {
+		action = "conflict"
+	}
+
+	if DryRun {
+		printFileAction(action, fpath)
+		PrintFileDiff(os.Stdout, fpath, string(old), content)
+		return action != "conflict" || conflicts
+	}
+	if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+		beeLogger.Log.Fatalf("Could not create directory: %s", err)
+	}
+	if err := ioutil.WriteFile(fpath, []byte(content), 0666); err != nil {
+		beeLogger.Log.Fatalf("Could not write '%s': %s", fpath, err)
+	}
+	if merge {
+		saveGeneratedBase(fpath, generated)
+	}
+	printFileAction(action, fpath)
+	if conflicts {
+		beeLogger.Log.Warnf("'%s' has conflicts between your changes and the generated code. Resolve them between the <<<<<<< and >>>>>>> markers", fpath)
+	}
+	return true
+}
+
+// CreateGeneratedDir creates a directory of a generator, unless it exists or -dry-run is given
+func CreateGeneratedDir(dir string) {
+	if IsExist(dir) {
+		return
+	}
+	if !DryRun {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			beeLogger.Log.Fatalf("Could not create directory: %s", err)
+		}
+	}
+	printFileAction("create", dir+string(filepath.Separator))
+}
+
+// PrintFileDiff prints the unified diff of the old and new content of a file. The old
+// content of a new file is empty.
+func PrintFileDiff(w io.Writer, fpath, old, content string) {
+	name := fpath
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, fpath); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	from, a := "a/"+filepath.ToSlash(name), diffLines(old)
+	if old == "" {
+		from, a = "/dev/null", nil
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        a,
+		B:        diffLines(content),
+		FromFile: from,
+		ToFile:   "b/" + filepath.ToSlash(name),
+		Context:  3,
+	})
+	if err != nil {
+		beeLogger.Log.Warnf("Could not diff '%s': %s", fpath, err)
+		return
+	}
+	fmt.Fprint(w, diff)
+}
+
+// diffLines splits s in lines keeping their newline; unlike difflib.SplitLines,
+// a final newline does not add an empty line
+func diffLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func printFileAction(action, fpath string) {
+	color := "\x1b[32m"
+	switch action {
+	case "identical", "skip":
+		color = "\x1b[33m"
+	case "conflict":
+		color = "\x1b[31m"
+	}
+	w := colors.NewColorWriter(os.Stdout)
+	fmt.Fprintf(w, "\t%s%s%s%s\t %s%s\n", color, "\x1b[1m", action, "\x1b[21m", fpath, "\x1b[0m")
+}
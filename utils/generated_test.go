@@ -0,0 +1,237 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package utils
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStdio runs f with input as the standard input, and returns what it printed
+func captureStdio(t *testing.T, input string, f func()) string {
+	stdin, stdout := os.Stdin, os.Stdout
+	defer func() { os.Stdin, os.Stdout = stdin, stdout }()
+
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inR.Close()
+	if _, err := inW.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	inW.Close()
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outR.Close()
+	os.Stdin, os.Stdout = inR, outW
+
+	out := make(chan string)
+	go func() {
+		var b bytes.Buffer
+		io.Copy(&b, outR)
+		out <- b.String()
+	}()
+	f()
+	outW.Close()
+	return <-out
+}
+
+func TestWriteGeneratedFile(t *testing.T) {
+	const old, content = "old\n", "new\n"
+	policies := map[ExistingPolicy]string{
+		FailIfExists:      "fail",
+		AskIfExists:       "ask",
+		OverwriteExisting: "overwrite",
+		KeepExisting:      "keep",
+	}
+	tests := []struct {
+		policy       ExistingPolicy
+		exists       bool
+		dryRun       bool
+		force        bool
+		skipExisting bool
+		answer       string
+		written      bool
+		expected     string // content of the file after, empty when there is none
+	}{
+		// a new file is written whatever the policy, unless -dry-run is given
+		{policy: FailIfExists, written: true, expected: content},
+		{policy: AskIfExists, written: true, expected: content},
+		{policy: OverwriteExisting, written: true, expected: content},
+		{policy: KeepExisting, written: true, expected: content},
+		{policy: KeepExisting, skipExisting: true, written: true, expected: content},
+		{policy: FailIfExists, dryRun: true, written: true},
+		{policy: AskIfExists, dryRun: true, written: true},
+		{policy: OverwriteExisting, dryRun: true, written: true},
+		{policy: KeepExisting, dryRun: true, written: true},
+
+		{policy: AskIfExists, exists: true, answer: "yes\n", written: true, expected: content},
+		{policy: AskIfExists, exists: true, answer: "no\n", expected: old},
+		{policy: OverwriteExisting, exists: true, written: true, expected: content},
+		{policy: KeepExisting, exists: true, expected: old},
+
+		// -force overwrites whatever the policy
+		{policy: FailIfExists, exists: true, force: true, written: true, expected: content},
+		{policy: AskIfExists, exists: true, force: true, written: true, expected: content},
+		{policy: KeepExisting, exists: true, force: true, written: true, expected: content},
+
+		// -skip-existing keeps whatever the policy
+		{policy: FailIfExists, exists: true, skipExisting: true, expected: old},
+		{policy: AskIfExists, exists: true, skipExisting: true, expected: old},
+		{policy: OverwriteExisting, exists: true, skipExisting: true, expected: old},
+
+		// -dry-run changes nothing, and reports the files that would be written
+		{policy: FailIfExists, exists: true, dryRun: true, expected: old},
+		{policy: AskIfExists, exists: true, dryRun: true, written: true, expected: old},
+		{policy: OverwriteExisting, exists: true, dryRun: true, written: true, expected: old},
+		{policy: KeepExisting, exists: true, dryRun: true, expected: old},
+		{policy: FailIfExists, exists: true, dryRun: true, force: true, written: true, expected: old},
+	}
+	defer func() { DryRun, Force, SkipExisting = false, false, false }()
+	for _, tt := range tests {
+		name := policies[tt.policy]
+		for flag, set := range map[string]bool{"exists": tt.exists, "dry-run": tt.dryRun, "force": tt.force, "skip-existing": tt.skipExisting} {
+			if set {
+				name += " " + flag
+			}
+		}
+		if tt.answer != "" {
+			name += " " + strings.TrimSpace(tt.answer)
+		}
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "generated")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			fpath := filepath.Join(dir, "sub", "file.txt")
+			if tt.exists {
+				if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(fpath, []byte(old), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			DryRun, Force, SkipExisting = tt.dryRun, tt.force, tt.skipExisting
+			var written bool
+			out := captureStdio(t, tt.answer, func() { written = WriteGeneratedFile(fpath, content, tt.policy) })
+
+			if written != tt.written {
+				t.Errorf("expected written to be %v, got %v", tt.written, written)
+			}
+			got, err := ioutil.ReadFile(fpath)
+			if tt.expected == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("expected no file, got %q", got)
+				}
+				if _, err := os.Stat(filepath.Dir(fpath)); !os.IsNotExist(err) {
+					t.Errorf("expected no directory: %v", err)
+				}
+			} else if string(got) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+			if tt.dryRun && tt.written && !strings.Contains(out, "+new\n") {
+				t.Errorf("expected the diff of the file to be printed, got:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestWriteGeneratedFileIdentical(t *testing.T) {
+	dir, err := ioutil.TempDir("", "generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fpath := filepath.Join(dir, "file.go")
+	// Go sources are formatted before being compared
+	if err := ioutil.WriteFile(fpath, []byte("package a\n\nvar A = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var written bool
+	out := captureStdio(t, "", func() { written = WriteGeneratedFile(fpath, "package a\nvar A   = 1", FailIfExists) })
+	if written || !strings.Contains(out, "identical") {
+		t.Errorf("expected the file to be identical, got written %v:\n%s", written, out)
+	}
+}
+
+func TestWriteGeneratedFileMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	merged, overwritten := filepath.Join(dir, "models", "a.txt"), filepath.Join(dir, "b.txt")
+	captureStdio(t, "", func() {
+		WriteGeneratedFile(merged, "a\nb\nc\n", MergeExisting)
+		WriteGeneratedFile(overwritten, "a\n", OverwriteExisting)
+	})
+	if _, err := os.Stat(filepath.Join(dir, GeneratedCacheDir, "b.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected only the files written with MergeExisting to be cached: %v", err)
+	}
+
+	// the changes made since the file was generated are merged with the new version
+	if err := ioutil.WriteFile(merged, []byte("a\nb\nc\nmine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	captureStdio(t, "", func() { WriteGeneratedFile(merged, "A\nb\nc\n", MergeExisting) })
+	if got, _ := ioutil.ReadFile(merged); string(got) != "A\nb\nc\nmine\n" {
+		t.Errorf("expected the changes to be merged, got %q", got)
+	}
+
+	// a file generated without MergeExisting is not merged but asked for
+	if err := ioutil.WriteFile(overwritten, []byte("mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	captureStdio(t, "no\n", func() { WriteGeneratedFile(overwritten, "b\n", MergeExisting) })
+	if got, _ := ioutil.ReadFile(overwritten); string(got) != "mine\n" {
+		t.Errorf("expected the file to be kept, got %q", got)
+	}
+}
+
+func TestPrintFileDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		content  string
+		expected string
+	}{
+		{"new file", "", "a\n", "--- /dev/null\n+++ b/x/file.txt\n@@ -0,0 +1 @@\n+a\n"},
+		{"changed", "a\nb\n", "a\nc\n", "--- a/x/file.txt\n+++ b/x/file.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"},
+		{"identical", "a\n", "a\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			PrintFileDiff(&b, filepath.Join("x", "file.txt"), tt.old, tt.content)
+			if b.String() != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+)
+
+// GeneratedCacheDir is the directory, at the root of the application, where the
+// generators keep the last version they wrote of the files written with MergeExisting
+const GeneratedCacheDir = ".bee/generated"
+
+var (
+	regionBeginRegex = regexp.MustCompile(`bee:begin\s+(\S+)`)
+	regionEndRegex   = regexp.MustCompile(`bee:end\b`)
+)
+
+// Markers of the conflicts left in a file by MergeGenerated
+const (
+	conflictExisting  = "<<<<<<< existing\n"
+	conflictSeparator = "=======\n"
+	conflictGenerated = ">>>>>>> generated\n"
+)
+
+// KeepCustomRegions copies the content of the protected regions of the existing file,
+// the lines between "bee:begin <name>" and "bee:end", into the regions of the same
+// name of the generated content
+func KeepCustomRegions(fpath, existing, content string) string {
+	regions := customRegions(existing)
+	if len(regions) == 0 {
+		return content
+	}
+
+	var b strings.Builder
+	lines := splitMergeLines(content)
+	for i := 0; i < len(lines); i++ {
+		b.WriteString(lines[i])
+		m := regionBeginRegex.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		body, ok := regions[m[1]]
+		if !ok {
+			continue
+		}
+		delete(regions, m[1])
+		end := i + 1
+		for end < len(lines) && !regionEndRegex.MatchString(lines[end]) {
+			end++
+		}
+		if end == len(lines) {
+			beeLogger.Log.Warnf("Region '%s' of '%s' has no bee:end in the generated content, its content is dropped", m[1], fpath)
+			continue
+		}
+		b.WriteString(body)
+		i = end - 1
+	}
+	for name := range regions {
+		beeLogger.Log.Warnf("Region '%s' of '%s' is no longer generated, its content is dropped", name, fpath)
+	}
+	return strings.TrimSuffix(b.String(), "\n") + trailingNewline(content)
+}
+
+// customRegions returns the content of the protected regions of a file, by name
+func customRegions(content string) map[string]string {
+	regions := make(map[string]string)
+	var name string
+	var body strings.Builder
+	inRegion := false
+	for _, line := range splitMergeLines(content) {
+		if !inRegion {
+			if m := regionBeginRegex.FindStringSubmatch(line); m != nil {
+				name, inRegion = m[1], true
+				body.Reset()
+			}
+			continue
+		}
+		if regionEndRegex.MatchString(line) {
+			regions[name] = body.String()
+			inRegion = false
+			continue
+		}
+		body.WriteString(line)
+	}
+	return regions
+}
+
+// MergeGenerated merges the changes made to a generated file since its base, the
+// version the generator wrote last, with the new generated content. Changes to the
+// same lines are conflicts, kept between markers. It reports whether the merge
+// has conflicts.
+func MergeGenerated(base, existing, content string) (string, bool) {
+	z, a, b := splitMergeLines(base), splitMergeLines(existing), splitMergeLines(content)
+
+	var out strings.Builder
+	conflicts := false
+	iz, ia, ib := 0, 0, 0
+	for _, r := range syncRegions(z, a, b) {
+		za, aa, ba := z[iz:r.base], a[ia:r.a], b[ib:r.b]
+		switch {
+		case equalLines(aa, ba), equalLines(za, ba):
+			writeLines(&out, aa)
+		case equalLines(za, aa):
+			writeLines(&out, ba)
+		default:
+			conflicts = true
+			out.WriteString(conflictExisting)
+			writeLines(&out, aa)
+			out.WriteString(conflictSeparator)
+			writeLines(&out, ba)
+			out.WriteString(conflictGenerated)
+		}
+		writeLines(&out, z[r.base:r.base+r.size])
+		iz, ia, ib = r.base+r.size, r.a+r.size, r.b+r.size
+	}
+	return strings.TrimSuffix(out.String(), "\n") + trailingNewline(content), conflicts
+}
+
+// syncRegion is a range of lines unchanged in both versions of a file since their base
+type syncRegion struct {
+	base, a, b, size int
+}
+
+// syncRegions returns the ranges of the base unchanged in both a and b, ending with
+// an empty range at the end of the three files
+func syncRegions(z, a, b []string) []syncRegion {
+	am := difflib.NewMatcherWithJunk(z, a, false, nil).GetMatchingBlocks()
+	bm := difflib.NewMatcherWithJunk(z, b, false, nil).GetMatchingBlocks()
+
+	var regions []syncRegion
+	for i, j := 0, 0; i < len(am) && j < len(bm); {
+		ma, mb := am[i], bm[j]
+		start, end := ma.A, ma.A+ma.Size
+		if mb.A > start {
+			start = mb.A
+		}
+		if mb.A+mb.Size < end {
+			end = mb.A + mb.Size
+		}
+		if start < end {
+			regions = append(regions, syncRegion{
+				base: start,
+				a:    ma.B + start - ma.A,
+				b:    mb.B + start - mb.A,
+				size: end - start,
+			})
+		}
+		if ma.A+ma.Size < mb.A+mb.Size {
+			i++
+		} else {
+			j++
+		}
+	}
+	return append(regions, syncRegion{base: len(z), a: len(a), b: len(b)})
+}
+
+// splitMergeLines splits a file into lines, all ending with a newline
+func splitMergeLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLines(b *strings.Builder, lines []string) {
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+}
+
+func trailingNewline(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return "\n"
+	}
+	return ""
+}
+
+// generatedBasePath returns the path of the last generated version of a file, in the
+// cache directory of the closest directory with a go.mod, or else of the current one
+func generatedBasePath(fpath string) string {
+	fpath, _ = filepath.Abs(fpath)
+	root, _ := os.Getwd()
+	for dir := filepath.Dir(fpath); ; dir = filepath.Dir(dir) {
+		if IsExist(filepath.Join(dir, "go.mod")) {
+			root = dir
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	rel, err := filepath.Rel(root, fpath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.Join(root, filepath.FromSlash(GeneratedCacheDir), rel)
+}
+
+// readGeneratedBase returns the last generated version of a file, if it was cached
+func readGeneratedBase(fpath string) (string, bool) {
+	bpath := generatedBasePath(fpath)
+	if bpath == "" {
+		return "", false
+	}
+	base, err := ioutil.ReadFile(bpath)
+	if err != nil {
+		return "", false
+	}
+	return string(base), true
+}
+
+// saveGeneratedBase caches the generated version of a file, to merge the next one with
+func saveGeneratedBase(fpath, content string) {
+	bpath := generatedBasePath(fpath)
+	if bpath == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(bpath), 0755); err != nil {
+		beeLogger.Log.Warnf("Could not cache '%s': %s", fpath, err)
+		return
+	}
+	if err := ioutil.WriteFile(bpath, []byte(content), 0666); err != nil {
+		beeLogger.Log.Warnf("Could not cache '%s': %s", fpath, err)
+	}
+}
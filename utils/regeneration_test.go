@@ -0,0 +1,70 @@
+// Copyright 2013 bee authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package utils
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	beeLogger "github.com/beego/bee/v2/logger"
+)
+
+func TestKeepCustomRegions(t *testing.T) {
+	existing := "package models\n\n// bee:begin custom\nfunc Custom() {}\n// bee:end\n"
+	content := "package models\n\ntype User struct{}\n\n// bee:begin custom\n// bee:end\n"
+	expected := "package models\n\ntype User struct{}\n\n// bee:begin custom\nfunc Custom() {}\n// bee:end\n"
+	if got := KeepCustomRegions("user.go", existing, content); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	// a region left open in the generated content can not be filled
+	var buf bytes.Buffer
+	beeLogger.Log.SetOutput(&buf)
+	defer beeLogger.Log.SetOutput(os.Stdout)
+	content = "package models\n\n// bee:begin custom\n"
+	if got := KeepCustomRegions("user.go", existing, content); got != content {
+		t.Errorf("expected the generated content, got:\n%s", got)
+	}
+	if !strings.Contains(buf.String(), "Region 'custom' of 'user.go' has no bee:end") {
+		t.Errorf("expected a warning, got %q", buf.String())
+	}
+}
+
+func TestMergeGenerated(t *testing.T) {
+	base := "a\nb\nc\nd\n"
+	tests := []struct {
+		name      string
+		existing  string
+		content   string
+		expected  string
+		conflicts bool
+	}{
+		{"unchanged", base, "a\nB\nc\nd\n", "a\nB\nc\nd\n", false},
+		{"edited", "a\nb\nc\nd\ne\n", base, "a\nb\nc\nd\ne\n", false},
+		{"both", "a\nb\nc\nD\n", "A\nb\nc\nd\n", "A\nb\nc\nD\n", false},
+		{"same change", "a\nB\nc\nd\n", "a\nB\nc\nd\n", "a\nB\nc\nd\n", false},
+		{"conflict", "a\nx\nc\nd\n", "a\ny\nc\nd\n", "a\n<<<<<<< existing\nx\n=======\ny\n>>>>>>> generated\nc\nd\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := MergeGenerated(base, tt.existing, tt.content)
+			if got != tt.expected || conflicts != tt.conflicts {
+				t.Errorf("expected (conflicts %v):\n%s\ngot (conflicts %v):\n%s", tt.conflicts, tt.expected, conflicts, got)
+			}
+		})
+	}
+}